@@ -13,6 +13,10 @@ type Config struct {
 	HealthCheckPath *string                        `mapstructure:"healthCheckPath"`
 	Databases       map[string]ConfigDynamodbTable `mapstructure:"dynamodb"`
 	Buckets         map[string]ConfigS3Bucket      `mapstructure:"buckets"`
+	Renditions      ConfigRenditions               `mapstructure:"renditions"`
+	Encoding        ConfigEncoding                 `mapstructure:"encoding"`
+	QualityGate     ConfigQualityGate              `mapstructure:"qualityGate"`
+	Admins          []string                       `mapstructure:"admins"`
 }
 
 type ConfigDynamodbTable struct {
@@ -27,6 +31,28 @@ type ConfigS3Bucket struct {
 	Name *string `mapstructure:"name"`
 }
 
+// resized copies of the pictures served on demand
+type ConfigRenditions struct {
+	Widths []int `mapstructure:"widths"`
+}
+
+// format of the files written by the backend
+type ConfigEncoding struct {
+	NormalizeFormat string `mapstructure:"normalizeFormat"`
+	Quality         int    `mapstructure:"quality"`
+	StripMetadata   bool   `mapstructure:"stripMetadata"`
+}
+
+// limits of the pictures accepted on ingestion
+type ConfigQualityGate struct {
+	MinWidth       int     `mapstructure:"minWidth"`
+	MinHeight      int     `mapstructure:"minHeight"`
+	MinBlur        float64 `mapstructure:"minBlur"`
+	MaxDarkRatio   float64 `mapstructure:"maxDarkRatio"`
+	MaxBrightRatio float64 `mapstructure:"maxBrightRatio"`
+	Action         string  `mapstructure:"action"`
+}
+
 func ReadConfigFile(path string) (*Config, error) {
 	f, err := os.ReadFile(path)
 	if err != nil {
@@ -69,5 +95,27 @@ func ReadConfigFile(path string) (*Config, error) {
 		}
 	}
 
+	for _, width := range c.Renditions.Widths {
+		if width <= 0 {
+			return nil, fmt.Errorf("rendition width must be positive: %d", width)
+		}
+	}
+
+	switch c.Encoding.NormalizeFormat {
+	case "", "jpg", "png", "gif", "tiff":
+	default:
+		return nil, fmt.Errorf("normalize format not available: %s", c.Encoding.NormalizeFormat)
+	}
+
+	if c.Encoding.Quality < 1 || c.Encoding.Quality > 100 {
+		return nil, fmt.Errorf("encoding quality must be between 1 and 100: %d", c.Encoding.Quality)
+	}
+
+	switch c.QualityGate.Action {
+	case "", "reject", "block":
+	default:
+		return nil, fmt.Errorf("quality gate action not available: %s", c.QualityGate.Action)
+	}
+
 	return &c, nil
 }
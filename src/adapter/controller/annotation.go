@@ -0,0 +1,385 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+
+	"golang.org/x/exp/slices"
+)
+
+// boxes of two annotators are the same object when their IoU reaches it
+const annotationMatchIoU = 0.5
+
+// creates or replaces the pending set of the annotator, a reviewed set cannot be changed
+func (c ControllerPicture) UpdatePictureAnnotationSet(ctx context.Context, primaryKey string, sortKey model.UUID, version int, annotator string, tags []controllerModel.PictureTag) error {
+	if annotator == "" || annotator == controllerModel.AuditActorUnknown {
+		return fmt.Errorf("an annotation set requires an annotator")
+	}
+	picture, err := c.readPictureVersion(ctx, controllerModel.PictureStateProcess, primaryKey, sortKey, version)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range tags {
+		tags[i].ID = model.NewUUID()
+		tags[i].CreationDate = now
+		tags[i].OriginName = annotator
+	}
+	labeled := *picture
+	labeled.Tags = tags
+	errs, err := ValidatorBoxBounds{}.Validate(ctx, labeled)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return controllerModel.ValidationErrors(errs)
+	}
+
+	newPicture := *picture
+	newPicture.AnnotationSets = slices.Clone(picture.AnnotationSets)
+	var before any
+	idx := slices.IndexFunc(newPicture.AnnotationSets, func(set controllerModel.AnnotationSet) bool { return set.Annotator == annotator })
+	if idx == -1 {
+		newPicture.AnnotationSets = append(newPicture.AnnotationSets, controllerModel.AnnotationSet{
+			ID:           model.NewUUID(),
+			Annotator:    annotator,
+			Status:       controllerModel.AnnotationSetPending,
+			CreationDate: now,
+		})
+		idx = len(newPicture.AnnotationSets) - 1
+	} else {
+		before = newPicture.AnnotationSets[idx]
+		if newPicture.AnnotationSets[idx].Status != controllerModel.AnnotationSetPending {
+			return fmt.Errorf("annotation set of %s in picture %s/%s is already %s", annotator, primaryKey, sortKey, newPicture.AnnotationSets[idx].Status)
+		}
+	}
+	newPicture.AnnotationSets[idx].Tags = tags
+
+	audit, err := newPictureAudit(ctx, "UpdatePictureAnnotationSet", primaryKey, sortKey, before, newPicture.AnnotationSets[idx])
+	if err != nil {
+		return err
+	}
+	return c.DynamodbProcess.CreatePicture(ctx, sortKey, newPicture, audit)
+}
+
+// accepts one set, merges several into an accepted one or rejects sets.
+// accepting or merging rejects the other sets still pending and the set accepted before.
+func (c ControllerPicture) UpdatePictureAnnotationReview(ctx context.Context, primaryKey string, sortKey model.UUID, version int, review controllerModel.AnnotationReview) error {
+	if review.Reviewer == "" || review.Reviewer == controllerModel.AuditActorUnknown {
+		return fmt.Errorf("an annotation review requires a reviewer")
+	}
+	picture, err := c.readPictureVersion(ctx, controllerModel.PictureStateProcess, primaryKey, sortKey, version)
+	if err != nil {
+		return err
+	}
+
+	newPicture := *picture
+	newPicture.AnnotationSets = slices.Clone(picture.AnnotationSets)
+	reviewed := make([]int, 0, len(review.SetIDs))
+	for _, setID := range review.SetIDs {
+		idx := slices.IndexFunc(newPicture.AnnotationSets, func(set controllerModel.AnnotationSet) bool { return set.ID == setID })
+		if idx == -1 {
+			return fmt.Errorf("annotation set %s not found in picture %s/%s", setID, primaryKey, sortKey)
+		}
+		set := newPicture.AnnotationSets[idx]
+		if set.Status != controllerModel.AnnotationSetPending {
+			return fmt.Errorf("annotation set %s is already %s", setID, set.Status)
+		}
+		if set.Annotator == review.Reviewer && review.Decision != controllerModel.AnnotationReviewReject {
+			return fmt.Errorf("annotation set %s cannot be accepted by its annotator", setID)
+		}
+		reviewed = append(reviewed, idx)
+	}
+
+	now := time.Now()
+	setStatus := func(idx int, status string) {
+		newPicture.AnnotationSets[idx].Status = status
+		newPicture.AnnotationSets[idx].Reviewer = review.Reviewer
+		newPicture.AnnotationSets[idx].ReviewDate = now
+	}
+	// the sets left out of an accepted result are not used anymore
+	rejectOthers := func() {
+		for i, set := range newPicture.AnnotationSets {
+			if !slices.Contains(reviewed, i) && (set.Status == controllerModel.AnnotationSetPending || set.Status == controllerModel.AnnotationSetAccepted) {
+				setStatus(i, controllerModel.AnnotationSetRejected)
+			}
+		}
+	}
+
+	switch review.Decision {
+	case controllerModel.AnnotationReviewAccept:
+		if len(reviewed) != 1 {
+			return fmt.Errorf("decision %s requires one set, %d given", review.Decision, len(reviewed))
+		}
+		rejectOthers()
+		setStatus(reviewed[0], controllerModel.AnnotationSetAccepted)
+	case controllerModel.AnnotationReviewMerge:
+		if len(reviewed) < 2 {
+			return fmt.Errorf("decision %s requires at least two sets, %d given", review.Decision, len(reviewed))
+		}
+		sets := make([]controllerModel.AnnotationSet, 0, len(reviewed))
+		for _, idx := range reviewed {
+			sets = append(sets, newPicture.AnnotationSets[idx])
+		}
+		rejectOthers()
+		for _, idx := range reviewed {
+			setStatus(idx, controllerModel.AnnotationSetMerged)
+		}
+		newPicture.AnnotationSets = append(newPicture.AnnotationSets, controllerModel.AnnotationSet{
+			ID:           model.NewUUID(),
+			Annotator:    review.Reviewer,
+			Tags:         mergeAnnotationSets(sets),
+			Status:       controllerModel.AnnotationSetAccepted,
+			CreationDate: now,
+			Reviewer:     review.Reviewer,
+			ReviewDate:   now,
+		})
+	case controllerModel.AnnotationReviewReject:
+		if len(reviewed) == 0 {
+			return fmt.Errorf("decision %s requires at least one set", review.Decision)
+		}
+		for _, idx := range reviewed {
+			setStatus(idx, controllerModel.AnnotationSetRejected)
+		}
+	default:
+		return fmt.Errorf("annotation review decision `%s` not available", review.Decision)
+	}
+
+	after := newPicture
+	after.Version++
+	audit, err := newPictureAudit(ctx, "UpdatePictureAnnotationReview", primaryKey, sortKey, *picture, after)
+	if err != nil {
+		return err
+	}
+	return c.DynamodbProcess.CreatePicture(ctx, sortKey, newPicture, audit)
+}
+
+// pictures of the process table labeled by one annotator only, the ones labeled by the annotator are left out
+func (c ControllerPicture) ReadPicturesAnnotationSecond(ctx context.Context, annotator string) ([]controllerModel.Picture, error) {
+	pictures, err := c.DynamodbProcess.ReadPictures(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var second []controllerModel.Picture
+	for _, picture := range pictures {
+		if _, ok := acceptedAnnotationSet(picture); ok {
+			continue
+		}
+		pending := pendingAnnotationSets(picture)
+		if len(pending) == 1 && pending[0].Annotator != annotator {
+			second = append(second, picture)
+		}
+	}
+	return second, nil
+}
+
+// pictures of the process table whose pending sets agree less than the threshold, least agreed first
+func (c ControllerPicture) ReadPicturesAnnotationDisagreement(ctx context.Context, threshold float64) ([]controllerModel.AnnotationAgreement, error) {
+	pictures, err := c.DynamodbProcess.ReadPictures(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var agreements []controllerModel.AnnotationAgreement
+	for _, picture := range pictures {
+		if _, ok := acceptedAnnotationSet(picture); ok {
+			continue
+		}
+		pending := pendingAnnotationSets(picture)
+		if len(pending) < 2 {
+			continue
+		}
+		agreement := annotationAgreement(pending)
+		if agreement >= threshold {
+			continue
+		}
+		agreements = append(agreements, controllerModel.AnnotationAgreement{
+			Origin:    picture.Origin,
+			ID:        picture.ID,
+			Version:   picture.Version,
+			Sets:      len(pending),
+			Agreement: agreement,
+		})
+	}
+	sort.SliceStable(agreements, func(i, j int) bool { return agreements[i].Agreement < agreements[j].Agreement })
+	return agreements, nil
+}
+
+func acceptedAnnotationSet(picture controllerModel.Picture) (controllerModel.AnnotationSet, bool) {
+	idx := slices.IndexFunc(picture.AnnotationSets, func(set controllerModel.AnnotationSet) bool {
+		return set.Status == controllerModel.AnnotationSetAccepted
+	})
+	if idx == -1 {
+		return controllerModel.AnnotationSet{}, false
+	}
+	return picture.AnnotationSets[idx], true
+}
+
+func pendingAnnotationSets(picture controllerModel.Picture) []controllerModel.AnnotationSet {
+	var pending []controllerModel.AnnotationSet
+	for _, set := range picture.AnnotationSets {
+		if set.Status == controllerModel.AnnotationSetPending {
+			pending = append(pending, set)
+		}
+	}
+	return pending
+}
+
+// mean agreement of every pair of sets
+func annotationAgreement(sets []controllerModel.AnnotationSet) float64 {
+	if len(sets) < 2 {
+		return 1
+	}
+	total, pairs := 0., 0
+	for i := range sets {
+		for j := i + 1; j < len(sets); j++ {
+			total += tagsAgreement(sets[i].Tags, sets[j].Tags)
+			pairs++
+		}
+	}
+	return total / float64(pairs)
+}
+
+// twice the IoU of the matched boxes over the amount of boxes of both sets, 1 when neither has a box
+func tagsAgreement(a, b []controllerModel.PictureTag) float64 {
+	boxesA, boxesB := boxTags(a), boxTags(b)
+	if len(boxesA)+len(boxesB) == 0 {
+		return 1
+	}
+	sum := 0.
+	for _, match := range matchBoxes(boxesA, boxesB) {
+		sum += match.iou
+	}
+	return 2 * sum / float64(len(boxesA)+len(boxesB))
+}
+
+func boxTags(tags []controllerModel.PictureTag) []controllerModel.PictureTag {
+	var boxes []controllerModel.PictureTag
+	for _, tag := range tags {
+		if tag.BoxInformation.Valid {
+			boxes = append(boxes, tag)
+		}
+	}
+	return boxes
+}
+
+type boxMatch struct {
+	a   int
+	b   int
+	iou float64
+}
+
+// greedy matching of the boxes with the same name and size, the best IoU first
+func matchBoxes(a, b []controllerModel.PictureTag) []boxMatch {
+	var candidates []boxMatch
+	for i, tagA := range a {
+		for j, tagB := range b {
+			if tagA.Name != tagB.Name || tagA.BoxInformation.Body.PictureSizeID != tagB.BoxInformation.Body.PictureSizeID {
+				continue
+			}
+			iou := boxIoU(tagA.BoxInformation.Body.Box, tagB.BoxInformation.Body.Box)
+			if iou >= annotationMatchIoU {
+				candidates = append(candidates, boxMatch{a: i, b: j, iou: iou})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].iou > candidates[j].iou })
+
+	usedA := map[int]bool{}
+	usedB := map[int]bool{}
+	var matches []boxMatch
+	for _, candidate := range candidates {
+		if usedA[candidate.a] || usedB[candidate.b] {
+			continue
+		}
+		usedA[candidate.a] = true
+		usedB[candidate.b] = true
+		matches = append(matches, candidate)
+	}
+	return matches
+}
+
+func boxIoU(a, b controllerModel.Box) float64 {
+	width := math.Min(float64(a.Tlx+a.Width), float64(b.Tlx+b.Width)) - math.Max(float64(a.Tlx), float64(b.Tlx))
+	height := math.Min(float64(a.Tly+a.Height), float64(b.Tly+b.Height)) - math.Max(float64(a.Tly), float64(b.Tly))
+	if width <= 0 || height <= 0 {
+		return 0
+	}
+	intersection := width * height
+	union := float64(a.Width*a.Height+b.Width*b.Height) - intersection
+	if union <= 0 {
+		return 0
+	}
+	return intersection / union
+}
+
+// the boxes matched between the sets are averaged, the others are kept as they are.
+// the tags without a box are kept once per name.
+func mergeAnnotationSets(sets []controllerModel.AnnotationSet) []controllerModel.PictureTag {
+	type mergedBox struct {
+		tag                     controllerModel.PictureTag
+		tlx, tly, width, height float64
+		count                   int
+	}
+	var merged []mergedBox
+	var others []controllerModel.PictureTag
+	for _, set := range sets {
+		current := make([]controllerModel.PictureTag, 0, len(merged))
+		for _, box := range merged {
+			current = append(current, box.tag)
+		}
+		boxes := boxTags(set.Tags)
+		matched := map[int]bool{}
+		for _, match := range matchBoxes(current, boxes) {
+			matched[match.b] = true
+			box := boxes[match.b].BoxInformation.Body.Box
+			m := &merged[match.a]
+			m.tlx += float64(box.Tlx)
+			m.tly += float64(box.Tly)
+			m.width += float64(box.Width)
+			m.height += float64(box.Height)
+			m.count++
+			n := float64(m.count)
+			m.tag.BoxInformation.Body.Box = controllerModel.Box{
+				Tlx:    int(math.Round(m.tlx / n)),
+				Tly:    int(math.Round(m.tly / n)),
+				Width:  int(math.Round(m.width / n)),
+				Height: int(math.Round(m.height / n)),
+			}
+		}
+		for j, tag := range boxes {
+			if matched[j] {
+				continue
+			}
+			box := tag.BoxInformation.Body.Box
+			merged = append(merged, mergedBox{
+				tag:    tag,
+				tlx:    float64(box.Tlx),
+				tly:    float64(box.Tly),
+				width:  float64(box.Width),
+				height: float64(box.Height),
+				count:  1,
+			})
+		}
+		for _, tag := range set.Tags {
+			if tag.BoxInformation.Valid {
+				continue
+			}
+			if slices.IndexFunc(others, func(other controllerModel.PictureTag) bool { return other.Name == tag.Name }) == -1 {
+				others = append(others, tag)
+			}
+		}
+	}
+
+	tags := make([]controllerModel.PictureTag, 0, len(merged)+len(others))
+	for _, box := range merged {
+		tags = append(tags, box.tag)
+	}
+	return append(tags, others...)
+}
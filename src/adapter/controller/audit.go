@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	interfaceDatabase "scraper-backend/src/driver/interface/database"
+	model "scraper-backend/src/driver/model"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+)
+
+type ControllerAudit struct {
+	Dynamodb interfaceDatabase.DriverDynamodbAudit
+}
+
+// entries of the picture in whatever state it is, oldest first
+func (c ControllerAudit) ReadPictureHistory(ctx context.Context, primaryKey string, sortKey model.UUID) ([]controllerModel.Audit, error) {
+	audits, err := c.Dynamodb.ReadAudits(ctx, controllerModel.AuditEntityPicture, primaryKey, sortKey)
+	if err != nil {
+		return nil, err
+	}
+	sortAudits(audits)
+	return audits, nil
+}
+
+// entries of every entity matching the filter, oldest first
+func (c ControllerAudit) ReadAudits(ctx context.Context, filter controllerModel.AuditFilter) ([]controllerModel.Audit, error) {
+	var conditions []expression.ConditionBuilder
+	if filter.Entity != "" {
+		conditions = append(conditions, expression.Name("Entity").Equal(expression.Value(filter.Entity)))
+	}
+	if filter.Actor != "" {
+		conditions = append(conditions, expression.Name("Actor").Equal(expression.Value(filter.Actor)))
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, expression.Name("Action").Equal(expression.Value(filter.Action)))
+	}
+	var condition *expression.ConditionBuilder
+	switch len(conditions) {
+	case 0:
+	case 1:
+		condition = &conditions[0]
+	default:
+		and := expression.And(conditions[0], conditions[1], conditions[2:]...)
+		condition = &and
+	}
+	audits, err := c.Dynamodb.ScanAudits(ctx, condition)
+	if err != nil {
+		return nil, err
+	}
+
+	// the dates are stored with a variable amount of decimals, they are not compared as strings by the scan
+	filtered := audits[:0]
+	for _, audit := range audits {
+		if !filter.From.IsZero() && audit.CreationDate.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && audit.CreationDate.After(filter.To) {
+			continue
+		}
+		filtered = append(filtered, audit)
+	}
+	sortAudits(filtered)
+	return filtered, nil
+}
+
+func sortAudits(audits []controllerModel.Audit) {
+	sort.SliceStable(audits, func(i, j int) bool { return audits[i].CreationDate.Before(audits[j].CreationDate) })
+}
+
+// entry of a mutation to write with it, before is nil for a creation and after is nil for a deletion
+func newAudit(ctx context.Context, entity string, primaryKey string, sortKey model.UUID, action string, before, after any) (*controllerModel.Audit, error) {
+	beforeDiff, afterDiff, err := auditDiff(before, after)
+	if err != nil {
+		return nil, err
+	}
+	return &controllerModel.Audit{
+		ID:           model.NewUUID(),
+		Entity:       entity,
+		Origin:       primaryKey,
+		EntityID:     sortKey,
+		Action:       action,
+		Actor:        controllerModel.ContextActor(ctx),
+		CreationDate: time.Now().UTC(),
+		Before:       beforeDiff,
+		After:        afterDiff,
+	}, nil
+}
+
+// records a mutation already made, for the ones spanning several items that cannot carry their entry.
+// the mutation is not undone when its entry cannot be written, the error says so.
+func createAudit(ctx context.Context, dynamodb interfaceDatabase.DriverDynamodbAudit, entity string, primaryKey string, sortKey model.UUID, action string, before, after any) error {
+	audit, err := newAudit(ctx, entity, primaryKey, sortKey, action, before, after)
+	if err != nil {
+		return fmt.Errorf("%s of %s %s/%s is done but its audit has failed: %v", action, entity, primaryKey, sortKey, err)
+	}
+	if err := dynamodb.CreateAudit(ctx, *audit); err != nil {
+		return fmt.Errorf("%s of %s %s/%s is done but its audit has failed: %v", action, entity, primaryKey, sortKey, err)
+	}
+	return nil
+}
+
+// JSON of the fields that differ between both values.
+// lists of objects with an ID, e.g. tags, sizes and transitions, are compared by ID.
+func auditDiff(before, after any) (string, string, error) {
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return "", "", err
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return "", "", err
+	}
+	beforeDiff, afterDiff := diffJSON(beforeJSON, afterJSON)
+
+	var beforeBytes, afterBytes []byte
+	if beforeJSON != nil {
+		if beforeBytes, err = json.Marshal(beforeDiff); err != nil {
+			return "", "", err
+		}
+	}
+	if afterJSON != nil {
+		if afterBytes, err = json.Marshal(afterDiff); err != nil {
+			return "", "", err
+		}
+	}
+	return string(beforeBytes), string(afterBytes), nil
+}
+
+// generic JSON of the value, objects are maps and lists are slices
+func auditJSON(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	buffer, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(buffer, &generic); err != nil {
+		return nil, err
+	}
+	return indexJSON(generic), nil
+}
+
+// lists whose elements are all objects with an ID become objects keyed by ID
+func indexJSON(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, element := range typed {
+			typed[key] = indexJSON(element)
+		}
+		return typed
+	case []any:
+		indexed := make(map[string]any, len(typed))
+		for _, element := range typed {
+			object, ok := element.(map[string]any)
+			if !ok {
+				return typed
+			}
+			id, ok := object["ID"].(string)
+			if !ok {
+				return typed
+			}
+			indexed[id] = indexJSON(object)
+		}
+		if len(indexed) != len(typed) {
+			return typed
+		}
+		return indexed
+	default:
+		return value
+	}
+}
+
+// keeps the fields of both objects that are not equal, other values are kept whole
+func diffJSON(before, after any) (any, any) {
+	beforeObject, beforeOk := before.(map[string]any)
+	afterObject, afterOk := after.(map[string]any)
+	if !beforeOk || !afterOk {
+		return before, after
+	}
+	beforeDiff := map[string]any{}
+	afterDiff := map[string]any{}
+	for key, beforeValue := range beforeObject {
+		afterValue, ok := afterObject[key]
+		if !ok {
+			beforeDiff[key] = beforeValue
+			continue
+		}
+		if reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+		beforeDiff[key], afterDiff[key] = diffJSON(beforeValue, afterValue)
+	}
+	for key, afterValue := range afterObject {
+		if _, ok := beforeObject[key]; !ok {
+			afterDiff[key] = afterValue
+		}
+	}
+	return beforeDiff, afterDiff
+}
+
+func newPictureAudit(ctx context.Context, action string, primaryKey string, sortKey model.UUID, before, after any) (*controllerModel.Audit, error) {
+	return newAudit(ctx, controllerModel.AuditEntityPicture, primaryKey, sortKey, action, before, after)
+}
+
+func (c ControllerPicture) auditPicture(ctx context.Context, action string, primaryKey string, sortKey model.UUID, before, after any) error {
+	return createAudit(ctx, c.DynamodbAudit, controllerModel.AuditEntityPicture, primaryKey, sortKey, action, before, after)
+}
+
+func (c ControllerPicture) auditPictureTransfer(ctx context.Context, action string, picture controllerModel.Picture) error {
+	before, after := pictureTransferDiff(picture)
+	return c.auditPicture(ctx, action, picture.Origin, picture.ID, before, after)
+}
+
+// the transferred picture is compared with itself before its last transition, it is stored with the next version
+func pictureTransferDiff(picture controllerModel.Picture) (controllerModel.Picture, controllerModel.Picture) {
+	before := picture
+	before.Transitions = picture.Transitions[:len(picture.Transitions)-1]
+	after := picture
+	after.Version++
+	return before, after
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	interfaceAdapter "scraper-backend/src/adapter/interface"
+	dynamodbTable "scraper-backend/src/driver/database/dynamodb/table"
+	interfaceDatabase "scraper-backend/src/driver/interface/database"
+	model "scraper-backend/src/driver/model"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"golang.org/x/exp/slices"
+)
+
+// blocked tags and users, the same for every source and for the pictures already stored
+type blockPolicy struct {
+	tags  *TagMatcher
+	users []controllerModel.User
+}
+
+// the users trusted or capped are not blocked
+func newBlockPolicy(tags []controllerModel.Tag, users []controllerModel.User) (*blockPolicy, error) {
+	matcher, err := NewTagMatcher(tags)
+	if err != nil {
+		return nil, err
+	}
+	policy := blockPolicy{tags: matcher}
+	for _, user := range users {
+		if userStatus(user) == controllerModel.UserStatusBlocked {
+			policy.users = append(policy.users, user)
+		}
+	}
+	return &policy, nil
+}
+
+func readBlockPolicy(ctx context.Context, controllerTag interfaceAdapter.ControllerTag, controllerUser interfaceAdapter.ControllerUser) (*blockPolicy, error) {
+	tags, err := controllerTag.ReadTags(ctx, dynamodbTable.TagPrimaryKeyBlocked)
+	if err != nil {
+		return nil, err
+	}
+	users, err := controllerUser.ReadUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newBlockPolicy(tags, users)
+}
+
+// why a picture of the user with the tags and texts is blocked, empty when it is not.
+// the texts are matched whole and word by word, like the tags
+func (p blockPolicy) reason(origin, userOriginID string, tags []string, texts ...string) string {
+	if idx := slices.IndexFunc(p.users, func(user controllerModel.User) bool {
+		return user.Origin == origin && user.OriginID == userOriginID
+	}); idx != -1 {
+		return fmt.Sprintf("user `%s` of %s is blocked", p.users[idx].Name, origin)
+	}
+	names := slices.Clone(tags)
+	for _, text := range texts {
+		names = append(names, textWords(text)...)
+	}
+	if matches := p.tags.Match(names); len(matches) > 0 {
+		return fmt.Sprintf("`%s` matches the blocked tag `%s`", matches[0].Name, matches[0].Tag.Name)
+	}
+	return ""
+}
+
+func (p blockPolicy) pictureReason(picture controllerModel.Picture) string {
+	tags := make([]string, 0, len(picture.Tags))
+	for _, tag := range picture.Tags {
+		tags = append(tags, tag.Name)
+	}
+	return p.reason(picture.Origin, picture.User.OriginID, tags, picture.Title, picture.Description)
+}
+
+// the text followed by its words, empty for a blank text
+func textWords(text string) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	words := strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) })
+	return append([]string{text}, words...)
+}
+
+// whether a picture of the host already is in one of the tables
+func pictureExists(ctx context.Context, controllerPicture interfaceAdapter.ControllerPicture, originID string) (bool, error) {
+	projEx := expression.NamesList(expression.Name("OriginID"))
+	filtEx := expression.Name("OriginID").Equal(expression.Value(originID))
+	for _, state := range []string{controllerModel.PictureStateProduction, controllerModel.PictureStateValidation, controllerModel.PictureStateProcess, controllerModel.PictureStateBlocked} {
+		pictures, err := controllerPicture.ReadPictures(ctx, state, &projEx, &filtEx)
+		if err != nil {
+			return false, err
+		}
+		if len(pictures) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// moves the pictures of the process and validation tables matching the blocked tags or users to the blocked table.
+// a picture changed during the sweep is reported as failed and left where it is
+func (c ControllerPicture) SweepPicturesBlocked(ctx context.Context) (*controllerModel.BlockSweep, error) {
+	sweep := controllerModel.BlockSweep{Actor: controllerModel.ContextActor(ctx), StartDate: time.Now(), Pictures: []controllerModel.BlockSweepPicture{}}
+	tags, err := c.DynamodbTag.ReadTags(ctx, dynamodbTable.TagPrimaryKeyBlocked)
+	if err != nil {
+		return nil, err
+	}
+	users, err := c.DynamodbUser.ScanUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := newBlockPolicy(tags, users)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, state := range []string{controllerModel.PictureStateProcess, controllerModel.PictureStateValidation} {
+		pictures, err := c.ReadPictures(ctx, state, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		sweep.Checked += len(pictures)
+		for _, picture := range pictures {
+			reason := policy.pictureReason(picture)
+			if reason == "" {
+				continue
+			}
+			result := controllerModel.BlockSweepPicture{Origin: picture.Origin, ID: picture.ID, From: state, Reason: reason, Status: controllerModel.PictureBulkStatusDone}
+			if err := c.CreatePictureBlocked(ctx, picture.Origin, picture.ID, picture.Version, state, sweep.Actor, reason); err != nil {
+				result.Status = controllerModel.PictureBulkStatusFailed
+				result.Message = err.Error()
+			}
+			sweep.Pictures = append(sweep.Pictures, result)
+		}
+	}
+	sweep.EndDate = time.Now()
+	return &sweep, nil
+}
+
+// sweeps the pictures once the request is answered, the report is appended to the audit log of the blocked tag or user
+func sweepPicturesBlocked(ctx context.Context, controllerPicture interfaceAdapter.ControllerPicture, dynamodbAudit interfaceDatabase.DriverDynamodbAudit, entity, primaryKey string, sortKey model.UUID) {
+	ctx = controllerModel.ContextWithActor(context.Background(), controllerModel.ContextActor(ctx))
+	go func() {
+		sweep, err := controllerPicture.SweepPicturesBlocked(ctx)
+		if err != nil {
+			sweep = &controllerModel.BlockSweep{Actor: controllerModel.ContextActor(ctx), Message: err.Error()}
+		}
+		// nobody waits for the sweep, a report that cannot be written is lost and the sweep-blocked job runs it again
+		_ = createAudit(ctx, dynamodbAudit, entity, primaryKey, sortKey, "SweepPicturesBlocked", nil, *sweep)
+	}()
+}
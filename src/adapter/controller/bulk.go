@@ -0,0 +1,276 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// maximum amount of pictures processed at the same time by a bulk operation
+const bulkConcurrency = 8
+
+func (c ControllerPicture) UpdatePicturesBulk(ctx context.Context, bulk controllerModel.PictureBulk) ([]controllerModel.PictureBulkResult, error) {
+	if bulk.From == "" {
+		bulk.From = controllerModel.PictureStateProcess
+	}
+	if _, err := c.driverDynamodbMap(bulk.From); err != nil {
+		return nil, err
+	}
+
+	var prepare func(ctx context.Context, key controllerModel.PictureKey) (*controllerModel.Picture, string, error)
+	var apply func(ctx context.Context, pictures []controllerModel.Picture) error
+	var audit func(ctx context.Context, picture controllerModel.Picture) error
+	switch bulk.Operation {
+	case controllerModel.PictureBulkTransfer, controllerModel.PictureBulkBlock, controllerModel.PictureBulkUnblock:
+		transition := controllerModel.PictureTransition{
+			From:     bulk.From,
+			To:       bulk.To,
+			Actor:    bulk.Actor,
+			Reason:   bulk.Reason,
+			Override: bulk.Override,
+		}
+		switch bulk.Operation {
+		case controllerModel.PictureBulkBlock:
+			transition.To = controllerModel.PictureStateBlocked
+		case controllerModel.PictureBulkUnblock:
+			transition.From = controllerModel.PictureStateBlocked
+			transition.To = controllerModel.PictureStateProcess
+		}
+		if err := checkPictureTransition(transition); err != nil {
+			return nil, err
+		}
+		if err := c.checkPictureOverride(ctx, transition); err != nil {
+			return nil, err
+		}
+		// the pictures as read are kept to be put back if the transfer fails
+		var mutex sync.Mutex
+		originals := map[controllerModel.PictureKey]controllerModel.Picture{}
+		prepare = func(ctx context.Context, key controllerModel.PictureKey) (*controllerModel.Picture, string, error) {
+			original, err := c.readPicture(ctx, transition.From, key.Origin, key.ID)
+			if err != nil {
+				return nil, "", err
+			}
+			picture, err := c.preparePictureTransfer(ctx, *original, transition)
+			if err != nil {
+				return nil, "", err
+			}
+			mutex.Lock()
+			originals[key] = *original
+			mutex.Unlock()
+			return picture, fmt.Sprintf("moved from %s to %s", transition.From, transition.To), nil
+		}
+		apply = func(ctx context.Context, pictures []controllerModel.Picture) error {
+			read := make([]controllerModel.Picture, 0, len(pictures))
+			for _, picture := range pictures {
+				read = append(read, originals[controllerModel.PictureKey{Origin: picture.Origin, ID: picture.ID}])
+			}
+			return c.transferPictures(ctx, read, pictures, transition.From, transition.To)
+		}
+		audit = func(ctx context.Context, picture controllerModel.Picture) error {
+			return c.auditPictureTransfer(ctx, "UpdatePicturesBulk", picture)
+		}
+	case controllerModel.PictureBulkDelete:
+		prepare = func(ctx context.Context, key controllerModel.PictureKey) (*controllerModel.Picture, string, error) {
+			picture, err := c.readPicture(ctx, bulk.From, key.Origin, key.ID)
+			if err != nil {
+				return nil, "", err
+			}
+			return picture, fmt.Sprintf("deleted from %s with its file", bulk.From), nil
+		}
+		apply = func(ctx context.Context, pictures []controllerModel.Picture) error {
+			return c.deletePictures(ctx, pictures, bulk.From)
+		}
+		audit = func(ctx context.Context, picture controllerModel.Picture) error {
+			return c.auditPicture(ctx, "UpdatePicturesBulk", picture.Origin, picture.ID, picture, nil)
+		}
+	case controllerModel.PictureBulkAddTag, controllerModel.PictureBulkRemoveTag:
+		// tags can only be edited in the process table
+		if bulk.From != controllerModel.PictureStateProcess {
+			return nil, fmt.Errorf("tags can only be edited in %s", controllerModel.PictureStateProcess)
+		}
+		tagName := strings.ToLower(bulk.TagName)
+		if tagName == "" {
+			return nil, fmt.Errorf("operation %s requires a tag name", bulk.Operation)
+		}
+		add := bulk.Operation == controllerModel.PictureBulkAddTag
+		// tags are updated picture by picture, nothing is left for the batch
+		prepare = func(ctx context.Context, key controllerModel.PictureKey) (*controllerModel.Picture, string, error) {
+			picture, err := c.readPicture(ctx, bulk.From, key.Origin, key.ID)
+			if err != nil {
+				return nil, "", err
+			}
+			idx := slices.IndexFunc(picture.Tags, func(tag controllerModel.PictureTag) bool { return tag.Name == tagName })
+			if add && idx != -1 {
+				return nil, fmt.Sprintf("tag `%s` already present", tagName), nil
+			}
+			if !add && idx == -1 {
+				return nil, fmt.Sprintf("tag `%s` not present", tagName), nil
+			}
+			if add {
+				if !bulk.DryRun {
+					tag := controllerModel.PictureTag{
+						ID:           model.NewUUID(),
+						Name:         tagName,
+						CreationDate: time.Now(),
+						OriginName:   bulk.Actor,
+					}
+					if err := c.CreatePictureTag(ctx, picture.Origin, picture.ID, picture.Version, tag.ID, tag); err != nil {
+						return nil, "", err
+					}
+				}
+				return nil, fmt.Sprintf("tag `%s` added", tagName), nil
+			}
+			if !bulk.DryRun {
+				// every tag written increments the version
+				version := picture.Version
+				for _, tag := range picture.Tags {
+					if tag.Name != tagName {
+						continue
+					}
+					if err := c.DeletePictureTag(ctx, picture.Origin, picture.ID, version, tag.ID); err != nil {
+						return nil, "", err
+					}
+					version++
+				}
+			}
+			return nil, fmt.Sprintf("tag `%s` removed", tagName), nil
+		}
+	default:
+		return nil, fmt.Errorf("bulk operation `%s` not available", bulk.Operation)
+	}
+
+	// prepare every picture with bounded concurrency
+	results := make([]controllerModel.PictureBulkResult, len(bulk.Pictures))
+	pictures := make([]*controllerModel.Picture, len(bulk.Pictures))
+	semaphore := make(chan struct{}, bulkConcurrency)
+	var wg sync.WaitGroup
+	seen := map[controllerModel.PictureKey]struct{}{}
+	for i, key := range bulk.Pictures {
+		// a picture written twice in the same transaction would fail the whole batch
+		if _, ok := seen[key]; ok {
+			results[i] = controllerModel.PictureBulkResult{Origin: key.Origin, ID: key.ID, Status: controllerModel.PictureBulkStatusSkipped, Message: "repeated in the request"}
+			continue
+		}
+		seen[key] = struct{}{}
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, key controllerModel.PictureKey) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			results[i] = controllerModel.PictureBulkResult{Origin: key.Origin, ID: key.ID}
+			picture, message, err := prepare(ctx, key)
+			switch {
+			case err != nil:
+				results[i].Status = controllerModel.PictureBulkStatusFailed
+				results[i].Message = err.Error()
+			case bulk.DryRun:
+				results[i].Status = controllerModel.PictureBulkStatusDryRun
+				results[i].Message = message
+			default:
+				results[i].Status = controllerModel.PictureBulkStatusDone
+				results[i].Message = message
+				pictures[i] = picture
+			}
+		}(i, key)
+	}
+	wg.Wait()
+
+	if bulk.DryRun || apply == nil {
+		return results, nil
+	}
+
+	// apply the prepared pictures in batches
+	var indexes []int
+	var batch []controllerModel.Picture
+	for i, picture := range pictures {
+		if picture != nil {
+			indexes = append(indexes, i)
+			batch = append(batch, *picture)
+		}
+	}
+	if err := apply(ctx, batch); err != nil {
+		for _, i := range indexes {
+			results[i].Status = controllerModel.PictureBulkStatusFailed
+			results[i].Message = err.Error()
+		}
+		return results, nil
+	}
+	for j, i := range indexes {
+		if err := audit(ctx, batch[j]); err != nil {
+			results[i].Status = controllerModel.PictureBulkStatusFailed
+			results[i].Message = err.Error()
+		}
+	}
+	return results, nil
+}
+
+// moves the prepared pictures, the originals are the same pictures as read from the source table
+func (c ControllerPicture) transferPictures(ctx context.Context, originals []controllerModel.Picture, pictures []controllerModel.Picture, from, to string) error {
+	fromDynamodb, err := c.driverDynamodbMap(from)
+	if err != nil {
+		return err
+	}
+	toDynamodb, err := c.driverDynamodbMap(to)
+	if err != nil {
+		return err
+	}
+	// the copies are written with the next version
+	copies := make([]controllerModel.Picture, 0, len(pictures))
+	for _, picture := range pictures {
+		picture.Version++
+		copies = append(copies, picture)
+	}
+	if err := toDynamodb.CreatePictures(ctx, pictures); err != nil {
+		return compensate(err, func() error { return toDynamodb.DeletePictures(ctx, copies) })
+	}
+	if err := fromDynamodb.DeletePictures(ctx, pictures); err != nil {
+		// the pictures already deleted are put back as they were read before removing the copies
+		return compensate(err, func() error {
+			var deleted []controllerModel.Picture
+			for _, original := range originals {
+				current, err := fromDynamodb.ReadPicture(ctx, original.Origin, original.ID)
+				if err != nil {
+					return err
+				}
+				if current == nil {
+					deleted = append(deleted, original)
+				}
+			}
+			if err := fromDynamodb.CreatePictures(ctx, deleted); err != nil {
+				return err
+			}
+			return toDynamodb.DeletePictures(ctx, copies)
+		})
+	}
+	return nil
+}
+
+func (c ControllerPicture) deletePictures(ctx context.Context, pictures []controllerModel.Picture, state string) error {
+	dynamodb, err := c.driverDynamodbMap(state)
+	if err != nil {
+		return err
+	}
+	if err := dynamodb.DeletePictures(ctx, pictures); err != nil {
+		return compensate(err, func() error { return dynamodb.CreatePictures(ctx, pictures) })
+	}
+	for i, picture := range pictures {
+		started, err := c.deletePictureFiles(ctx, picture)
+		if err == nil {
+			continue
+		}
+		// the rows of the pictures whose files are all still there are put back
+		restored := pictures[i:]
+		if started {
+			restored = pictures[i+1:]
+		}
+		return compensate(err, func() error { return dynamodb.CreatePictures(ctx, restored) })
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package controller
+
+import (
+	"fmt"
+)
+
+// undoes the steps already done when a multi-step operation fails
+func compensate(err error, undo func() error) error {
+	if undoErr := undo(); undoErr != nil {
+		return fmt.Errorf("%v, compensation has failed: %v", err, undoErr)
+	}
+	return err
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	interfaceAdapter "scraper-backend/src/adapter/interface"
+	interfaceDatabase "scraper-backend/src/driver/interface/database"
 	"scraper-backend/src/util"
 
 	driverDynamodb "scraper-backend/src/driver/database/dynamodb"
@@ -10,9 +11,32 @@ import (
 )
 
 func ConstructorPicture(cfg util.Config) interfaceAdapter.ControllerPicture {
+	s3 := driverBucket.Constructor(cfg.AwsS3Client)
+	dynamodbTag := driverDynamodb.ConstructorTag(
+		cfg.AwsDynamodbClient,
+		cfg.AwsDynamodbTableTag.TableName,
+		cfg.AwsDynamodbTableTag.PrimaryKeyName,
+		cfg.AwsDynamodbTableTag.PrimaryKeyType,
+		*cfg.AwsDynamodbTableTag.SortKeyName,
+		*cfg.AwsDynamodbTableTag.SortKeyType,
+		cfg.AwsDynamodbTableAudit.TableName,
+		cfg.AwsDynamodbTableAudit.PrimaryKeyName,
+	)
 	return &ControllerPicture{
-		S3:         driverBucket.Constructor(cfg.AwsS3Client),
-		BucketName: cfg.S3BucketNamePictures,
+		S3:              s3,
+		BucketName:      cfg.S3BucketNamePictures,
+		RenditionWidths: cfg.RenditionWidths,
+		NormalizeFormat: cfg.EncodingNormalizeFormat,
+		Quality:         cfg.EncodingQuality,
+		StripMetadata:   cfg.EncodingStripMetadata,
+		QualityGate: QualityGate{
+			MinWidth:       cfg.QualityGate.MinWidth,
+			MinHeight:      cfg.QualityGate.MinHeight,
+			MinBlur:        cfg.QualityGate.MinBlur,
+			MaxDarkRatio:   cfg.QualityGate.MaxDarkRatio,
+			MaxBrightRatio: cfg.QualityGate.MaxBrightRatio,
+			Block:          cfg.QualityGate.Action == "block",
+		},
 		DynamodbProcess: driverDynamodb.ConstructorPicture(
 			cfg.AwsDynamodbClient,
 			cfg.AwsDynamodbTablePictureProcess.TableName,
@@ -20,6 +44,8 @@ func ConstructorPicture(cfg util.Config) interfaceAdapter.ControllerPicture {
 			cfg.AwsDynamodbTablePictureProcess.PrimaryKeyType,
 			*cfg.AwsDynamodbTablePictureProcess.SortKeyName,
 			*cfg.AwsDynamodbTablePictureProcess.SortKeyType,
+			cfg.AwsDynamodbTableAudit.TableName,
+			cfg.AwsDynamodbTableAudit.PrimaryKeyName,
 		),
 		DynamodbValidation: driverDynamodb.ConstructorPicture(
 			cfg.AwsDynamodbClient,
@@ -28,6 +54,8 @@ func ConstructorPicture(cfg util.Config) interfaceAdapter.ControllerPicture {
 			cfg.AwsDynamodbTablePictureValidation.PrimaryKeyType,
 			*cfg.AwsDynamodbTablePictureValidation.SortKeyName,
 			*cfg.AwsDynamodbTablePictureValidation.SortKeyType,
+			cfg.AwsDynamodbTableAudit.TableName,
+			cfg.AwsDynamodbTableAudit.PrimaryKeyName,
 		),
 		DynamodbProduction: driverDynamodb.ConstructorPicture(
 			cfg.AwsDynamodbClient,
@@ -36,6 +64,8 @@ func ConstructorPicture(cfg util.Config) interfaceAdapter.ControllerPicture {
 			cfg.AwsDynamodbTablePictureProduction.PrimaryKeyType,
 			*cfg.AwsDynamodbTablePictureProduction.SortKeyName,
 			*cfg.AwsDynamodbTablePictureProduction.SortKeyType,
+			cfg.AwsDynamodbTableAudit.TableName,
+			cfg.AwsDynamodbTableAudit.PrimaryKeyName,
 		),
 		DynamodbBlocked: driverDynamodb.ConstructorPicture(
 			cfg.AwsDynamodbClient,
@@ -44,7 +74,23 @@ func ConstructorPicture(cfg util.Config) interfaceAdapter.ControllerPicture {
 			cfg.AwsDynamodbTablePictureBlocked.PrimaryKeyType,
 			*cfg.AwsDynamodbTablePictureBlocked.SortKeyName,
 			*cfg.AwsDynamodbTablePictureBlocked.SortKeyType,
+			cfg.AwsDynamodbTableAudit.TableName,
+			cfg.AwsDynamodbTableAudit.PrimaryKeyName,
 		),
+		DynamodbTag:   dynamodbTag,
+		DynamodbUser:  constructorDynamodbUser(cfg),
+		DynamodbAudit: constructorDynamodbAudit(cfg),
+		Validators: []PictureValidator{
+			ValidatorBoxRequired{},
+			ValidatorBoxBounds{},
+			ValidatorBoxMinimumSize{Width: boxMinimumSize, Height: boxMinimumSize},
+			ValidatorTagSearched{Dynamodb: dynamodbTag},
+			ValidatorAnnotationAccepted{},
+			ValidatorLicense{Licenses: licensesAvailable},
+			ValidatorFile{S3: s3, BucketName: cfg.S3BucketNamePictures},
+		},
+		Admins:      cfg.Admins,
+		sampleCache: newSampleCache(),
 	}
 }
 
@@ -57,24 +103,66 @@ func ConstructorTag(cfg util.Config, controllerPicture interfaceAdapter.Controll
 			cfg.AwsDynamodbTableTag.PrimaryKeyType,
 			*cfg.AwsDynamodbTableTag.SortKeyName,
 			*cfg.AwsDynamodbTableTag.SortKeyType,
+			cfg.AwsDynamodbTableAudit.TableName,
+			cfg.AwsDynamodbTableAudit.PrimaryKeyName,
 		),
+		DynamodbAudit:     constructorDynamodbAudit(cfg),
 		ControllerPicture: controllerPicture,
 	}
 }
 
-func ConstructorUser(cfg util.Config) interfaceAdapter.ControllerUser {
+func ConstructorUser(cfg util.Config, controllerPicture interfaceAdapter.ControllerPicture) interfaceAdapter.ControllerUser {
 	return &ControllerUser{
-		Dynamodb: driverDynamodb.ConstructorUser(
+		Dynamodb:          constructorDynamodbUser(cfg),
+		DynamodbAudit:     constructorDynamodbAudit(cfg),
+		ControllerPicture: controllerPicture,
+	}
+}
+
+func constructorDynamodbUser(cfg util.Config) interfaceDatabase.DriverDynamodbUser {
+	return driverDynamodb.ConstructorUser(
+		cfg.AwsDynamodbClient,
+		cfg.AwsDynamodbTableUser.TableName,
+		cfg.AwsDynamodbTableUser.PrimaryKeyName,
+		cfg.AwsDynamodbTableUser.PrimaryKeyType,
+		*cfg.AwsDynamodbTableUser.SortKeyName,
+		*cfg.AwsDynamodbTableUser.SortKeyType,
+		cfg.AwsDynamodbTableAudit.TableName,
+		cfg.AwsDynamodbTableAudit.PrimaryKeyName,
+	)
+}
+
+func ConstructorAudit(cfg util.Config) interfaceAdapter.ControllerAudit {
+	return &ControllerAudit{
+		Dynamodb: constructorDynamodbAudit(cfg),
+	}
+}
+
+func ConstructorTask(cfg util.Config, controllerPicture interfaceAdapter.ControllerPicture) interfaceAdapter.ControllerTask {
+	return &ControllerTask{
+		Dynamodb: driverDynamodb.ConstructorTask(
 			cfg.AwsDynamodbClient,
-			cfg.AwsDynamodbTableUser.TableName,
-			cfg.AwsDynamodbTableUser.PrimaryKeyName,
-			cfg.AwsDynamodbTableUser.PrimaryKeyType,
-			*cfg.AwsDynamodbTableUser.SortKeyName,
-			*cfg.AwsDynamodbTableUser.SortKeyType,
+			cfg.AwsDynamodbTableTask.TableName,
+			cfg.AwsDynamodbTableTask.PrimaryKeyName,
+			cfg.AwsDynamodbTableTask.PrimaryKeyType,
+			*cfg.AwsDynamodbTableTask.SortKeyName,
+			*cfg.AwsDynamodbTableTask.SortKeyType,
 		),
+		ControllerPicture: controllerPicture,
 	}
 }
 
+func constructorDynamodbAudit(cfg util.Config) interfaceDatabase.DriverDynamodbAudit {
+	return driverDynamodb.ConstructorAudit(
+		cfg.AwsDynamodbClient,
+		cfg.AwsDynamodbTableAudit.TableName,
+		cfg.AwsDynamodbTableAudit.PrimaryKeyName,
+		cfg.AwsDynamodbTableAudit.PrimaryKeyType,
+		*cfg.AwsDynamodbTableAudit.SortKeyName,
+		*cfg.AwsDynamodbTableAudit.SortKeyType,
+	)
+}
+
 func ConstructorFlickr(cfg util.Config, controllerPicture interfaceAdapter.ControllerPicture, controllerTag interfaceAdapter.ControllerTag, controllerUser interfaceAdapter.ControllerUser) interfaceAdapter.ControllerFlickr {
 	return &ControllerFlickr{
 		Api:               driverHost.ConstructorApiFlickr(),
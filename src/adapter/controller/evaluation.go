@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	"sort"
+)
+
+// compares the predictions of a model with the boxes drawn by hand, or accepted by the review, tag by tag.
+// only the pictures with a prediction of the model are evaluated, the others may not have been seen by it.
+func (c ControllerPicture) EvaluatePredictions(ctx context.Context, filter controllerModel.EvaluationFilter) (*controllerModel.Evaluation, error) {
+	if filter.State != controllerModel.PictureStateValidation && filter.State != controllerModel.PictureStateProduction {
+		return nil, fmt.Errorf("predictions can only be evaluated in %s and %s", controllerModel.PictureStateValidation, controllerModel.PictureStateProduction)
+	}
+	if filter.Model == "" {
+		return nil, fmt.Errorf("an evaluation requires a model")
+	}
+	if filter.IoU <= 0 || filter.IoU > 1 {
+		return nil, fmt.Errorf("IoU of %f is not between 0 and 1", filter.IoU)
+	}
+	pictures, err := c.ReadPictures(ctx, filter.State, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluation := controllerModel.Evaluation{
+		State:   filter.State,
+		Model:   filter.Model,
+		Weights: filter.Weights,
+		IoU:     filter.IoU,
+	}
+	detections := map[string][]evaluationDetection{}
+	humans := map[string]int{}
+	for _, picture := range pictures {
+		var predicted []controllerModel.PictureTag
+		for _, tag := range picture.Tags {
+			if predictedTag(tag) && tag.BoxInformation.Body.Model.String == filter.Model && (filter.Weights == "" || tag.BoxInformation.Body.Weights.String == filter.Weights) {
+				predicted = append(predicted, tag)
+			}
+		}
+		if len(predicted) == 0 {
+			continue
+		}
+		drawn := drawnTags(picture)
+		evaluation.Pictures++
+
+		result := controllerModel.EvaluationPicture{Origin: picture.Origin, ID: picture.ID}
+		matched := matchPredictions(predicted, drawn, filter.IoU)
+		for i, tag := range predicted {
+			detections[tag.Name] = append(detections[tag.Name], evaluationDetection{
+				confidence:   tag.BoxInformation.Body.Confidence.Float64,
+				truePositive: matched[i],
+			})
+			if matched[i] {
+				result.TruePositives++
+			} else {
+				result.FalsePositives++
+			}
+		}
+		for _, tag := range drawn {
+			humans[tag.Name]++
+		}
+		result.FalseNegatives = len(drawn) - result.TruePositives
+		result.F1 = 1
+		if mistakes := result.FalsePositives + result.FalseNegatives; mistakes > 0 {
+			result.F1 = float64(2*result.TruePositives) / float64(2*result.TruePositives+mistakes)
+		}
+		evaluation.Worst = append(evaluation.Worst, result)
+	}
+
+	names := make([]string, 0, len(humans))
+	for name := range humans {
+		names = append(names, name)
+	}
+	for name := range detections {
+		if _, ok := humans[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var evaluated int
+	for _, name := range names {
+		tag := evaluateDetections(detections[name], humans[name])
+		tag.Name = name
+		evaluation.Tags = append(evaluation.Tags, tag)
+		if tag.Humans > 0 {
+			evaluation.MeanAP += tag.AP
+			evaluated++
+		}
+	}
+	if evaluated > 0 {
+		evaluation.MeanAP /= float64(evaluated)
+	}
+
+	sort.SliceStable(evaluation.Worst, func(i, j int) bool {
+		a, b := evaluation.Worst[i], evaluation.Worst[j]
+		if a.F1 != b.F1 {
+			return a.F1 < b.F1
+		}
+		return a.FalsePositives+a.FalseNegatives > b.FalsePositives+b.FalseNegatives
+	})
+	if filter.Worst >= 0 && len(evaluation.Worst) > filter.Worst {
+		evaluation.Worst = evaluation.Worst[:filter.Worst]
+	}
+	return &evaluation, nil
+}
+
+// boxes of the set accepted by the review, or the boxes drawn by hand on the picture when it has not been reviewed
+func drawnTags(picture controllerModel.Picture) []controllerModel.PictureTag {
+	tags := picture.Tags
+	if annotationSet, ok := acceptedAnnotationSet(picture); ok {
+		tags = annotationSet.Tags
+	}
+	var drawn []controllerModel.PictureTag
+	for _, tag := range tags {
+		if tag.BoxInformation.Valid && !predictedTag(tag) {
+			drawn = append(drawn, tag)
+		}
+	}
+	return drawn
+}
+
+type evaluationDetection struct {
+	confidence   float64
+	truePositive bool
+}
+
+// each prediction, the most confident first, matches the unmatched box drawn by hand with the same name and size and the best IoU
+func matchPredictions(predicted, drawn []controllerModel.PictureTag, iou float64) []bool {
+	order := make([]int, len(predicted))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return predicted[order[i]].BoxInformation.Body.Confidence.Float64 > predicted[order[j]].BoxInformation.Body.Confidence.Float64
+	})
+
+	matched := make([]bool, len(predicted))
+	used := make([]bool, len(drawn))
+	for _, i := range order {
+		prediction := predicted[i].BoxInformation.Body
+		best, bestIoU := -1, iou
+		for j, tag := range drawn {
+			if used[j] || tag.Name != predicted[i].Name || tag.BoxInformation.Body.PictureSizeID != prediction.PictureSizeID {
+				continue
+			}
+			if current := boxIoU(prediction.Box, tag.BoxInformation.Body.Box); current >= bestIoU {
+				best, bestIoU = j, current
+			}
+		}
+		if best != -1 {
+			used[best] = true
+			matched[i] = true
+		}
+	}
+	return matched
+}
+
+// precision and recall of every detection, and the average precision over every recall
+func evaluateDetections(detections []evaluationDetection, humans int) controllerModel.EvaluationTag {
+	tag := controllerModel.EvaluationTag{Humans: humans, Predictions: len(detections)}
+	sort.SliceStable(detections, func(i, j int) bool { return detections[i].confidence > detections[j].confidence })
+
+	precisions := make([]float64, len(detections))
+	recalls := make([]float64, len(detections))
+	for i, detection := range detections {
+		if detection.truePositive {
+			tag.TruePositives++
+		}
+		precisions[i] = float64(tag.TruePositives) / float64(i+1)
+		if humans > 0 {
+			recalls[i] = float64(tag.TruePositives) / float64(humans)
+		}
+	}
+	if len(detections) > 0 {
+		tag.Precision = precisions[len(precisions)-1]
+	}
+	if humans > 0 {
+		tag.Recall = float64(tag.TruePositives) / float64(humans)
+	}
+
+	// the precision at a recall is the best one reached at any higher recall
+	for i := len(precisions) - 2; i >= 0; i-- {
+		if precisions[i+1] > precisions[i] {
+			precisions[i] = precisions[i+1]
+		}
+	}
+	var previousRecall float64
+	for i := range detections {
+		tag.AP += (recalls[i] - previousRecall) * precisions[i]
+		previousRecall = recalls[i]
+	}
+	return tag
+}
@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"database/sql"
+	"math"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+var evaluationSizeID = model.NewUUID()
+
+func evaluationTag(name string, box controllerModel.Box, confidence float64) controllerModel.PictureTag {
+	tag := controllerModel.PictureTag{ID: model.NewUUID(), Name: name}
+	tag.BoxInformation = model.NewNullable(controllerModel.BoxInformation{PictureSizeID: evaluationSizeID, Box: box})
+	if confidence > 0 {
+		tag.BoxInformation.Body.Model = sql.NullString{String: "yolov5", Valid: true}
+		tag.BoxInformation.Body.Confidence = sql.NullFloat64{Float64: confidence, Valid: true}
+	}
+	return tag
+}
+
+func TestMatchPredictions(t *testing.T) {
+	box := controllerModel.Box{Tlx: 0, Tly: 0, Width: 10, Height: 10}
+	shifted := controllerModel.Box{Tlx: 2, Tly: 0, Width: 10, Height: 10} // IoU of 0.67 with box
+	half := controllerModel.Box{Tlx: 5, Tly: 0, Width: 10, Height: 10}    // IoU of 0.33 with box
+	otherSize := evaluationTag("dog", box, 0)
+	otherSize.BoxInformation.Body.PictureSizeID = model.NewUUID()
+
+	tests := []struct {
+		name      string
+		predicted []controllerModel.PictureTag
+		drawn     []controllerModel.PictureTag
+		want      []bool
+	}{
+		{
+			name:      "same box",
+			predicted: []controllerModel.PictureTag{evaluationTag("dog", box, 0.9)},
+			drawn:     []controllerModel.PictureTag{evaluationTag("dog", box, 0)},
+			want:      []bool{true},
+		},
+		{
+			name:      "other name",
+			predicted: []controllerModel.PictureTag{evaluationTag("dog", box, 0.9)},
+			drawn:     []controllerModel.PictureTag{evaluationTag("cat", box, 0)},
+			want:      []bool{false},
+		},
+		{
+			name:      "other size",
+			predicted: []controllerModel.PictureTag{evaluationTag("dog", box, 0.9)},
+			drawn:     []controllerModel.PictureTag{otherSize},
+			want:      []bool{false},
+		},
+		{
+			name:      "IoU under the threshold",
+			predicted: []controllerModel.PictureTag{evaluationTag("dog", half, 0.9)},
+			drawn:     []controllerModel.PictureTag{evaluationTag("dog", box, 0)},
+			want:      []bool{false},
+		},
+		{
+			name:      "most confident first",
+			predicted: []controllerModel.PictureTag{evaluationTag("dog", box, 0.6), evaluationTag("dog", box, 0.9)},
+			drawn:     []controllerModel.PictureTag{evaluationTag("dog", box, 0)},
+			want:      []bool{false, true},
+		},
+		{
+			name:      "best IoU",
+			predicted: []controllerModel.PictureTag{evaluationTag("dog", box, 0.9), evaluationTag("dog", shifted, 0.8)},
+			drawn:     []controllerModel.PictureTag{evaluationTag("dog", shifted, 0), evaluationTag("dog", box, 0)},
+			want:      []bool{true, true},
+		},
+		{
+			name:      "zero humans",
+			predicted: []controllerModel.PictureTag{evaluationTag("dog", box, 0.9)},
+			want:      []bool{false},
+		},
+		{
+			name:  "zero detections",
+			drawn: []controllerModel.PictureTag{evaluationTag("dog", box, 0)},
+			want:  []bool{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := matchPredictions(test.predicted, test.drawn, 0.5); !slices.Equal(got, test.want) {
+				t.Errorf("matchPredictions() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateDetections(t *testing.T) {
+	tests := []struct {
+		name       string
+		detections []evaluationDetection
+		humans     int
+		want       controllerModel.EvaluationTag
+	}{
+		{
+			name:   "zero detections",
+			humans: 3,
+			want:   controllerModel.EvaluationTag{Humans: 3},
+		},
+		{
+			name:       "zero humans",
+			detections: []evaluationDetection{{confidence: 0.9}, {confidence: 0.8}},
+			want:       controllerModel.EvaluationTag{Predictions: 2},
+		},
+		{
+			name:       "every box found",
+			detections: []evaluationDetection{{confidence: 0.9, truePositive: true}, {confidence: 0.8, truePositive: true}},
+			humans:     2,
+			want:       controllerModel.EvaluationTag{Humans: 2, Predictions: 2, TruePositives: 2, Precision: 1, Recall: 1, AP: 1},
+		},
+		{
+			name:       "missed boxes",
+			detections: []evaluationDetection{{confidence: 0.9, truePositive: true}},
+			humans:     4,
+			want:       controllerModel.EvaluationTag{Humans: 4, Predictions: 1, TruePositives: 1, Precision: 1, Recall: 0.25, AP: 0.25},
+		},
+		{
+			// precisions 1, 1/2, 2/3 at recalls 1/2, 1/2, 1 are interpolated to 1, 2/3, 2/3
+			name:       "interpolated precision",
+			detections: []evaluationDetection{{confidence: 0.7, truePositive: true}, {confidence: 0.9, truePositive: true}, {confidence: 0.8}},
+			humans:     2,
+			want:       controllerModel.EvaluationTag{Humans: 2, Predictions: 3, TruePositives: 2, Precision: 2.0 / 3, Recall: 1, AP: 0.5 + 0.5*2/3},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := evaluateDetections(test.detections, test.humans)
+			if got.Humans != test.want.Humans || got.Predictions != test.want.Predictions || got.TruePositives != test.want.TruePositives {
+				t.Errorf("evaluateDetections() = %+v, want %+v", got, test.want)
+			}
+			for _, value := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"precision", got.Precision, test.want.Precision},
+				{"recall", got.Recall, test.want.Recall},
+				{"AP", got.AP, test.want.AP},
+			} {
+				if math.Abs(value.got-value.want) > 1e-9 {
+					t.Errorf("%s = %f, want %f", value.name, value.got, value.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDrawnTags(t *testing.T) {
+	box := controllerModel.Box{Tlx: 0, Tly: 0, Width: 10, Height: 10}
+	drawn := evaluationTag("dog", box, 0)
+	predicted := evaluationTag("dog", box, 0.9)
+	accepted := evaluationTag("cat", box, 0)
+	pending := evaluationTag("bird", box, 0)
+	noBox := controllerModel.PictureTag{ID: model.NewUUID(), Name: "dog"}
+
+	tests := []struct {
+		name    string
+		picture controllerModel.Picture
+		want    []model.UUID
+	}{
+		{
+			name:    "not reviewed",
+			picture: controllerModel.Picture{Tags: []controllerModel.PictureTag{drawn, predicted, noBox}},
+			want:    []model.UUID{drawn.ID},
+		},
+		{
+			name: "accepted set",
+			picture: controllerModel.Picture{
+				Tags: []controllerModel.PictureTag{drawn, predicted},
+				AnnotationSets: []controllerModel.AnnotationSet{
+					{Status: controllerModel.AnnotationSetPending, Tags: []controllerModel.PictureTag{pending}},
+					{Status: controllerModel.AnnotationSetAccepted, Tags: []controllerModel.PictureTag{accepted}},
+				},
+			},
+			want: []model.UUID{accepted.ID},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []model.UUID
+			for _, tag := range drawnTags(test.picture) {
+				got = append(got, tag.ID)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("drawnTags() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
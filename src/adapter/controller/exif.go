@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"bytes"
+	"image"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/rwcarlsen/goexif/tiff"
+)
+
+// camera and exposure fields kept in the metadata of a picture, the other ones such as GPS are dropped
+var exifFieldsKept = []exif.FieldName{
+	exif.Make,
+	exif.Model,
+	exif.LensMake,
+	exif.LensModel,
+	exif.ExposureTime,
+	exif.FNumber,
+	exif.ISOSpeedRatings,
+	exif.FocalLength,
+	exif.Flash,
+	exif.WhiteBalance,
+}
+
+// reads the EXIF of a file, ok is false when the file has none
+func readExif(buffer []byte) (orientation int, metadata map[string]string, ok bool) {
+	x, err := exif.Decode(bytes.NewReader(buffer))
+	if err != nil {
+		return 1, nil, false
+	}
+
+	orientation = 1
+	if tag, err := x.Get(exif.Orientation); err == nil {
+		if value, err := tag.Int(0); err == nil && value >= 1 && value <= 8 {
+			orientation = value
+		}
+	}
+
+	metadata = map[string]string{}
+	for _, field := range exifFieldsKept {
+		tag, err := x.Get(field)
+		if err != nil {
+			continue
+		}
+		value := tag.String()
+		if tag.Format() == tiff.StringVal {
+			if value, err = tag.StringVal(); err != nil {
+				continue
+			}
+		}
+		metadata[string(field)] = value
+	}
+	return orientation, metadata, true
+}
+
+// rotates and flips the pixels so that they match the displayed picture
+func orientFile(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		// the orientations from 5 to 8 swap the width and the height
+		dw, dh = h, w
+	}
+
+	oriented := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored horizontally
+				sx, sy = w-1-x, y
+			case 3: // rotated 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // rotated 90 clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotated 270 clockwise
+				sx, sy = w-1-y, x
+			}
+			oriented.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return oriented
+}
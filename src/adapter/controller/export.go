@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"context"
+	"math"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+
+	dynamodbTable "scraper-backend/src/driver/database/dynamodb/table"
+	model "scraper-backend/src/driver/model"
+
+	"golang.org/x/exp/slices"
+)
+
+// exports the boxes and segmentations anchored to the current size of the pictures of a collection.
+// the synonyms are exported with the name of their tag, and the tags deeper than a non-negative level with their ancestor at it
+func (c ControllerPicture) ExportPictures(ctx context.Context, state string, level int) (*controllerModel.Export, error) {
+	pictures, err := c.ReadPictures(ctx, state, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	export := controllerModel.Export{
+		Images:      []controllerModel.ExportImage{},
+		Annotations: []controllerModel.ExportAnnotation{},
+		Categories:  []controllerModel.ExportCategory{},
+	}
+	tags, err := c.DynamodbTag.ReadTags(ctx, dynamodbTable.TagPrimaryKeySearched)
+	if err != nil {
+		return nil, err
+	}
+	canonicalNames := tagCanonicalNames(tags, level)
+	skeletons := map[string]controllerModel.Skeleton{}
+	for _, tag := range tags {
+		if tag.Skeleton.Valid {
+			skeletons[tag.Name] = tag.Skeleton.Body
+		}
+	}
+
+	categoryIDs := map[string]int{}
+	for _, picture := range pictures {
+		if len(picture.Sizes) == 0 {
+			continue
+		}
+		size := picture.Sizes[len(picture.Sizes)-1]
+		image := controllerModel.ExportImage{
+			ID:        len(export.Images) + 1,
+			Origin:    picture.Origin,
+			PictureID: picture.ID,
+			FileName:  size.ObjectKey,
+			Width:     size.Box.Width,
+			Height:    size.Box.Height,
+		}
+		export.Images = append(export.Images, image)
+
+		for _, tag := range picture.Tags {
+			// the predictions kept to be evaluated are not part of the dataset
+			if predictedTag(tag) {
+				continue
+			}
+			if name, ok := canonicalNames[tag.Name]; ok {
+				tag.Name = name
+			}
+			skeleton, hasSkeleton := skeletons[tag.Name]
+			annotation, ok := exportAnnotation(tag, size, skeleton)
+			if !ok {
+				continue
+			}
+			categoryID, ok := categoryIDs[tag.Name]
+			if !ok {
+				categoryID = len(categoryIDs) + 1
+				categoryIDs[tag.Name] = categoryID
+				category := controllerModel.ExportCategory{ID: categoryID, Name: tag.Name}
+				if hasSkeleton {
+					category.Skeleton = model.NewNullable(skeleton)
+				}
+				export.Categories = append(export.Categories, category)
+			}
+			annotation.ID = len(export.Annotations) + 1
+			annotation.ImageID = image.ID
+			annotation.CategoryID = categoryID
+			export.Annotations = append(export.Annotations, annotation)
+		}
+	}
+	return &export, nil
+}
+
+// annotation of the tag in the size, ok is false when it has no box, segmentation nor keypoints in it
+func exportAnnotation(tag controllerModel.PictureTag, size controllerModel.PictureSize, skeleton controllerModel.Skeleton) (controllerModel.ExportAnnotation, bool) {
+	var annotation controllerModel.ExportAnnotation
+	hasBox := tag.BoxInformation.Valid && tag.BoxInformation.Body.PictureSizeID == size.ID
+	hasSegmentation := tag.Segmentation.Valid && tag.Segmentation.Body.PictureSizeID == size.ID
+	hasKeypoints := tag.Keypoints.Valid && tag.Keypoints.Body.PictureSizeID == size.ID && len(skeleton.Keypoints) > 0
+	if !hasBox && !hasSegmentation && !hasKeypoints {
+		return annotation, false
+	}
+
+	if hasKeypoints {
+		annotation.Keypoints = make([]controllerModel.Keypoint, 0, len(skeleton.Keypoints))
+		for _, name := range skeleton.Keypoints {
+			idx := slices.IndexFunc(tag.Keypoints.Body.Points, func(point controllerModel.Keypoint) bool { return point.Name == name })
+			if idx == -1 {
+				annotation.Keypoints = append(annotation.Keypoints, controllerModel.Keypoint{Name: name, Visibility: controllerModel.KeypointNotLabeled})
+				continue
+			}
+			annotation.Keypoints = append(annotation.Keypoints, tag.Keypoints.Body.Points[idx])
+		}
+	}
+
+	if hasBox {
+		annotation.Box = tag.BoxInformation.Body.Box
+		annotation.Area = float64(annotation.Box.Width * annotation.Box.Height)
+	}
+	if hasSegmentation {
+		segmentation := tag.Segmentation.Body
+		if segmentation.Mask.Valid {
+			annotation.Mask = segmentation.Mask
+			annotation.Area = float64(maskArea(segmentation.Mask.Body))
+		} else {
+			annotation.Polygons = slices.Clone(segmentation.Polygons)
+			annotation.Area = 0
+			for _, polygon := range segmentation.Polygons {
+				annotation.Area += polygonArea(polygon)
+			}
+		}
+		if !hasBox {
+			annotation.Box = segmentationBox(segmentation)
+		}
+	}
+	return annotation, true
+}
+
+// number of pixels set in the mask
+func maskArea(mask controllerModel.Mask) int {
+	area := 0
+	for i := 1; i < len(mask.Counts); i += 2 {
+		area += mask.Counts[i]
+	}
+	return area
+}
+
+// smallest box containing the segmentation
+func segmentationBox(segmentation controllerModel.Segmentation) controllerModel.Box {
+	if segmentation.Mask.Valid {
+		mask := segmentation.Mask.Body
+		pixels := decodeMask(mask)
+		minX, minY, maxX, maxY := mask.Width, mask.Height, -1, -1
+		for i, pixel := range pixels {
+			if !pixel {
+				continue
+			}
+			x, y := i/mask.Height, i%mask.Height
+			if x < minX {
+				minX = x
+			}
+			if y < minY {
+				minY = y
+			}
+			if x > maxX {
+				maxX = x
+			}
+			if y > maxY {
+				maxY = y
+			}
+		}
+		if maxX == -1 {
+			return controllerModel.Box{}
+		}
+		return controllerModel.Box{Tlx: minX, Tly: minY, Width: maxX - minX + 1, Height: maxY - minY + 1}
+	}
+
+	first := true
+	var minX, minY, maxX, maxY float64
+	for _, polygon := range segmentation.Polygons {
+		for _, point := range polygon {
+			if first {
+				minX, minY, maxX, maxY = point.X, point.Y, point.X, point.Y
+				first = false
+				continue
+			}
+			minX, minY = math.Min(minX, point.X), math.Min(minY, point.Y)
+			maxX, maxY = math.Max(maxX, point.X), math.Max(maxY, point.Y)
+		}
+	}
+	return controllerModel.Box{Tlx: int(minX), Tly: int(minY), Width: int(maxX - minX), Height: int(maxY - minY)}
+}
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	dynamodbTable "scraper-backend/src/driver/database/dynamodb/table"
+	model "scraper-backend/src/driver/model"
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestExportPictures(t *testing.T) {
+	oldSize := controllerModel.PictureSize{ID: model.NewUUID(), Box: controllerModel.Box{Width: 800, Height: 600}, ObjectKey: "pexels/picture/old.jpg"}
+	size := controllerModel.PictureSize{ID: model.NewUUID(), Box: controllerModel.Box{Width: 400, Height: 300}, ObjectKey: "pexels/picture/current.jpg"}
+	predicted := predictedBoxTag
+	predicted.BoxInformation.Body.PictureSizeID = size.ID
+	picture := controllerModel.Picture{
+		Origin: "pexels",
+		ID:     model.NewUUID(),
+		Sizes:  []controllerModel.PictureSize{oldSize, size},
+		Tags: []controllerModel.PictureTag{
+			boxTag("dog", size.ID, controllerModel.Box{Tlx: 10, Tly: 10, Width: 100, Height: 50}),
+			predicted,
+			boxTag("dog", oldSize.ID, controllerModel.Box{Tlx: 10, Tly: 10, Width: 100, Height: 50}),
+			{ID: model.NewUUID(), Name: "pup", Segmentation: model.NewNullable(controllerModel.Segmentation{PictureSizeID: size.ID, Mask: model.NewNullable(squareMask)})},
+			{ID: model.NewUUID(), Name: "cat", Segmentation: model.NewNullable(controllerModel.Segmentation{
+				PictureSizeID: size.ID,
+				Polygons:      []controllerModel.Polygon{{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 20}, {X: 0, Y: 20}}},
+			})},
+			{ID: model.NewUUID(), Name: "dog", Keypoints: model.NewNullable(controllerModel.Keypoints{
+				PictureSizeID: size.ID,
+				Points:        []controllerModel.Keypoint{{Name: "tail", X: 5, Y: 5, Visibility: controllerModel.KeypointVisible}},
+			})},
+			labelTag,
+		},
+	}
+	withoutSize := controllerModel.Picture{Origin: "pexels", ID: model.NewUUID(), Tags: []controllerModel.PictureTag{labelTag}}
+	c := ControllerPicture{
+		DynamodbProduction: &fakeDynamodbPicture{pictures: []controllerModel.Picture{withoutSize, picture}},
+		DynamodbTag: &fakeDynamodbTag{tags: map[string][]controllerModel.Tag{
+			dynamodbTable.TagPrimaryKeySearched: {
+				{Name: "dog", Skeleton: model.NewNullable(dogSkeleton)},
+				{Name: "puppy", Parent: "dog", Synonyms: []string{"pup"}},
+				{Name: "cat"},
+			},
+		}},
+	}
+
+	wantAnnotations := []controllerModel.ExportAnnotation{
+		{Box: controllerModel.Box{Tlx: 10, Tly: 10, Width: 100, Height: 50}, Area: 5000},
+		{Box: controllerModel.Box{Tlx: 1, Tly: 1, Width: 2, Height: 2}, Area: 4, Mask: model.NewNullable(squareMask)},
+		{Box: controllerModel.Box{Width: 10, Height: 20}, Area: 200},
+		{Keypoints: []controllerModel.Keypoint{
+			{Name: "nose", Visibility: controllerModel.KeypointNotLabeled},
+			{Name: "tail", X: 5, Y: 5, Visibility: controllerModel.KeypointVisible},
+			{Name: "paw", Visibility: controllerModel.KeypointNotLabeled},
+		}},
+	}
+	tests := []struct {
+		name            string
+		level           int
+		wantCategories  []string
+		wantCategoryIDs []int // of the annotations
+	}{
+		{name: "every tag", level: -1, wantCategories: []string{"dog", "puppy", "cat"}, wantCategoryIDs: []int{1, 2, 3, 1}},
+		{name: "roots only", level: 0, wantCategories: []string{"dog", "cat"}, wantCategoryIDs: []int{1, 1, 2, 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			export, err := c.ExportPictures(context.Background(), controllerModel.PictureStateProduction, test.level)
+			if err != nil {
+				t.Fatalf("ExportPictures() error = %v", err)
+			}
+			wantImage := controllerModel.ExportImage{ID: 1, Origin: picture.Origin, PictureID: picture.ID, FileName: size.ObjectKey, Width: 400, Height: 300}
+			if len(export.Images) != 1 || export.Images[0] != wantImage {
+				t.Errorf("images = %+v, want only %+v", export.Images, wantImage)
+			}
+
+			var categories []string
+			for i, category := range export.Categories {
+				categories = append(categories, category.Name)
+				if category.ID != i+1 {
+					t.Errorf("category %s ID = %d, want %d", category.Name, category.ID, i+1)
+				}
+				if category.Skeleton.Valid != (category.Name == "dog") {
+					t.Errorf("category %s has a skeleton %v", category.Name, category.Skeleton.Valid)
+				}
+			}
+			if !slices.Equal(categories, test.wantCategories) {
+				t.Errorf("categories = %v, want %v", categories, test.wantCategories)
+			}
+
+			if len(export.Annotations) != len(wantAnnotations) {
+				t.Fatalf("%d annotations, want %d: %+v", len(export.Annotations), len(wantAnnotations), export.Annotations)
+			}
+			for i, annotation := range export.Annotations {
+				want := wantAnnotations[i]
+				if annotation.ID != i+1 || annotation.ImageID != 1 || annotation.CategoryID != test.wantCategoryIDs[i] {
+					t.Errorf("annotation %d IDs = %d, %d, %d, want %d, 1, %d", i, annotation.ID, annotation.ImageID, annotation.CategoryID, i+1, test.wantCategoryIDs[i])
+				}
+				if annotation.Box != want.Box || annotation.Area != want.Area || annotation.Mask.Valid != want.Mask.Valid || !slices.Equal(annotation.Keypoints, want.Keypoints) {
+					t.Errorf("annotation %d = %+v, want %+v", i, annotation, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSegmentationBox(t *testing.T) {
+	tests := []struct {
+		name         string
+		segmentation controllerModel.Segmentation
+		want         controllerModel.Box
+	}{
+		{
+			name:         "mask",
+			segmentation: controllerModel.Segmentation{Mask: model.NewNullable(squareMask)},
+			want:         controllerModel.Box{Tlx: 1, Tly: 1, Width: 2, Height: 2},
+		},
+		{
+			name:         "empty mask",
+			segmentation: controllerModel.Segmentation{Mask: model.NewNullable(controllerModel.Mask{Width: 4, Height: 4, Counts: []int{16}})},
+			want:         controllerModel.Box{},
+		},
+		{
+			name: "polygons",
+			segmentation: controllerModel.Segmentation{Polygons: []controllerModel.Polygon{
+				{{X: 10, Y: 20}, {X: 30, Y: 20}, {X: 30, Y: 40}},
+				{{X: 50, Y: 5}, {X: 60, Y: 5}, {X: 60, Y: 15}},
+			}},
+			want: controllerModel.Box{Tlx: 10, Tly: 5, Width: 50, Height: 35},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := segmentationBox(test.segmentation); got != test.want {
+				t.Errorf("segmentationBox() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
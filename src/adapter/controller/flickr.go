@@ -2,10 +2,10 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/foolin/pagser"
 
 	"golang.org/x/exp/slices"
@@ -18,7 +18,6 @@ import (
 	hostModel "scraper-backend/src/driver/host/model"
 	interfaceHost "scraper-backend/src/driver/interface/host"
 	model "scraper-backend/src/driver/model"
-	"scraper-backend/src/util"
 )
 
 type ControllerFlickr struct {
@@ -49,12 +48,30 @@ func (c *ControllerFlickr) SearchPhotos(ctx context.Context, quality string) err
 		return fmt.Errorf("no searched tags")
 	}
 
-	blockedTags, err := c.ControllerTag.ReadTags(ctx, "blocked")
+	policy, err := readBlockPolicy(ctx, c.ControllerTag, c.ControllerUser)
 	if err != nil {
 		return err
 	}
 
+	users, err := readUserPolicy(ctx, c.ControllerPicture, c.ControllerUser, searchedTags)
+	if err != nil {
+		return err
+	}
+
+	budget, err := readSearchBudget(ctx, c.ControllerPicture, searchedTags)
+	if err != nil {
+		return err
+	}
+
+	// the synonyms are searched like the names of their tags, and counted in the quotas and targets with them
+	canonicalNames := tagCanonicalNames(searchedTags, -1)
+	var searchedNames []string
 	for _, searchedTag := range searchedTags {
+		searchedNames = append(searchedNames, tagNames(searchedTag)...)
+	}
+
+	for _, searchedName := range searchedNames {
+		searchedTag := canonicalNames[searchedName]
 
 		// all the commercial use licenses
 		// https://www.flickr.com/services/api/flickr.photos.licenses.getInfo.html
@@ -67,31 +84,34 @@ func (c *ControllerFlickr) SearchPhotos(ctx context.Context, quality string) err
 		}
 		licenseIDs := [5]string{"4", "5", "7", "9", "10"}
 		for _, licenseID := range licenseIDs {
+			if budget.exhausted(searchedTag) {
+				break // enough pictures or pages of the tag
+			}
 
 			// start with the first page
 			page := 1
-			searchPerPage, err := c.Api.SearchPhotosPerPage(parser, licenseID, searchedTag.Name, fmt.Sprint(page))
+			searchPerPage, err := c.Api.SearchPhotosPerPage(parser, licenseID, searchedName, fmt.Sprint(page))
 			if err != nil {
 				return fmt.Errorf("SearchPhotosPerPage has failed: %v", err)
 			}
 
-			for page := page; page <= int(searchPerPage.Pages); page++ {
-				searchPerPage, err := c.Api.SearchPhotosPerPage(parser, licenseID, searchedTag.Name, fmt.Sprint(page))
+			for page := page; page <= int(searchPerPage.Pages) && budget.page(searchedTag); page++ {
+				searchPerPage, err := c.Api.SearchPhotosPerPage(parser, licenseID, searchedName, fmt.Sprint(page))
 				if err != nil {
 					return fmt.Errorf("searchPhotosPerPageFlickr has failed: %v", err)
 				}
 				for _, photo := range searchPerPage.Photos {
+					if budget.full(searchedTag) {
+						break // enough pictures of the tag
+					}
+
 					// look for existing images
-					for _, state := range []string{"production", "validation", "process", "blocked"} {
-						projEx := expression.NamesList(expression.Name("OriginID"))
-						filtEx := expression.Name("OriginID").Equal(expression.Value(photo.ID))
-						pictures, err := c.ControllerPicture.ReadPictures(ctx, state, &projEx, &filtEx)
-						if err != nil {
-							return err
-						}
-						if len(pictures) > 0 {
-							continue // skip existing image
-						}
+					exists, err := pictureExists(ctx, c.ControllerPicture, photo.ID)
+					if err != nil {
+						return err
+					}
+					if exists {
+						continue // skip existing image
 					}
 
 					// extract the photo informations
@@ -103,29 +123,16 @@ func (c *ControllerFlickr) SearchPhotos(ctx context.Context, quality string) err
 						infoData.OriginalFormat = "jpg"
 					}
 
-					// look for unwanted user
-					users, err := c.ControllerUser.ReadUsers(ctx)
-					if err != nil {
-						return err
-					}
-					for _, user := range users {
-						if user.OriginID == infoData.UserID {
-							continue // skip the image with unwanted user
-						}
-					}
-
-					// look for unwanted tag
+					// look for unwanted user or tag
 					var photoTags []string
 					for _, tag := range infoData.Tags {
 						photoTags = append(photoTags, strings.ToLower(tag.Name))
 					}
-					var blockedTagsString []string
-					for _, tag := range blockedTags {
-						blockedTagsString = append(blockedTagsString, tag.Name)
+					if reason := policy.reason(origin, infoData.UserID, photoTags, infoData.Title, infoData.Description); reason != "" {
+						continue // skip the image with unwanted user or tag
 					}
-					idx := util.FindIndexRegExp(blockedTagsString, photoTags)
-					if idx != -1 {
-						continue // skip image with unwanted tag
+					if users.quotaReached(origin, infoData.UserID, searchedTag) {
+						continue // skip the image of a user with enough of them
 					}
 
 					// extract the photo download link
@@ -137,7 +144,7 @@ func (c *ControllerFlickr) SearchPhotos(ctx context.Context, quality string) err
 					// get the download link for the correct resolution
 					label := strings.ToLower(quality)
 					regexpMatch := fmt.Sprintf(`[\-\_\w\d]*%s[\-\_\w\d]*`, label)
-					idx = slices.IndexFunc(downloadData.Photos, func(download hostModel.DownloadPhotoSingleData) bool { return strings.ToLower(download.Label) == label })
+					idx := slices.IndexFunc(downloadData.Photos, func(download hostModel.DownloadPhotoSingleData) bool { return strings.ToLower(download.Label) == label })
 					if idx == -1 {
 						idx = slices.IndexFunc(downloadData.Photos, func(download hostModel.DownloadPhotoSingleData) bool {
 							matched, err := regexp.Match(regexpMatch, []byte(strings.ToLower(download.Label)))
@@ -206,7 +213,13 @@ func (c *ControllerFlickr) SearchPhotos(ctx context.Context, quality string) err
 						Tags:         tags,
 					}
 
-					if err := c.ControllerPicture.CreatePicture(ctx, model.NewUUID(), picture, buffer); err != nil {
+					budget.tagPicture(&picture, searchedTag)
+					if err := users.createPicture(ctx, c.ControllerPicture, budget, searchedTag, picture, buffer); err != nil {
+						var rejected controllerModel.PictureRejectedError
+						if errors.As(err, &rejected) {
+							fmt.Printf("%v\n", rejected)
+							continue // skip the rejected image
+						}
 						return fmt.Errorf("CreatePicture has failed: %v", err)
 					}
 				}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"path"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	// registers the webp decoder for image.Decode, the other formats are registered by their encoders.
+	// gif files are decoded to their first frame.
+	_ "golang.org/x/image/webp"
+)
+
+// formats that can be written, webp has no encoder in the standard and x/image libraries so it is only read
+var extensionsEncodable = []string{"jpg", "png", "gif", "tiff"}
+
+// single spelling for each format, e.g. `JPEG` becomes `jpg`
+func normalizeExtension(extension string) string {
+	extension = strings.ToLower(strings.TrimPrefix(extension, "."))
+	switch extension {
+	case "jpeg":
+		return "jpg"
+	case "tif":
+		return "tiff"
+	default:
+		return extension
+	}
+}
+
+// a file is never written in another format than the one asked, e.g. the crops of a webp picture fail
+func checkEncodable(extension string) error {
+	if !slices.Contains(extensionsEncodable, normalizeExtension(extension)) {
+		return controllerModel.FormatUnsupportedError{Extension: normalizeExtension(extension)}
+	}
+	return nil
+}
+
+// extension of the file stored under the key
+func keyExtension(key string) string {
+	return normalizeExtension(path.Ext(key))
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// checks a downloaded file before it is stored and returns the buffer to store
+// with the reason to block the picture when it fails the quality gate.
+// the real dimensions and format of the file replace the ones given by the host.
+// the EXIF orientation is applied to the pixels so that boxes and crops match the displayed picture.
+func (c ControllerPicture) ingestFile(picture *controllerModel.Picture, buffer []byte) ([]byte, string, error) {
+	if len(picture.Sizes) == 0 {
+		return nil, "", fmt.Errorf("picture %s/%s has no size", picture.Origin, picture.OriginID)
+	}
+
+	// the whole file is decoded so that truncated files are detected
+	img, format, err := image.Decode(bytes.NewReader(buffer))
+	if err != nil {
+		return nil, "", controllerModel.PictureRejectedError{Reason: fmt.Sprintf("file of %s/%s cannot be decoded: %v", picture.Origin, picture.OriginID, err)}
+	}
+	if img.Bounds().Empty() {
+		return nil, "", controllerModel.PictureRejectedError{Reason: fmt.Sprintf("file of %s/%s is empty", picture.Origin, picture.OriginID)}
+	}
+
+	orientation, metadata, hasExif := readExif(buffer)
+	picture.Metadata = metadata
+	img = orientFile(img, orientation)
+
+	bounds := img.Bounds()
+	size := &picture.Sizes[len(picture.Sizes)-1]
+	size.Box = controllerModel.Box{Tlx: 0, Tly: 0, Width: bounds.Dx(), Height: bounds.Dy()}
+	picture.Extension = normalizeExtension(format)
+
+	quality := scoreQuality(img)
+	picture.Quality = model.NewNullable(quality)
+	var blockReason string
+	if failures := c.QualityGate.check(quality); len(failures) > 0 {
+		reason := fmt.Sprintf("quality gate: %s", strings.Join(failures, ", "))
+		if !c.QualityGate.Block {
+			return nil, "", controllerModel.PictureRejectedError{Reason: fmt.Sprintf("%s/%s %s", picture.Origin, picture.OriginID, reason)}
+		}
+		blockReason = reason
+	}
+
+	// the encoders do not write any EXIF so the file is stripped when it is written again
+	extension := picture.Extension
+	if c.NormalizeFormat != "" {
+		extension = c.NormalizeFormat
+	}
+	if extension != picture.Extension || orientation != 1 || (hasExif && c.StripMetadata) {
+		encoded, err := c.fileToBuffer(img, extension)
+		var formatErr controllerModel.FormatUnsupportedError
+		if errors.As(err, &formatErr) {
+			return nil, "", controllerModel.PictureRejectedError{Reason: fmt.Sprintf("%s/%s must be written again: %v", picture.Origin, picture.OriginID, err)}
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		buffer = encoded.Bytes()
+		picture.Extension = extension
+	}
+	return buffer, blockReason, nil
+}
+
+// sets the dimensions of every size with a file to the ones of its file
+func (c ControllerPicture) BackfillPictureSizes(ctx context.Context, fix bool) (*controllerModel.SizeBackfill, error) {
+	backfill := controllerModel.SizeBackfill{}
+	for _, state := range []string{controllerModel.PictureStateProcess, controllerModel.PictureStateValidation, controllerModel.PictureStateProduction, controllerModel.PictureStateBlocked} {
+		dynamodb, err := c.driverDynamodbMap(state)
+		if err != nil {
+			return nil, err
+		}
+		pictures, err := dynamodb.ReadPictures(ctx, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, picture := range pictures {
+			before := picture
+			before.Sizes = slices.Clone(picture.Sizes)
+			changed := false
+			for i, size := range picture.Sizes {
+				if size.ObjectKey == "" {
+					continue
+				}
+				report := controllerModel.SizeBackfillPicture{
+					State:  state,
+					Origin: picture.Origin,
+					ID:     picture.ID,
+					SizeID: size.ID,
+					From:   size.Box,
+				}
+				buffer, err := c.S3.ItemRead(ctx, c.BucketName, size.ObjectKey)
+				if err != nil {
+					return nil, err
+				}
+				config, _, err := image.DecodeConfig(bytes.NewReader(buffer))
+				if err != nil {
+					backfill.Corrupt = append(backfill.Corrupt, report)
+					continue
+				}
+				if config.Width == size.Box.Width && config.Height == size.Box.Height {
+					continue
+				}
+				// the anchor of a crop is kept, it is relative to the original file
+				picture.Sizes[i].Box.Width = config.Width
+				picture.Sizes[i].Box.Height = config.Height
+				report.To = picture.Sizes[i].Box
+				backfill.Fixed = append(backfill.Fixed, report)
+				changed = true
+			}
+			if changed && fix {
+				after := picture
+				after.Version++
+				audit, err := newPictureAudit(ctx, "BackfillPictureSizes", picture.Origin, picture.ID, before, after)
+				if err != nil {
+					return nil, err
+				}
+				if err := dynamodb.CreatePicture(ctx, picture.ID, picture, audit); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return &backfill, nil
+}
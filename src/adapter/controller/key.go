@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Keys of the files in the bucket only depend on the IDs of the picture and of its size,
+// they do not change when a picture is renamed, transferred, copied or cropped.
+
+func objectKey(picture controllerModel.Picture, size controllerModel.PictureSize) string {
+	return fmt.Sprintf("%s/%s/%s.%s", picture.Origin, picture.ID, size.ID, picture.Extension)
+}
+
+// key of the file displayed for the picture, the one of its last size
+func currentObjectKey(picture controllerModel.Picture) (string, error) {
+	if len(picture.Sizes) == 0 || picture.Sizes[len(picture.Sizes)-1].ObjectKey == "" {
+		return "", fmt.Errorf("picture %s/%s has no file", picture.Origin, picture.ID)
+	}
+	return picture.Sizes[len(picture.Sizes)-1].ObjectKey, nil
+}
+
+// keys of all the files of the picture
+func objectKeys(picture controllerModel.Picture) []string {
+	var keys []string
+	for _, size := range picture.Sizes {
+		if size.ObjectKey != "" {
+			keys = append(keys, size.ObjectKey)
+		}
+	}
+	return keys
+}
+
+// key used before the keys were derived from the IDs
+func legacyObjectKey(picture controllerModel.Picture) string {
+	return fmt.Sprintf("%s/%s.%s", picture.Origin, picture.Name, picture.Extension)
+}
+
+// moves the files still stored under the legacy keys, the pictures already migrated are skipped
+func (c ControllerPicture) MigratePictureKeys(ctx context.Context) (*controllerModel.KeyMigration, error) {
+	migration := controllerModel.KeyMigration{}
+	for _, state := range []string{controllerModel.PictureStateProcess, controllerModel.PictureStateValidation, controllerModel.PictureStateProduction, controllerModel.PictureStateBlocked} {
+		dynamodb, err := c.driverDynamodbMap(state)
+		if err != nil {
+			return nil, err
+		}
+		pictures, err := dynamodb.ReadPictures(ctx, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, picture := range pictures {
+			if _, err := currentObjectKey(picture); err == nil {
+				continue
+			}
+			report := controllerModel.KeyMigrationPicture{
+				State:  state,
+				Origin: picture.Origin,
+				ID:     picture.ID,
+				From:   legacyObjectKey(picture),
+			}
+			exists, err := c.S3.ItemExists(ctx, c.BucketName, report.From)
+			if err != nil {
+				return nil, err
+			}
+			if !exists || len(picture.Sizes) == 0 {
+				migration.Missing = append(migration.Missing, report)
+				continue
+			}
+
+			before := picture
+			before.Sizes = slices.Clone(picture.Sizes)
+			size := &picture.Sizes[len(picture.Sizes)-1]
+			size.ObjectKey = objectKey(picture, *size)
+			if err := c.S3.ItemCopy(ctx, c.BucketName, report.From, size.ObjectKey); err != nil {
+				return nil, err
+			}
+			after := picture
+			after.Version++
+			audit, err := newPictureAudit(ctx, "MigratePictureKeys", picture.Origin, picture.ID, before, after)
+			if err != nil {
+				return nil, compensate(err, func() error { return c.S3.ItemDelete(ctx, c.BucketName, size.ObjectKey) })
+			}
+			if err := dynamodb.CreatePicture(ctx, picture.ID, picture, audit); err != nil {
+				return nil, compensate(err, func() error { return c.S3.ItemDelete(ctx, c.BucketName, size.ObjectKey) })
+			}
+			// the row already points to the new key, a legacy file left behind is removed by the reconciler
+			if err := c.S3.ItemDelete(ctx, c.BucketName, report.From); err != nil {
+				return nil, err
+			}
+			report.To = size.ObjectKey
+			migration.Moved = append(migration.Moved, report)
+		}
+	}
+	return &migration, nil
+}
+
+// key of the resized copy of a file, e.g. `origin/pictureID/sizeID_w512.png`, written in the format of the file
+func renditionObjectKey(key string, width int) string {
+	extension := path.Ext(key)
+	return fmt.Sprintf("%s_w%d%s", strings.TrimSuffix(key, extension), width, extension)
+}
+
+// keys of all the files of the picture with their renditions
+func (c ControllerPicture) objectKeysWithRenditions(picture controllerModel.Picture) []string {
+	var keys []string
+	for _, key := range objectKeys(picture) {
+		keys = append(keys, key)
+		for _, width := range c.RenditionWidths {
+			keys = append(keys, renditionObjectKey(key, width))
+		}
+	}
+	return keys
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	dynamodbTable "scraper-backend/src/driver/database/dynamodb/table"
+	model "scraper-backend/src/driver/model"
+
+	"golang.org/x/exp/slices"
+)
+
+// adds a tag with keypoints, they must follow the skeleton of the searched tag with the same name
+func (c ControllerPicture) CreatePictureTagKeypoints(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tag controllerModel.PictureTag) error {
+	if !tag.Keypoints.Valid {
+		return fmt.Errorf("tag %s has no keypoints", tag.Name)
+	}
+	picture, err := c.readPictureVersion(ctx, controllerModel.PictureStateProcess, primaryKey, sortKey, version)
+	if err != nil {
+		return err
+	}
+	if err := c.validateKeypoints(ctx, *picture, tag.Name, tag.Keypoints.Body); err != nil {
+		return err
+	}
+	tag.ID = model.NewUUID()
+	tag.CreationDate = time.Now()
+	audit, err := newPictureAudit(ctx, "CreatePictureTagKeypoints", primaryKey, sortKey, nil, tag)
+	if err != nil {
+		return err
+	}
+	return c.DynamodbProcess.CreatePictureTag(ctx, primaryKey, sortKey, picture.Version, tag, audit)
+}
+
+// replaces the keypoints of an existing tag
+func (c ControllerPicture) UpdatePictureTagKeypoints(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID, keypoints controllerModel.Keypoints) error {
+	picture, err := c.readPictureVersion(ctx, controllerModel.PictureStateProcess, primaryKey, sortKey, version)
+	if err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(picture.Tags, func(tag controllerModel.PictureTag) bool { return tag.ID == tagID })
+	if idx == -1 {
+		return fmt.Errorf("tag %s not found in picture %s/%s", tagID, primaryKey, sortKey)
+	}
+	if err := c.validateKeypoints(ctx, *picture, picture.Tags[idx].Name, keypoints); err != nil {
+		return err
+	}
+	tag := picture.Tags[idx]
+	tag.Keypoints = model.NewNullable(keypoints)
+	audit, err := newPictureAudit(ctx, "UpdatePictureTagKeypoints", primaryKey, sortKey, picture.Tags[idx], tag)
+	if err != nil {
+		return err
+	}
+	return c.DynamodbProcess.UpdatePictureTag(ctx, primaryKey, sortKey, picture.Version, tag, audit)
+}
+
+// skeleton of the searched tag with the name
+func (c ControllerPicture) readSkeleton(ctx context.Context, name string) (*controllerModel.Skeleton, error) {
+	tags, err := c.DynamodbTag.ReadTags(ctx, dynamodbTable.TagPrimaryKeySearched)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(tags, func(tag controllerModel.Tag) bool { return tag.Name == name && tag.Skeleton.Valid })
+	if idx == -1 {
+		return nil, nil
+	}
+	return &tags[idx].Skeleton.Body, nil
+}
+
+// the keypoints are named after the skeleton of the tag and stay inside of their size
+func (c ControllerPicture) validateKeypoints(ctx context.Context, picture controllerModel.Picture, name string, keypoints controllerModel.Keypoints) error {
+	skeleton, err := c.readSkeleton(ctx, name)
+	if err != nil {
+		return err
+	}
+	if skeleton == nil {
+		return controllerModel.ValidationErrors{{Rule: "keypoints", Message: fmt.Sprintf("tag `%s` has no skeleton", name)}}
+	}
+	idx := slices.IndexFunc(picture.Sizes, func(size controllerModel.PictureSize) bool { return size.ID == keypoints.PictureSizeID })
+	if idx == -1 {
+		return controllerModel.ValidationErrors{{Rule: "keypoints", Message: fmt.Sprintf("keypoints reference the unknown size %s", keypoints.PictureSizeID)}}
+	}
+	size := picture.Sizes[idx].Box
+
+	var errs controllerModel.ValidationErrors
+	names := map[string]bool{}
+	for _, point := range keypoints.Points {
+		if !slices.Contains(skeleton.Keypoints, point.Name) {
+			errs = append(errs, controllerModel.ValidationError{Rule: "keypoints", Message: fmt.Sprintf("keypoint `%s` is not in the skeleton of `%s`", point.Name, name)})
+			continue
+		}
+		if names[point.Name] {
+			errs = append(errs, controllerModel.ValidationError{Rule: "keypoints", Message: fmt.Sprintf("keypoint `%s` is repeated", point.Name)})
+			continue
+		}
+		names[point.Name] = true
+		if point.Visibility < controllerModel.KeypointNotLabeled || point.Visibility > controllerModel.KeypointVisible {
+			errs = append(errs, controllerModel.ValidationError{Rule: "keypoints", Message: fmt.Sprintf("keypoint `%s` has the unknown visibility %d", point.Name, point.Visibility)})
+			continue
+		}
+		if point.Visibility != controllerModel.KeypointNotLabeled && (point.X < 0 || point.Y < 0 || point.X > float64(size.Width) || point.Y > float64(size.Height)) {
+			errs = append(errs, controllerModel.ValidationError{Rule: "keypoints", Message: fmt.Sprintf("keypoint `%s` %+v is outside of size %s %+v", point.Name, point, keypoints.PictureSizeID, size)})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// keypoints relative to the crop, the ones outside of it are no longer labeled.
+// ok is false when none of them is labeled anymore
+func cropKeypoints(keypoints controllerModel.Keypoints, box controllerModel.Box) (controllerModel.Keypoints, bool) {
+	points := make([]controllerModel.Keypoint, 0, len(keypoints.Points))
+	labeled := false
+	for _, point := range keypoints.Points {
+		inside := point.X >= float64(box.Tlx) && point.Y >= float64(box.Tly) &&
+			point.X <= float64(box.Tlx+box.Width) && point.Y <= float64(box.Tly+box.Height)
+		if point.Visibility == controllerModel.KeypointNotLabeled || !inside {
+			points = append(points, controllerModel.Keypoint{Name: point.Name, Visibility: controllerModel.KeypointNotLabeled})
+			continue
+		}
+		point.X -= float64(box.Tlx)
+		point.Y -= float64(box.Tly)
+		points = append(points, point)
+		labeled = true
+	}
+	keypoints.Points = points
+	return keypoints, labeled
+}
+
+// the keypoint names are unique and the links reference them
+func validateSkeleton(skeleton controllerModel.Skeleton) error {
+	var errs controllerModel.ValidationErrors
+	if len(skeleton.Keypoints) == 0 {
+		errs = append(errs, controllerModel.ValidationError{Rule: "skeleton", Message: "skeleton has no keypoint"})
+	}
+	names := map[string]bool{}
+	for _, name := range skeleton.Keypoints {
+		if name == "" || names[name] {
+			errs = append(errs, controllerModel.ValidationError{Rule: "skeleton", Message: fmt.Sprintf("keypoint `%s` is empty or repeated", name)})
+		}
+		names[name] = true
+	}
+	for _, link := range skeleton.Links {
+		if link[0] < 0 || link[1] < 0 || link[0] >= len(skeleton.Keypoints) || link[1] >= len(skeleton.Keypoints) || link[0] == link[1] {
+			errs = append(errs, controllerModel.ValidationError{Rule: "skeleton", Message: fmt.Sprintf("link %v does not join two keypoints", link)})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
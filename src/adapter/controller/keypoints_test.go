@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"context"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	dynamodbTable "scraper-backend/src/driver/database/dynamodb/table"
+	model "scraper-backend/src/driver/model"
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+var dogSkeleton = controllerModel.Skeleton{Keypoints: []string{"nose", "tail", "paw"}, Links: [][2]int{{0, 1}, {1, 2}}}
+
+func TestValidateKeypoints(t *testing.T) {
+	size := controllerModel.PictureSize{ID: model.NewUUID(), Box: controllerModel.Box{Width: 400, Height: 300}}
+	picture := controllerModel.Picture{Sizes: []controllerModel.PictureSize{size}}
+	c := ControllerPicture{DynamodbTag: &fakeDynamodbTag{tags: map[string][]controllerModel.Tag{
+		dynamodbTable.TagPrimaryKeySearched: {
+			{Name: "dog", Skeleton: model.NewNullable(dogSkeleton)},
+			{Name: "cat"},
+		},
+	}}}
+
+	tests := []struct {
+		name       string
+		tagName    string
+		sizeID     model.UUID
+		points     []controllerModel.Keypoint
+		wantErrors int
+	}{
+		{
+			name:    "labeled, occluded and not labeled",
+			tagName: "dog",
+			sizeID:  size.ID,
+			points: []controllerModel.Keypoint{
+				{Name: "nose", X: 10, Y: 10, Visibility: controllerModel.KeypointVisible},
+				{Name: "tail", X: 400, Y: 300, Visibility: controllerModel.KeypointOccluded},
+				{Name: "paw", X: -5, Y: 500, Visibility: controllerModel.KeypointNotLabeled},
+			},
+		},
+		{
+			name:       "tag without skeleton",
+			tagName:    "cat",
+			sizeID:     size.ID,
+			points:     []controllerModel.Keypoint{{Name: "nose", X: 10, Y: 10, Visibility: controllerModel.KeypointVisible}},
+			wantErrors: 1,
+		},
+		{
+			name:       "unknown size",
+			tagName:    "dog",
+			sizeID:     model.NewUUID(),
+			points:     []controllerModel.Keypoint{{Name: "nose", X: 10, Y: 10, Visibility: controllerModel.KeypointVisible}},
+			wantErrors: 1,
+		},
+		{
+			name:    "not in the skeleton, repeated, unknown visibility and outside",
+			tagName: "dog",
+			sizeID:  size.ID,
+			points: []controllerModel.Keypoint{
+				{Name: "ear", X: 10, Y: 10, Visibility: controllerModel.KeypointVisible},
+				{Name: "nose", X: 10, Y: 10, Visibility: controllerModel.KeypointVisible},
+				{Name: "nose", X: 20, Y: 20, Visibility: controllerModel.KeypointVisible},
+				{Name: "tail", X: 10, Y: 10, Visibility: 3},
+				{Name: "paw", X: 401, Y: 10, Visibility: controllerModel.KeypointOccluded},
+			},
+			wantErrors: 4,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := c.validateKeypoints(context.Background(), picture, test.tagName, controllerModel.Keypoints{PictureSizeID: test.sizeID, Points: test.points})
+			if test.wantErrors == 0 {
+				if err != nil {
+					t.Errorf("validateKeypoints() error = %v, want none", err)
+				}
+				return
+			}
+			validationErrors, ok := err.(controllerModel.ValidationErrors)
+			if !ok || len(validationErrors) != test.wantErrors {
+				t.Errorf("validateKeypoints() error = %v, want %d validation errors", err, test.wantErrors)
+			}
+		})
+	}
+}
+
+func TestCropKeypoints(t *testing.T) {
+	tests := []struct {
+		name        string
+		points      []controllerModel.Keypoint
+		want        []controllerModel.Keypoint
+		wantLabeled bool
+	}{
+		{
+			name: "inside and on the edges",
+			points: []controllerModel.Keypoint{
+				{Name: "nose", X: 150, Y: 160, Visibility: controllerModel.KeypointVisible},
+				{Name: "tail", X: 300, Y: 300, Visibility: controllerModel.KeypointOccluded},
+			},
+			want: []controllerModel.Keypoint{
+				{Name: "nose", X: 50, Y: 60, Visibility: controllerModel.KeypointVisible},
+				{Name: "tail", X: 200, Y: 200, Visibility: controllerModel.KeypointOccluded},
+			},
+			wantLabeled: true,
+		},
+		{
+			name: "outside points are no longer labeled",
+			points: []controllerModel.Keypoint{
+				{Name: "nose", X: 150, Y: 160, Visibility: controllerModel.KeypointVisible},
+				{Name: "tail", X: 50, Y: 160, Visibility: controllerModel.KeypointVisible},
+				{Name: "paw", X: 150, Y: 301, Visibility: controllerModel.KeypointOccluded},
+			},
+			want: []controllerModel.Keypoint{
+				{Name: "nose", X: 50, Y: 60, Visibility: controllerModel.KeypointVisible},
+				{Name: "tail", Visibility: controllerModel.KeypointNotLabeled},
+				{Name: "paw", Visibility: controllerModel.KeypointNotLabeled},
+			},
+			wantLabeled: true,
+		},
+		{
+			name: "none left",
+			points: []controllerModel.Keypoint{
+				{Name: "nose", X: 50, Y: 50, Visibility: controllerModel.KeypointVisible},
+				{Name: "tail", X: 150, Y: 150, Visibility: controllerModel.KeypointNotLabeled},
+			},
+			want: []controllerModel.Keypoint{
+				{Name: "nose", Visibility: controllerModel.KeypointNotLabeled},
+				{Name: "tail", Visibility: controllerModel.KeypointNotLabeled},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keypoints, labeled := cropKeypoints(controllerModel.Keypoints{Points: test.points}, cropBox)
+			if labeled != test.wantLabeled {
+				t.Errorf("cropKeypoints() labeled = %v, want %v", labeled, test.wantLabeled)
+			}
+			if !slices.Equal(keypoints.Points, test.want) {
+				t.Errorf("cropKeypoints() = %+v, want %+v", keypoints.Points, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateSkeleton(t *testing.T) {
+	tests := []struct {
+		name       string
+		skeleton   controllerModel.Skeleton
+		wantErrors int
+	}{
+		{name: "valid", skeleton: dogSkeleton},
+		{name: "without link", skeleton: controllerModel.Skeleton{Keypoints: []string{"nose"}}},
+		{name: "without keypoint", skeleton: controllerModel.Skeleton{}, wantErrors: 1},
+		{name: "empty and repeated names", skeleton: controllerModel.Skeleton{Keypoints: []string{"nose", "", "nose"}}, wantErrors: 2},
+		{name: "links outside or on the same keypoint", skeleton: controllerModel.Skeleton{Keypoints: []string{"nose", "tail"}, Links: [][2]int{{0, 2}, {-1, 0}, {1, 1}}}, wantErrors: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateSkeleton(test.skeleton)
+			if test.wantErrors == 0 {
+				if err != nil {
+					t.Errorf("validateSkeleton() error = %v, want none", err)
+				}
+				return
+			}
+			validationErrors, ok := err.(controllerModel.ValidationErrors)
+			if !ok || len(validationErrors) != test.wantErrors {
+				t.Errorf("validateSkeleton() error = %v, want %d validation errors", err, test.wantErrors)
+			}
+		})
+	}
+}
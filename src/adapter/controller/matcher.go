@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	"strings"
+)
+
+// letters and digits make the words, anything else like `-` or `_` separates them
+const tagWordBoundary = `[^\p{L}\p{N}]`
+
+// blocked tags compiled once, to match the tags of many pictures
+type TagMatcher struct {
+	rules []tagRule
+}
+
+// name or synonym of a blocked tag
+type tagRule struct {
+	tag     controllerModel.Tag
+	pattern string
+	regexp  *regexp.Regexp
+}
+
+func NewTagMatcher(tags []controllerModel.Tag) (*TagMatcher, error) {
+	var matcher TagMatcher
+	for _, tag := range tags {
+		for _, pattern := range tagNames(tag) {
+			compiled, err := compileTagPattern(tag.Mode, pattern)
+			if err != nil {
+				return nil, fmt.Errorf("tag `%s`: %v", tag.Name, err)
+			}
+			matcher.rules = append(matcher.rules, tagRule{tag: tag, pattern: pattern, regexp: compiled})
+		}
+	}
+	return &matcher, nil
+}
+
+// case insensitive expression of a name in a mode
+func compileTagPattern(mode string, pattern string) (*regexp.Regexp, error) {
+	quoted := regexp.QuoteMeta(strings.ToLower(pattern))
+	switch mode {
+	case controllerModel.TagModeExact:
+		return regexp.Compile(`^` + quoted + `$`)
+	case controllerModel.TagModePrefix:
+		return regexp.Compile(`^` + quoted)
+	case controllerModel.TagModeWord, "":
+		return regexp.Compile(`(^|` + tagWordBoundary + `)` + quoted + `($|` + tagWordBoundary + `)`)
+	case controllerModel.TagModeRegex:
+		compiled, err := regexp.Compile(`(?i)` + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression `%s`: %v", pattern, err)
+		}
+		return compiled, nil
+	default:
+		return nil, fmt.Errorf("mode must be `%s`, `%s`, `%s` or `%s` and is `%s`", controllerModel.TagModeExact, controllerModel.TagModePrefix, controllerModel.TagModeWord, controllerModel.TagModeRegex, mode)
+	}
+}
+
+// every rule hitting one of the names
+func (m TagMatcher) Match(names []string) []controllerModel.TagMatch {
+	matches := []controllerModel.TagMatch{}
+	for _, name := range names {
+		lowered := strings.ToLower(name)
+		for _, rule := range m.rules {
+			if rule.regexp.MatchString(lowered) {
+				matches = append(matches, controllerModel.TagMatch{Name: name, Tag: rule.tag, Pattern: rule.pattern})
+			}
+		}
+	}
+	return matches
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestTagMatcherMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		tag   controllerModel.Tag
+		names []string
+		want  []string
+	}{
+		{
+			name:  "word",
+			tag:   controllerModel.Tag{Name: "art", Mode: controllerModel.TagModeWord},
+			names: []string{"art", "pop-art", "art_deco", "street art", "party", "artwork", "Pop-Art"},
+			want:  []string{"art", "pop-art", "art_deco", "street art", "Pop-Art"},
+		},
+		{
+			name:  "empty mode is word",
+			tag:   controllerModel.Tag{Name: "art"},
+			names: []string{"art", "pop-art", "party", "artwork"},
+			want:  []string{"art", "pop-art"},
+		},
+		{
+			name:  "exact",
+			tag:   controllerModel.Tag{Name: "art", Mode: controllerModel.TagModeExact},
+			names: []string{"art", "ART", "pop-art", "artwork"},
+			want:  []string{"art", "ART"},
+		},
+		{
+			name:  "prefix",
+			tag:   controllerModel.Tag{Name: "art", Mode: controllerModel.TagModePrefix},
+			names: []string{"art", "artwork", "pop-art", "party"},
+			want:  []string{"art", "artwork"},
+		},
+		{
+			name:  "synonyms",
+			tag:   controllerModel.Tag{Name: "art", Synonyms: []string{"painting"}},
+			names: []string{"oil painting", "paintings", "art"},
+			want:  []string{"oil painting", "art"},
+		},
+		{
+			name:  "metacharacters quoted in word mode",
+			tag:   controllerModel.Tag{Name: "c++", Mode: controllerModel.TagModeWord},
+			names: []string{"c++", "learn c++", "c", "cc"},
+			want:  []string{"c++", "learn c++"},
+		},
+		{
+			name:  "metacharacters quoted in exact mode",
+			tag:   controllerModel.Tag{Name: "a.b", Mode: controllerModel.TagModeExact},
+			names: []string{"a.b", "axb"},
+			want:  []string{"a.b"},
+		},
+		{
+			name:  "regex",
+			tag:   controllerModel.Tag{Name: "^nude|nsfw$", Mode: controllerModel.TagModeRegex},
+			names: []string{"nude art", "NSFW", "art nude", "nsfw content"},
+			want:  []string{"nude art", "NSFW"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matcher, err := NewTagMatcher([]controllerModel.Tag{test.tag})
+			if err != nil {
+				t.Fatalf("NewTagMatcher() error = %v", err)
+			}
+			got := []string{}
+			for _, match := range matcher.Match(test.names) {
+				got = append(got, match.Name)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("Match() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewTagMatcherError(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  controllerModel.Tag
+	}{
+		{name: "invalid regex", tag: controllerModel.Tag{Name: "c++", Mode: controllerModel.TagModeRegex}},
+		{name: "unknown mode", tag: controllerModel.Tag{Name: "art", Mode: "fuzzy"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewTagMatcher([]controllerModel.Tag{test.tag}); err == nil {
+				t.Errorf("NewTagMatcher() error = nil, want an error")
+			}
+		})
+	}
+}
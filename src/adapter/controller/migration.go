@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"context"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+)
+
+// the tags used to be stored in a list, writing the pictures again stores them in a map by tag ID
+func (c ControllerPicture) MigratePictureTags(ctx context.Context) (*controllerModel.TagMigration, error) {
+	migration := controllerModel.TagMigration{Rewritten: map[string]int{}}
+	for _, state := range []string{controllerModel.PictureStateProcess, controllerModel.PictureStateValidation, controllerModel.PictureStateProduction, controllerModel.PictureStateBlocked} {
+		dynamodb, err := c.driverDynamodbMap(state)
+		if err != nil {
+			return nil, err
+		}
+		pictures, err := dynamodb.ReadPictures(ctx, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		// one by one, each picture is written with its audit entry
+		for _, picture := range pictures {
+			rewritten := picture
+			rewritten.Version++
+			audit, err := newPictureAudit(ctx, "MigratePictureTags", picture.Origin, picture.ID, picture, rewritten)
+			if err != nil {
+				return nil, err
+			}
+			if err := dynamodb.CreatePicture(ctx, picture.ID, picture, audit); err != nil {
+				return nil, err
+			}
+		}
+		migration.Rewritten[state] = len(pictures)
+	}
+	return &migration, nil
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	model "scraper-backend/src/driver/model"
+	"time"
+)
+
+// status of an annotation set
+const (
+	AnnotationSetPending  = "pending"
+	AnnotationSetAccepted = "accepted"
+	AnnotationSetRejected = "rejected"
+	AnnotationSetMerged   = "merged" // merged into the accepted set
+)
+
+// decisions of the review of the annotation sets of a picture
+const (
+	AnnotationReviewAccept = "accept"
+	AnnotationReviewMerge  = "merge"
+	AnnotationReviewReject = "reject"
+)
+
+// tags drawn by one annotator, at most one set of a picture is accepted and used on promotion
+type AnnotationSet struct {
+	ID           model.UUID
+	Annotator    string
+	Tags         []PictureTag
+	Status       string
+	CreationDate time.Time
+	Reviewer     string
+	ReviewDate   time.Time // zero until reviewed
+}
+
+type AnnotationReview struct {
+	Decision string
+	SetIDs   []model.UUID // one set is accepted, several are merged, any are rejected
+	Reviewer string
+}
+
+// agreement between the sets of a picture waiting for a review
+type AnnotationAgreement struct {
+	Origin    string
+	ID        model.UUID
+	Version   int
+	Sets      int
+	Agreement float64 // between 0 and 1, 1 when the sets have the same boxes
+}
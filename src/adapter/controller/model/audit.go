@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+	model "scraper-backend/src/driver/model"
+	"time"
+)
+
+// entities whose mutations are recorded in the audit log
+const (
+	AuditEntityPicture = "picture"
+	AuditEntityTag     = "tag"
+	AuditEntityUser    = "user"
+)
+
+// actor of the mutations made without one in their context
+const AuditActorUnknown = "unknown"
+
+// one mutation of a picture, a tag or a user, entries are never updated nor deleted
+type Audit struct {
+	ID           model.UUID
+	Entity       string     // picture, tag or user
+	Origin       string     // primary key of the entity
+	EntityID     model.UUID // sort key of the entity
+	Action       string     // name of the mutation, e.g. UpdatePictureTag
+	Actor        string
+	CreationDate time.Time
+	Before       string // JSON of the fields changed by the action, empty when the entity did not exist
+	After        string // JSON of the fields changed by the action, empty when the entity was deleted
+}
+
+// empty fields are not filtered
+type AuditFilter struct {
+	Entity string
+	Actor  string
+	Action string
+	From   time.Time
+	To     time.Time
+}
+
+type actorKey struct{}
+
+// the actor is recorded in the audit entries of the mutations made with the context
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ContextActor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	if actor == "" {
+		return AuditActorUnknown
+	}
+	return actor
+}
@@ -0,0 +1,25 @@
+package controller
+
+import (
+	model "scraper-backend/src/driver/model"
+	"time"
+)
+
+// pictures of the process and validation tables moved to the blocked table because they match the blocked tags or users
+type BlockSweep struct {
+	Actor     string
+	StartDate time.Time
+	EndDate   time.Time
+	Checked   int
+	Pictures  []BlockSweepPicture
+	Message   string // why the sweep stopped before the end
+}
+
+type BlockSweepPicture struct {
+	Origin  string
+	ID      model.UUID
+	From    string
+	Reason  string
+	Status  string // done or failed, like the bulk operations
+	Message string
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	model "scraper-backend/src/driver/model"
+)
+
+// operations available on multiple pictures at once
+const (
+	PictureBulkTransfer  = "transfer"
+	PictureBulkBlock     = "block"
+	PictureBulkUnblock   = "unblock"
+	PictureBulkDelete    = "delete"
+	PictureBulkAddTag    = "addTag"
+	PictureBulkRemoveTag = "removeTag"
+)
+
+// status of one picture after a bulk operation
+const (
+	PictureBulkStatusDone    = "done"
+	PictureBulkStatusDryRun  = "dryRun"
+	PictureBulkStatusFailed  = "failed"
+	PictureBulkStatusSkipped = "skipped" // the picture is repeated in the request, only its first occurrence is processed
+)
+
+type PictureBulk struct {
+	Operation string
+	Pictures  []PictureKey
+	From      string // state of the pictures
+	To        string // state after a transfer
+	Actor     string
+	Reason    string
+	Override  bool
+	TagName   string // tag added or removed
+	DryRun    bool   // only report what would change
+}
+
+type PictureKey struct {
+	Origin string
+	ID     model.UUID
+}
+
+type PictureBulkResult struct {
+	Origin  string
+	ID      model.UUID
+	Status  string
+	Message string
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"fmt"
+	model "scraper-backend/src/driver/model"
+)
+
+// the picture was written by someone else since it was read
+type PictureConflictError struct {
+	Origin  string
+	ID      model.UUID
+	Version int // version currently stored
+}
+
+func (e PictureConflictError) Error() string {
+	return fmt.Sprintf("picture %s/%s has been modified, its version is now %d", e.Origin, e.ID, e.Version)
+}
+
+// the picture is not stored in the state, or in none when the state is empty
+type PictureNotFoundError struct {
+	State  string
+	Origin string
+	ID     string // name of the picture when it is looked up by the name of its former file
+}
+
+func (e PictureNotFoundError) Error() string {
+	if e.State == "" {
+		return fmt.Sprintf("picture %s/%s not found", e.Origin, e.ID)
+	}
+	return fmt.Sprintf("picture %s/%s not found in %s", e.Origin, e.ID, e.State)
+}
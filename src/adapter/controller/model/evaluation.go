@@ -0,0 +1,44 @@
+package controller
+
+import (
+	model "scraper-backend/src/driver/model"
+)
+
+type EvaluationFilter struct {
+	State   string // validation or production
+	Model   string
+	Weights string  // every weights of the model when empty
+	IoU     float64 // a prediction matches a box drawn by hand from it
+	Worst   int     // amount of pictures listed
+}
+
+// boxes predicted by a model compared with the boxes drawn by hand, over the pictures with a prediction of the model
+type Evaluation struct {
+	State    string
+	Model    string
+	Weights  string
+	IoU      float64
+	Pictures int
+	MeanAP   float64 // mean of the tags with a box drawn by hand
+	Tags     []EvaluationTag
+	Worst    []EvaluationPicture // lowest F1 first
+}
+
+type EvaluationTag struct {
+	Name          string
+	Humans        int // boxes drawn by hand
+	Predictions   int
+	TruePositives int
+	Precision     float64
+	Recall        float64
+	AP            float64 // area under the interpolated precision-recall curve
+}
+
+type EvaluationPicture struct {
+	Origin         string
+	ID             model.UUID
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+	F1             float64
+}
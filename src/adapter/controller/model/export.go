@@ -0,0 +1,39 @@
+package controller
+
+import (
+	model "scraper-backend/src/driver/model"
+)
+
+// annotations of a collection in the layout of the COCO datasets
+type Export struct {
+	Images      []ExportImage
+	Annotations []ExportAnnotation
+	Categories  []ExportCategory
+}
+
+type ExportImage struct {
+	ID        int
+	Origin    string
+	PictureID model.UUID
+	FileName  string // object key of the current size
+	Width     int
+	Height    int
+}
+
+type ExportAnnotation struct {
+	ID         int
+	ImageID    int
+	CategoryID int
+	Box        Box
+	Polygons   []Polygon
+	Mask       model.Nullable[Mask]
+	Area       float64
+	IsCrowd    bool       // a region of several objects ignored by training, never set since every tag is one instance, mask or not
+	Keypoints  []Keypoint // in the order of the skeleton of the category
+}
+
+type ExportCategory struct {
+	ID       int
+	Name     string
+	Skeleton model.Nullable[Skeleton]
+}
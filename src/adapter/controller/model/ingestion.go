@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"fmt"
+	model "scraper-backend/src/driver/model"
+)
+
+// the downloaded file cannot be stored, the scrapers skip the picture
+type PictureRejectedError struct {
+	Reason string
+}
+
+func (e PictureRejectedError) Error() string {
+	return fmt.Sprintf("picture rejected: %s", e.Reason)
+}
+
+// the file would have to be written in a format without encoder
+type FormatUnsupportedError struct {
+	Extension string
+}
+
+func (e FormatUnsupportedError) Error() string {
+	return fmt.Sprintf("files cannot be written as %s, only read", e.Extension)
+}
+
+// sizes whose dimensions did not match their file
+type SizeBackfill struct {
+	Fixed   []SizeBackfillPicture
+	Corrupt []SizeBackfillPicture // files that cannot be decoded
+}
+
+type SizeBackfillPicture struct {
+	State  string
+	Origin string
+	ID     model.UUID
+	SizeID model.UUID
+	From   Box
+	To     Box
+}
@@ -0,0 +1,24 @@
+package controller
+
+import (
+	model "scraper-backend/src/driver/model"
+)
+
+// pictures moved from the legacy keys `origin/name.ext` to the keys derived from the IDs
+type KeyMigration struct {
+	Moved   []KeyMigrationPicture
+	Missing []KeyMigrationPicture // pictures without key whose legacy file is missing
+}
+
+type KeyMigrationPicture struct {
+	State  string
+	Origin string
+	ID     model.UUID
+	From   string // legacy key
+	To     string // new key, empty when missing
+}
+
+// pictures written again so that their tags are stored by ID
+type TagMigration struct {
+	Rewritten map[string]int // amount of pictures by state
+}
@@ -2,29 +2,79 @@ package controller
 
 import (
 	"database/sql"
+	"fmt"
 	model "scraper-backend/src/driver/model"
 	"time"
 )
 
+// states of a picture, each one backed by its own table
+const (
+	PictureStateProcess    = "process"
+	PictureStateValidation = "validation"
+	PictureStateProduction = "production"
+	PictureStateBlocked    = "blocked"
+)
+
 type Picture struct {
-	Origin       string
+	Origin         string
+	ID             model.UUID
+	Name           string
+	OriginID       string
+	User           User
+	Extension      string
+	Sizes          []PictureSize
+	Title          string
+	Description    string
+	License        string
+	CreationDate   time.Time
+	Tags           []PictureTag
+	AnnotationSets []AnnotationSet // tags of each annotator waiting for or after a review
+	Transitions    []PictureTransition
+	Metadata       map[string]string // camera and exposure fields of the original file
+	Quality        model.Nullable[PictureQuality]
+	Version        int // incremented by the writes guarded by it
+}
+
+// scores of the original file computed on ingestion
+type PictureQuality struct {
+	Width       int
+	Height      int
+	Blur        float64 // variance of the Laplacian, the lower the blurrier
+	Luminance   float64 // mean luminance between 0 and 255
+	DarkRatio   float64 // share of the pixels with a luminance under 32
+	BrightRatio float64 // share of the pixels with a luminance above 223
+	Hash        string  // difference hash of the luminance in hexadecimal, near duplicates differ by a few bits
+}
+
+type PictureTransition struct {
 	ID           model.UUID
-	Name         string
-	OriginID     string
-	User         User
-	Extension    string
-	Sizes        []PictureSize
-	Title        string
-	Description  string
-	License      string
+	From         string
+	To           string
+	Actor        string
+	Reason       string
+	Override     bool // skip the validators of the transition, only for the admins
 	CreationDate time.Time
-	Tags         []PictureTag
+}
+
+// the actor of the request is not an admin and cannot skip the validators,
+// or is one but the transition would be recorded under another actor
+type OverrideForbiddenError struct {
+	Actor           string
+	TransitionActor string
+}
+
+func (e OverrideForbiddenError) Error() string {
+	if e.TransitionActor != "" {
+		return fmt.Sprintf("an override is recorded under the actor of the request %s, not %s", e.Actor, e.TransitionActor)
+	}
+	return fmt.Sprintf("only an admin can override the validation, %s is not one", e.Actor)
 }
 
 type PictureSize struct {
 	ID           model.UUID
 	CreationDate time.Time
 	Box          Box
+	ObjectKey    string // file of this size in the bucket, empty when it has none
 }
 
 type Box struct {
@@ -40,6 +90,8 @@ type PictureTag struct {
 	CreationDate   time.Time
 	OriginName     string
 	BoxInformation model.Nullable[BoxInformation]
+	Segmentation   model.Nullable[Segmentation]
+	Keypoints      model.Nullable[Keypoints]
 }
 
 type BoxInformation struct {
@@ -49,3 +101,58 @@ type BoxInformation struct {
 	Box           Box
 	Confidence    sql.NullFloat64
 }
+
+// instance segmentation of a tag, anchored to a size like the boxes
+type Segmentation struct {
+	Model         sql.NullString
+	Weights       sql.NullString
+	PictureSizeID model.UUID
+	Polygons      []Polygon
+	Mask          model.Nullable[Mask]
+	Confidence    sql.NullFloat64
+}
+
+// closed outline, the last point is linked to the first one
+type Polygon []Point
+
+type Point struct {
+	X float64
+	Y float64
+}
+
+// run-length encoded mask of the size, in column-major order and starting with a run of zeros
+type Mask struct {
+	Width  int
+	Height int
+	Counts []int
+}
+
+// pose of a tag, following the skeleton of the tag with the same name
+type Keypoints struct {
+	Model         sql.NullString
+	Weights       sql.NullString
+	PictureSizeID model.UUID
+	Points        []Keypoint
+	Confidence    sql.NullFloat64
+}
+
+const (
+	KeypointNotLabeled = 0
+	KeypointOccluded   = 1 // labeled but not visible
+	KeypointVisible    = 2
+)
+
+type Keypoint struct {
+	Name       string
+	X          float64
+	Y          float64
+	Visibility int
+}
+
+// file served for a picture, either its current file or one of its renditions
+type PictureFile struct {
+	Tag         string // changes whenever the content changes
+	Extension   string
+	Buffer      []byte
+	NotModified bool // the tag matches the cached one, the buffer is not read
+}
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	model "scraper-backend/src/driver/model"
+)
+
+// box of a tag found by a model in one size of a picture of the process table
+type Prediction struct {
+	Origin        string
+	ID            model.UUID
+	PictureSizeID model.UUID
+	TagName       string
+	Box           Box
+	Confidence    float64
+	Model         string
+	Weights       string
+}
+
+type PredictionImport struct {
+	Predictions []Prediction
+	Threshold   float64 // predictions less confident are skipped
+	Replace     bool    // the tags predicted before by the same models are deleted
+}
+
+// predictions written in one picture, the tags drawn by hand are never changed
+type PredictionResult struct {
+	Origin   string
+	ID       model.UUID
+	Status   string // done or failed, like a bulk operation
+	Created  int
+	Replaced int      // tags of the same models deleted
+	Skipped  int      // predictions under the threshold
+	Invalid  []string // predictions rejected, e.g. boxes outside of their size
+	Message  string
+}
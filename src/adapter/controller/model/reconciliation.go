@@ -0,0 +1,20 @@
+package controller
+
+import (
+	model "scraper-backend/src/driver/model"
+)
+
+// orphans found between the bucket and the picture tables
+type Reconciliation struct {
+	Files    []string                // files without a picture
+	Pictures []ReconciliationPicture // pictures without a file
+	Legacy   int                     // pictures still stored under the legacy keys, they are not compared
+	Fixed    bool                    // orphans have been deleted
+}
+
+type ReconciliationPicture struct {
+	State  string
+	Origin string
+	ID     model.UUID
+	Path   string // missing file
+}
@@ -0,0 +1,30 @@
+package controller
+
+import (
+	model "scraper-backend/src/driver/model"
+)
+
+// order in which the pictures are sampled for labeling
+const (
+	SampleStrategyConfidence   = "confidence"   // least confident predicted box first
+	SampleStrategyDisagreement = "disagreement" // boxes of the models agreeing the least first
+	SampleStrategyRare         = "rare"         // rarest predicted class in the validation and production tables first
+	SampleStrategyRandom       = "random"       // random pictures taken in turn from each predicted class
+)
+
+type SampleFilter struct {
+	State    string
+	Strategy string
+	Count    int
+	Seed     int64 // random strategy only, the same seed gives the same sample
+}
+
+// rank of a picture for a strategy, the lowest score first
+type Sample struct {
+	Origin     string
+	ID         model.UUID
+	Score      float64
+	Scored     bool         // the strategy has nothing to say about the picture, e.g. no prediction, it comes last
+	Duplicate  bool         // near duplicate of a picture ranked before, it comes after every other one
+	Duplicates []PictureKey // near duplicates ranked after the picture
+}
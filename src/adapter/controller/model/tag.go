@@ -11,4 +11,40 @@ type Tag struct {
 	Name         string
 	CreationDate time.Time
 	OriginName   string
+	Skeleton     model.Nullable[Skeleton]
+	Parent       string   // name of the searched tag it is a kind of, e.g. dog for puppy
+	Synonyms     []string // other names of the tag, searched and matched like its name
+	Mode         string   // how the names of a blocked tag match the tags of a picture, word when empty
+	TargetCount  int      // pictures of a searched tag wanted in the process, validation and production tables, not limited at 0
+	MaxPages     int      // pages of results read at most by a search of the tag on a host, not limited at 0
+}
+
+const (
+	TagModeExact  = "exact"  // the whole tag, e.g. art blocks art only
+	TagModePrefix = "prefix" // the start of the tag, e.g. art blocks artwork
+	TagModeWord   = "word"   // a word of the tag, e.g. art blocks pop-art but not party
+	TagModeRegex  = "regex"  // the names are regular expressions
+)
+
+// blocked tag whose name or synonym matches a tag of a picture
+type TagMatch struct {
+	Name    string // tag of the picture
+	Tag     Tag
+	Pattern string // name or synonym of the blocked tag
+}
+
+// keypoints expected for the instances of a tag and the links drawn between them
+type Skeleton struct {
+	Keypoints []string
+	Links     [][2]int // pairs of indexes in Keypoints
+}
+
+// pictures of a searched tag in the tables counted towards its target
+type TagProgress struct {
+	Name        string
+	TargetCount int
+	Process     int
+	Validation  int
+	Production  int
+	Remaining   int // pictures still wanted, 0 when the target is reached or not set
 }
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"fmt"
+	model "scraper-backend/src/driver/model"
+	"time"
+)
+
+// status of a task, a lease whose expiration date has passed returns to the queue
+const (
+	TaskStatusLeased    = "leased"
+	TaskStatusCompleted = "completed"
+)
+
+// the pictures of the queue are leased in the order of a sample strategy, or this one for the pictures created first
+const TaskPriorityOldest = "oldest"
+
+// labeling of a picture of the process or validation table by one user
+type Task struct {
+	ID             model.UUID // ID of the picture
+	State          string
+	Origin         string
+	User           string
+	Status         string
+	Priority       float64 // score of the picture in its sample when it was leased
+	LeaseDate      time.Time
+	ExpirationDate time.Time
+	CompletionDate time.Time
+	Version        int // incremented by every lease and completion
+}
+
+// the task was leased or completed by someone else since it was read
+type TaskConflictError struct {
+	ID    model.UUID
+	State string
+}
+
+func (e TaskConflictError) Error() string {
+	return fmt.Sprintf("task %s in %s has been modified", e.ID, e.State)
+}
+
+// throughput of one user, the completions are counted between the dates of the filter
+type TaskStats struct {
+	User         string
+	Leased       int           // leases still running
+	Expired      int           // leases expired and not completed
+	Completed    int           // tasks completed in the period
+	MeanDuration time.Duration // between the lease and the completion
+	PerHour      float64       // completions per hour over the period, or between the first and last completion
+}
+
+// empty dates are not filtered
+type TaskFilter struct {
+	From time.Time
+	To   time.Time
+}
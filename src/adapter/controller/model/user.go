@@ -11,4 +11,20 @@ type User struct {
 	Name         string
 	OriginID     string
 	CreationDate time.Time
+	Status       string // blocked when empty
+	Quota        int    // pictures ingested at most per searched tag, not limited at 0
+}
+
+const (
+	UserStatusBlocked = "blocked" // the pictures of the user are never ingested
+	UserStatusTrusted = "trusted" // the pictures of the user are moved to validation once ingested
+	UserStatusCapped  = "capped"  // only the quota applies to the pictures of the user
+)
+
+// pictures of the user in the tables
+type UserStats struct {
+	User     User
+	Ingested int // in any table
+	Blocked  int // in the blocked table
+	Promoted int // in the validation and production tables
 }
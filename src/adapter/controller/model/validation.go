@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ValidationError struct {
+	Rule    string // name of the validator that failed
+	Message string
+}
+
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, fmt.Sprintf("%s: %s", err.Rule, err.Message))
+	}
+	return fmt.Sprintf("picture validation has failed: %s", strings.Join(messages, ", "))
+}
@@ -2,17 +2,13 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/url"
-	"strconv"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
-
 	"golang.org/x/exp/slices"
 
 	"regexp"
-	"strings"
 
 	controllerModel "scraper-backend/src/adapter/controller/model"
 	interfaceAdapter "scraper-backend/src/adapter/interface"
@@ -45,44 +41,66 @@ func (c *ControllerPexels) SearchPhotos(ctx context.Context, quality string) err
 	if len(searchedTags) == 0 {
 		return fmt.Errorf("no searched tags")
 	}
-	// no blocked tag for pexels
+	policy, err := readBlockPolicy(ctx, c.ControllerTag, c.ControllerUser)
+	if err != nil {
+		return err
+	}
+
+	users, err := readUserPolicy(ctx, c.ControllerPicture, c.ControllerUser, searchedTags)
+	if err != nil {
+		return err
+	}
+
+	budget, err := readSearchBudget(ctx, c.ControllerPicture, searchedTags)
+	if err != nil {
+		return err
+	}
 
+	// the synonyms are searched like the names of their tags, the pictures are tagged with the name
+	canonicalNames := tagCanonicalNames(searchedTags, -1)
+	var searchedNames []string
 	for _, searchedTag := range searchedTags {
+		searchedNames = append(searchedNames, tagNames(searchedTag)...)
+	}
+
+	for _, searchedName := range searchedNames {
+		searchedTag := canonicalNames[searchedName]
+		if budget.exhausted(searchedTag) {
+			continue // enough pictures or pages of the tag
+		}
+
 		page := 1
-		searchPerPage, err := c.Api.SearchPhotosPerPage(searchedTag.Name, page)
+		searchPerPage, err := c.Api.SearchPhotosPerPage(searchedName, page)
 		if err != nil {
 			return fmt.Errorf("SearchPhotosPerPage has failed: %v", err)
 		}
 
-		for page := page; page <= searchPerPage.TotalResults/searchPerPage.PerPage; page++ {
-			searchPerPage, err = c.Api.SearchPhotosPerPage(searchedTag.Name, page)
+		for page := page; page <= searchPerPage.TotalResults/searchPerPage.PerPage && budget.page(searchedTag); page++ {
+			searchPerPage, err = c.Api.SearchPhotosPerPage(searchedName, page)
 			if err != nil {
 				return fmt.Errorf("SearchPhotosPerPage has failed: %v", err)
 			}
 
 			for _, photo := range searchPerPage.Photos {
-				// look for existing images
-				for _, state := range []string{"production", "validation", "process", "blocked"} {
-					projEx := expression.NamesList(expression.Name("OriginID"))
-					filtEx := expression.Name("OriginID").Equal(expression.Value(photo.ID))
-					pictures, err := c.ControllerPicture.ReadPictures(ctx, state, &projEx, &filtEx)
-					if err != nil {
-						return err
-					}
-					if len(pictures) > 0 {
-						continue // skip existing image
-					}
+				if budget.full(searchedTag) {
+					break // enough pictures of the tag
 				}
 
-				// look for unwanted user
-				users, err := c.ControllerUser.ReadUsers(ctx)
+				// look for existing images
+				exists, err := pictureExists(ctx, c.ControllerPicture, fmt.Sprint(photo.ID))
 				if err != nil {
 					return err
 				}
-				for _, user := range users {
-					if user.OriginID == fmt.Sprint(photo.PhotographerID) {
-						continue // skip the image with unwanted user
-					}
+				if exists {
+					continue // skip existing image
+				}
+
+				// look for unwanted user or tag, pexels has no tags but the alternative text of the photo
+				if reason := policy.reason(origin, fmt.Sprint(photo.PhotographerID), nil, photo.Alt); reason != "" {
+					continue // skip the image with unwanted user or tag
+				}
+				if users.quotaReached(origin, fmt.Sprint(photo.PhotographerID), searchedTag) {
+					continue // skip the image of a user with enough of them
 				}
 
 				//find download link and extension
@@ -126,29 +144,10 @@ func (c *ControllerPexels) SearchPhotos(ctx context.Context, quality string) err
 					OriginID:     fmt.Sprint(photo.PhotographerID),
 					CreationDate: now,
 				}
-				linkURL, err := url.Parse(link)
-				if err != nil {
-					return err
-				}
-				width, err := strconv.Atoi(linkURL.Query().Get("w"))
-				if err != nil {
-					return err
-				}
-				height, err := strconv.Atoi(linkURL.Query().Get("h"))
-				if err != nil {
-					return err
-				}
-				zero := 0
-				box := controllerModel.Box{
-					Tlx:    zero, // original x anchor
-					Tly:    zero, // original y anchor
-					Width:  width,
-					Height: height,
-				}
 				tags := []controllerModel.PictureTag{
 					{
 						ID:           model.NewUUID(),
-						Name:         strings.ToLower(searchedTag.Name),
+						Name:         searchedTag,
 						CreationDate: now,
 						OriginName:   origin,
 						// BoxInformation
@@ -158,7 +157,7 @@ func (c *ControllerPexels) SearchPhotos(ctx context.Context, quality string) err
 					{
 						ID:           pictureSizeID,
 						CreationDate: now,
-						Box:          box,
+						// Box set from the file
 					},
 				}
 				picture := controllerModel.Picture{
@@ -176,7 +175,12 @@ func (c *ControllerPexels) SearchPhotos(ctx context.Context, quality string) err
 					Tags:         tags,
 				}
 
-				if err := c.ControllerPicture.CreatePicture(ctx, model.NewUUID(), picture, buffer); err != nil {
+				if err := users.createPicture(ctx, c.ControllerPicture, budget, searchedTag, picture, buffer); err != nil {
+					var rejected controllerModel.PictureRejectedError
+					if errors.As(err, &rejected) {
+						fmt.Printf("%v\n", rejected)
+						continue // skip the rejected image
+					}
 					return fmt.Errorf("CreatePicture has failed: %v", err)
 				}
 			}
@@ -5,9 +5,9 @@ import (
 	"context"
 	"fmt"
 	"image"
+	"image/gif"
 	"image/jpeg"
 	"image/png"
-	"path/filepath"
 	controllerModel "scraper-backend/src/adapter/controller/model"
 	interfaceDatabase "scraper-backend/src/driver/interface/database"
 	interfaceStorage "scraper-backend/src/driver/interface/storage"
@@ -15,27 +15,40 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"golang.org/x/exp/slices"
+	"golang.org/x/image/tiff"
 )
 
 type ControllerPicture struct {
 	S3                 interfaceStorage.DriverS3
 	BucketName         string
+	RenditionWidths    []int  // ascending widths of the resized copies served on demand
+	NormalizeFormat    string // ingested files are converted to this format when set
+	Quality            int    // jpg quality of the files written
+	StripMetadata      bool   // ingested files with EXIF are written again without it
+	QualityGate        QualityGate
 	DynamodbProcess    interfaceDatabase.DriverDynamodbPicture
 	DynamodbValidation interfaceDatabase.DriverDynamodbPicture
 	DynamodbProduction interfaceDatabase.DriverDynamodbPicture
 	DynamodbBlocked    interfaceDatabase.DriverDynamodbPicture
+	DynamodbTag        interfaceDatabase.DriverDynamodbTag  // skeletons of the keypoints
+	DynamodbUser       interfaceDatabase.DriverDynamodbUser // blocked users swept from the tables
+	DynamodbAudit      interfaceDatabase.DriverDynamodbAudit
+	Validators         []PictureValidator // run before a picture is promoted to production
+	Admins             []string           // actors allowed to skip the validators
+	sampleCache        *sampleCache
 }
 
 func (c ControllerPicture) driverDynamodbMap(state string) (interfaceDatabase.DriverDynamodbPicture, error) {
 	switch state {
-	case "production":
+	case controllerModel.PictureStateProduction:
 		return c.DynamodbProduction, nil
-	case "validation":
+	case controllerModel.PictureStateValidation:
 		return c.DynamodbValidation, nil
-	case "process":
-		return c.DynamodbProcess, nil
-	case "blocked":
+	case controllerModel.PictureStateProcess:
 		return c.DynamodbProcess, nil
+	case controllerModel.PictureStateBlocked:
+		return c.DynamodbBlocked, nil
 	default:
 		return nil, fmt.Errorf("table name %s not available", state)
 	}
@@ -57,345 +70,602 @@ func (c ControllerPicture) ReadPicture(ctx context.Context, state string, primar
 	return dynamodb.ReadPicture(ctx, primaryKey, sortKey)
 }
 
-func (c ControllerPicture) ReadPictureFile(ctx context.Context, origin, name, extension string) ([]byte, error) {
-	fileName := fmt.Sprintf("%s.%s", name, extension)
-	path := filepath.Join(origin, fileName)
-
-	buffer, err := c.S3.ItemRead(ctx, c.BucketName, path)
+// same as ReadPicture but a missing picture is an error
+func (c ControllerPicture) readPicture(ctx context.Context, state string, primaryKey string, sortKey model.UUID) (*controllerModel.Picture, error) {
+	picture, err := c.ReadPicture(ctx, state, primaryKey, sortKey)
 	if err != nil {
 		return nil, err
 	}
-	return buffer, nil
-}
-
-func (c ControllerPicture) CreatePicture(ctx context.Context, id model.UUID, picture controllerModel.Picture, buffer []byte) error {
-	path := fmt.Sprintf("%s/%s.%s", picture.Origin, picture.Name, picture.Extension)
-	if err := c.S3.ItemCreate(ctx, bytes.NewReader(buffer), c.BucketName, path); err != nil {
-		return err
+	if picture == nil {
+		return nil, controllerModel.PictureNotFoundError{State: state, Origin: primaryKey, ID: sortKey.String()}
 	}
-	return c.DynamodbProcess.CreatePicture(ctx, id, picture)
+	return picture, nil
 }
 
-func (c ControllerPicture) DeletePicture(ctx context.Context, primaryKey string, sortKey model.UUID) error {
-	return c.DynamodbProcess.DeletePicture(ctx, primaryKey, sortKey)
+// reads the picture edited by the caller, it must not have been written since the caller read it
+func (c ControllerPicture) readPictureVersion(ctx context.Context, state string, primaryKey string, sortKey model.UUID, version int) (*controllerModel.Picture, error) {
+	picture, err := c.readPicture(ctx, state, primaryKey, sortKey)
+	if err != nil {
+		return nil, err
+	}
+	if picture.Version != version {
+		return nil, controllerModel.PictureConflictError{Origin: primaryKey, ID: sortKey, Version: picture.Version}
+	}
+	return picture, nil
 }
 
-func (c ControllerPicture) DeletePictureAndFile(ctx context.Context, primaryKey string, sortKey model.UUID, name string) error {
-	if err := c.DynamodbProcess.DeletePicture(ctx, primaryKey, sortKey); err != nil {
-		return err
+// returns the current file of the picture, or its rendition closest to the width when it is set.
+// the file is not read when its tag matches the cached one.
+func (c ControllerPicture) ReadPictureFile(ctx context.Context, state string, primaryKey string, sortKey model.UUID, width int, cachedTag string) (*controllerModel.PictureFile, error) {
+	picture, err := c.readPicture(ctx, state, primaryKey, sortKey)
+	if err != nil {
+		return nil, err
 	}
-	path := filepath.Join(primaryKey, name)
-	return c.S3.ItemDelete(ctx, c.BucketName, path)
+	return c.readPictureFile(ctx, *picture, width, cachedTag)
 }
 
-func (c ControllerPicture) DeletePicturesAndFiles(ctx context.Context, pictures []controllerModel.Picture) error {
-	for _, picture := range pictures {
-		if err := c.DynamodbProcess.DeletePicture(ctx, picture.Origin, picture.ID); err != nil {
-			return err
+// same as ReadPictureFile for the picture whose file was stored under its name before the keys were derived from the IDs, whatever its state
+func (c ControllerPicture) ReadPictureFileByName(ctx context.Context, primaryKey string, name string, width int, cachedTag string) (*controllerModel.PictureFile, error) {
+	filtEx := expression.Name("Origin").Equal(expression.Value(primaryKey)).And(expression.Name("Name").Equal(expression.Value(name)))
+	for _, state := range []string{controllerModel.PictureStateProcess, controllerModel.PictureStateValidation, controllerModel.PictureStateProduction, controllerModel.PictureStateBlocked} {
+		pictures, err := c.ReadPictures(ctx, state, nil, &filtEx)
+		if err != nil {
+			return nil, err
 		}
-		path := filepath.Join(picture.Origin, picture.Name)
-		if err := c.S3.ItemDelete(ctx, c.BucketName, path); err != nil {
-			return err
+		if len(pictures) > 0 {
+			return c.readPictureFile(ctx, pictures[0], width, cachedTag)
 		}
 	}
-	return nil
+	return nil, controllerModel.PictureNotFoundError{Origin: primaryKey, ID: name}
 }
 
-func (c ControllerPicture) CreatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, tagID model.UUID, tag controllerModel.PictureTag) error {
-	if err := c.DynamodbProcess.CreatePictureTag(ctx, primaryKey, sortKey, tagID, tag); err != nil {
-		return err
+func (c ControllerPicture) readPictureFile(ctx context.Context, picture controllerModel.Picture, width int, cachedTag string) (*controllerModel.PictureFile, error) {
+	key, err := currentObjectKey(picture)
+	if err != nil {
+		return nil, err
 	}
-	return nil
-}
 
-func (c ControllerPicture) UpdatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, tagID model.UUID, tag controllerModel.PictureTag) error {
-	if err := c.DynamodbProcess.UpdatePictureTag(ctx, primaryKey, sortKey, tagID, tag); err != nil {
-		return err
+	// the content of a key never changes, a new size always gets a new key
+	size := picture.Sizes[len(picture.Sizes)-1]
+	width = c.renditionWidth(width, size.Box.Width)
+	file := controllerModel.PictureFile{Tag: size.ID.String(), Extension: keyExtension(key)}
+	if width > 0 {
+		file.Tag = fmt.Sprintf("%s-w%d", size.ID, width)
+		file.Extension = keyExtension(renditionObjectKey(key, width))
+	}
+	if cachedTag != "" && cachedTag == file.Tag {
+		file.NotModified = true
+		return &file, nil
 	}
-	return nil
-}
 
-func (c ControllerPicture) DeletePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, tagID model.UUID) error {
-	if err := c.DynamodbProcess.DeletePictureTag(ctx, primaryKey, sortKey, tagID); err != nil {
-		return err
+	if width > 0 {
+		file.Buffer, err = c.readRendition(ctx, key, width)
+	} else {
+		file.Buffer, err = c.S3.ItemRead(ctx, c.BucketName, key)
 	}
-	return nil
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
 }
 
-func (c ControllerPicture) UpdatePictureCrop(ctx context.Context, primaryKey string, sortKey model.UUID, name string, pictureSizeID model.UUID, box controllerModel.Box) error {
-	newPicture, err := c.cropPicture(ctx, box, primaryKey, sortKey, pictureSizeID)
+// the file is checked and stored under the key of the last size of the picture.
+// a file that cannot be stored returns a PictureRejectedError,
+// a picture failing the quality gate is stored in the blocked table when the gate blocks, the state it is stored in is returned.
+func (c ControllerPicture) CreatePicture(ctx context.Context, id model.UUID, picture controllerModel.Picture, buffer []byte) (string, error) {
+	buffer, blockReason, err := c.ingestFile(&picture, buffer)
 	if err != nil {
-		return err
+		return "", err
+	}
+	picture.ID = id
+	state := controllerModel.PictureStateProcess
+	dynamodb := c.DynamodbProcess
+	if blockReason != "" {
+		state = controllerModel.PictureStateBlocked
+		dynamodb = c.DynamodbBlocked
+		// the picture never was in another state
+		picture.Transitions = append(picture.Transitions, controllerModel.PictureTransition{
+			ID:           model.NewUUID(),
+			To:           controllerModel.PictureStateBlocked,
+			Actor:        qualityGateActor,
+			Reason:       blockReason,
+			CreationDate: time.Now(),
+		})
+	}
+	size := &picture.Sizes[len(picture.Sizes)-1]
+	size.ObjectKey = objectKey(picture, *size)
+	after := picture
+	after.Version++
+	audit, err := newPictureAudit(ctx, "CreatePicture", picture.Origin, id, nil, after)
+	if err != nil {
+		return "", err
+	}
+	if err := c.S3.ItemCreate(ctx, bytes.NewReader(buffer), c.BucketName, size.ObjectKey); err != nil {
+		return "", err
+	}
+	if err := dynamodb.CreatePicture(ctx, id, picture, audit); err != nil {
+		return "", compensate(err, func() error { return c.S3.ItemDelete(ctx, c.BucketName, size.ObjectKey) })
 	}
+	return state, nil
+}
 
-	newFile, err := c.cropFile(ctx, box, primaryKey, name)
+func (c ControllerPicture) DeletePicture(ctx context.Context, primaryKey string, sortKey model.UUID, version int) error {
+	picture, err := c.readPictureVersion(ctx, controllerModel.PictureStateProcess, primaryKey, sortKey, version)
 	if err != nil {
 		return err
 	}
-
-	path := fmt.Sprintf("%s/%s.%s", newPicture.Origin, newPicture.Name, newPicture.Extension)
-	buffer, err := fileToBuffer(*newPicture, newFile)
+	audit, err := newPictureAudit(ctx, "DeletePicture", primaryKey, sortKey, *picture, nil)
 	if err != nil {
 		return err
 	}
-	if err := c.S3.ItemCreate(ctx, buffer, c.BucketName, path); err != nil {
+	return c.DynamodbProcess.DeletePicture(ctx, primaryKey, sortKey, version, audit)
+}
+
+func (c ControllerPicture) DeletePictureAndFile(ctx context.Context, primaryKey string, sortKey model.UUID, version int) error {
+	picture, err := c.readPictureVersion(ctx, controllerModel.PictureStateProcess, primaryKey, sortKey, version)
+	if err != nil {
 		return err
 	}
+	return c.deletePictureAndFiles(ctx, c.DynamodbProcess, *picture, "DeletePictureAndFile")
+}
 
-	// update current image
-	if err := c.DynamodbProcess.CreatePicture(ctx, newPicture.ID, *newPicture); err != nil {
-		return err
+func (c ControllerPicture) DeletePicturesAndFiles(ctx context.Context, pictures []controllerModel.Picture) error {
+	for _, picture := range pictures {
+		if err := c.deletePictureAndFiles(ctx, c.DynamodbProcess, picture, "DeletePicturesAndFiles"); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (c ControllerPicture) CreatePictureCrop(ctx context.Context, primaryKey string, sortKey model.UUID, id model.UUID, pictureSizeID model.UUID, box controllerModel.Box) error {
-	newPicture, err := c.DynamodbProcess.ReadPicture(ctx, primaryKey, sortKey)
+// the row is deleted first so that no row points to a missing file.
+// it is restored, and audited as created again, only when none of its files could be deleted
+func (c ControllerPicture) deletePictureAndFiles(ctx context.Context, dynamodb interfaceDatabase.DriverDynamodbPicture, picture controllerModel.Picture, action string) error {
+	audit, err := newPictureAudit(ctx, action, picture.Origin, picture.ID, picture, nil)
 	if err != nil {
 		return err
 	}
-	name := fmt.Sprintf("%s_%s", newPicture.OriginID, time.Now().Format(time.RFC3339))
-	newPicture.Name = name
-	newPicture.CreationDate = time.Now()
-
-	newPicture, err = c.cropPicture(ctx, box, primaryKey, sortKey, pictureSizeID)
-	if err != nil {
+	if err := dynamodb.DeletePicture(ctx, picture.Origin, picture.ID, picture.Version, audit); err != nil {
 		return err
 	}
-
-	newFile, err := c.cropFile(ctx, box, primaryKey, name)
-	if err != nil {
+	started, err := c.deletePictureFiles(ctx, picture)
+	if err == nil || started {
 		return err
 	}
+	return compensate(err, func() error {
+		restored := picture
+		restored.Version++
+		audit, err := newPictureAudit(ctx, action, picture.Origin, picture.ID, nil, restored)
+		if err != nil {
+			return err
+		}
+		return dynamodb.CreatePicture(ctx, picture.ID, picture, audit)
+	})
+}
 
-	destinationPath := fmt.Sprintf("%s/%s.%s", newPicture.Origin, name, newPicture.Extension)
-	buffer, err := fileToBuffer(*newPicture, newFile)
+// deletes the files of a picture whose row is already deleted, started is false when none of them was.
+// once one is deleted the others are all tried, the ones left are orphans removed by the reconciler
+func (c ControllerPicture) deletePictureFiles(ctx context.Context, picture controllerModel.Picture) (started bool, err error) {
+	var left []string
+	var firstErr error
+	for _, key := range c.objectKeysWithRenditions(picture) {
+		if err := c.S3.ItemDelete(ctx, c.BucketName, key); err != nil {
+			if !started {
+				return false, err
+			}
+			left = append(left, key)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		started = true
+	}
+	if firstErr != nil {
+		return true, fmt.Errorf("picture %s/%s is deleted but its files %v are left to the reconciler: %v", picture.Origin, picture.ID, left, firstErr)
+	}
+	return started, nil
+}
+
+// tags are written one by one, the write fails when the picture has changed since the version
+func (c ControllerPicture) CreatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID, tag controllerModel.PictureTag) error {
+	picture, err := c.readPictureVersion(ctx, controllerModel.PictureStateProcess, primaryKey, sortKey, version)
 	if err != nil {
 		return err
 	}
-	if err := c.S3.ItemCreate(ctx, buffer, c.BucketName, destinationPath); err != nil {
+	if err := c.validatePictureTag(ctx, *picture, tag); err != nil {
 		return err
 	}
-
-	if err := c.DynamodbProcess.CreatePicture(ctx, id, *newPicture); err != nil {
+	tag.ID = tagID
+	if tag.CreationDate.IsZero() {
+		tag.CreationDate = time.Now()
+	}
+	audit, err := newPictureAudit(ctx, "CreatePictureTag", primaryKey, sortKey, nil, tag)
+	if err != nil {
 		return err
 	}
-	return nil
+	return c.DynamodbProcess.CreatePictureTag(ctx, primaryKey, sortKey, picture.Version, tag, audit)
 }
 
-func (c ControllerPicture) CreatePictureCopy(ctx context.Context, primaryKey string, sortKey model.UUID, id model.UUID) error {
-	newPicture, err := c.DynamodbProcess.ReadPicture(ctx, primaryKey, sortKey)
+func (c ControllerPicture) UpdatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID, tag controllerModel.PictureTag) error {
+	picture, err := c.readPictureVersion(ctx, controllerModel.PictureStateProcess, primaryKey, sortKey, version)
 	if err != nil {
 		return err
 	}
-	name := fmt.Sprintf("%s_%s", newPicture.OriginID, time.Now().Format(time.RFC3339))
-	newPicture.Name = name
-	newPicture.CreationDate = time.Now()
-
-	sourcePath := fmt.Sprintf("%s/%s.%s", newPicture.Origin, newPicture.Name, newPicture.Extension)
-	destinationPath := fmt.Sprintf("%s/%s.%s", newPicture.Origin, name, newPicture.Extension)
-	if err := c.S3.ItemCopy(ctx, c.BucketName, sourcePath, destinationPath); err != nil {
+	idx := slices.IndexFunc(picture.Tags, func(tag controllerModel.PictureTag) bool { return tag.ID == tagID })
+	if idx == -1 {
+		return fmt.Errorf("tag %s not found in picture %s/%s", tagID, primaryKey, sortKey)
+	}
+	if err := c.validatePictureTag(ctx, *picture, tag); err != nil {
 		return err
 	}
-
-	if err := c.DynamodbProcess.CreatePicture(ctx, id, *newPicture); err != nil {
+	tag.ID = tagID
+	tag.CreationDate = picture.Tags[idx].CreationDate
+	audit, err := newPictureAudit(ctx, "UpdatePictureTag", primaryKey, sortKey, picture.Tags[idx], tag)
+	if err != nil {
 		return err
 	}
-	return nil
+	return c.DynamodbProcess.UpdatePictureTag(ctx, primaryKey, sortKey, picture.Version, tag, audit)
 }
 
-func (c ControllerPicture) UpdatePictureTransfer(ctx context.Context, primaryKey string, sortKey model.UUID, from, to string) error {
-	fromDynamodb, err := c.driverDynamodbMap(from)
+func (c ControllerPicture) DeletePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID) error {
+	picture, err := c.readPictureVersion(ctx, controllerModel.PictureStateProcess, primaryKey, sortKey, version)
 	if err != nil {
 		return err
 	}
-
-	oldPicture, err := fromDynamodb.ReadPicture(ctx, primaryKey, sortKey)
+	// the tag may already be missing, the deletion only checks the version
+	var before any
+	if idx := slices.IndexFunc(picture.Tags, func(tag controllerModel.PictureTag) bool { return tag.ID == tagID }); idx != -1 {
+		before = picture.Tags[idx]
+	}
+	audit, err := newPictureAudit(ctx, "DeletePictureTag", primaryKey, sortKey, before, nil)
 	if err != nil {
 		return err
 	}
+	return c.DynamodbProcess.DeletePictureTag(ctx, primaryKey, sortKey, picture.Version, tagID, audit)
+}
 
-	toDynamodb, err := c.driverDynamodbMap(to)
+// the cropped file and its renditions are stored under the key of the new size, the files of the previous sizes are kept
+func (c ControllerPicture) UpdatePictureCrop(ctx context.Context, primaryKey string, sortKey model.UUID, version int, pictureSizeID model.UUID, box controllerModel.Box) error {
+	oldPicture, err := c.readPictureVersion(ctx, controllerModel.PictureStateProcess, primaryKey, sortKey, version)
 	if err != nil {
 		return err
 	}
-
-	if err := toDynamodb.CreatePicture(ctx, oldPicture.ID, *oldPicture); err != nil {
+	newFile, err := c.cropFile(ctx, box, *oldPicture)
+	if err != nil {
 		return err
 	}
-
-	if err := fromDynamodb.DeletePicture(ctx, primaryKey, sortKey); err != nil {
+	newPicture, err := updatePictureTagBoxes(box, *oldPicture, pictureSizeID)
+	if err != nil {
 		return err
 	}
 
+	keys, err := c.createSizeFile(ctx, newPicture, newFile)
+	if err != nil {
+		return err
+	}
+	after := *newPicture
+	after.Version++
+	audit, err := newPictureAudit(ctx, "UpdatePictureCrop", primaryKey, sortKey, *oldPicture, after)
+	if err != nil {
+		return compensate(err, func() error { return c.deleteFiles(ctx, keys) })
+	}
+	if err := c.DynamodbProcess.CreatePicture(ctx, newPicture.ID, *newPicture, audit); err != nil {
+		return compensate(err, func() error { return c.deleteFiles(ctx, keys) })
+	}
 	return nil
 }
 
-func (c ControllerPicture) CreatePictureBlocked(ctx context.Context, primaryKey string, sortKey model.UUID) error {
-	picture, err := c.DynamodbProcess.ReadPicture(ctx, primaryKey, sortKey)
+// the cropped picture only has the file of its new size, the previous sizes are kept as history.
+// pictureSizeID must be the current size of the picture, the one the box refers to, the new size gets its own ID
+func (c ControllerPicture) CreatePictureCrop(ctx context.Context, primaryKey string, sortKey model.UUID, id model.UUID, pictureSizeID model.UUID, box controllerModel.Box) error {
+	oldPicture, err := c.readPicture(ctx, controllerModel.PictureStateProcess, primaryKey, sortKey)
 	if err != nil {
 		return err
 	}
-
-	sourcePath := fmt.Sprintf("%s/%s.%s", picture.Origin, picture.Name, picture.Extension)
-	if err := c.S3.ItemDelete(ctx, c.BucketName, sourcePath); err != nil {
+	// the tags are anchored to the current size, an older one cannot be cropped
+	if len(oldPicture.Sizes) == 0 || oldPicture.Sizes[len(oldPicture.Sizes)-1].ID != pictureSizeID {
+		return fmt.Errorf("size %s is not the current size of picture %s/%s, only the current one can be cropped", pictureSizeID, primaryKey, sortKey)
+	}
+	newFile, err := c.cropFile(ctx, box, *oldPicture)
+	if err != nil {
+		return err
+	}
+	newPicture, err := updatePictureTagBoxes(box, *oldPicture, model.NewUUID())
+	if err != nil {
 		return err
 	}
+	newPicture.ID = id
+	newPicture.Name = fmt.Sprintf("%s_%s", newPicture.OriginID, time.Now().Format(time.RFC3339))
+	newPicture.CreationDate = time.Now()
+	newPicture.Version = 0
+	for i := range newPicture.Sizes {
+		newPicture.Sizes[i].ObjectKey = ""
+	}
 
-	picture.CreationDate = time.Now()
-	if err := c.DynamodbBlocked.CreatePicture(ctx, picture.ID, *picture); err != nil {
+	keys, err := c.createSizeFile(ctx, newPicture, newFile)
+	if err != nil {
 		return err
 	}
+	after := *newPicture
+	after.Version++
+	audit, err := newPictureAudit(ctx, "CreatePictureCrop", newPicture.Origin, id, nil, after)
+	if err != nil {
+		return compensate(err, func() error { return c.deleteFiles(ctx, keys) })
+	}
+	if err := c.DynamodbProcess.CreatePicture(ctx, id, *newPicture, audit); err != nil {
+		return compensate(err, func() error { return c.deleteFiles(ctx, keys) })
+	}
+	return nil
+}
 
-	if err := c.DynamodbProcess.DeletePicture(ctx, picture.Origin, picture.ID); err != nil {
+// the files of every size are copied under the keys of the new picture
+func (c ControllerPicture) CreatePictureCopy(ctx context.Context, primaryKey string, sortKey model.UUID, id model.UUID) error {
+	picture, err := c.readPicture(ctx, controllerModel.PictureStateProcess, primaryKey, sortKey)
+	if err != nil {
 		return err
 	}
+	newPicture := *picture
+	newPicture.ID = id
+	newPicture.Name = fmt.Sprintf("%s_%s", picture.OriginID, time.Now().Format(time.RFC3339))
+	newPicture.CreationDate = time.Now()
+	newPicture.Version = 0
+	newPicture.Sizes = slices.Clone(picture.Sizes)
+
+	// the renditions are not copied, they are generated again on request
+	var copiedKeys []string
+	deleteCopies := func() error { return c.deleteFiles(ctx, copiedKeys) }
+	for i, size := range newPicture.Sizes {
+		if size.ObjectKey == "" {
+			continue
+		}
+		newPicture.Sizes[i].ObjectKey = objectKey(newPicture, size)
+		if err := c.S3.ItemCopy(ctx, c.BucketName, size.ObjectKey, newPicture.Sizes[i].ObjectKey); err != nil {
+			return compensate(err, deleteCopies)
+		}
+		copiedKeys = append(copiedKeys, newPicture.Sizes[i].ObjectKey)
+	}
 
+	after := newPicture
+	after.Version++
+	audit, err := newPictureAudit(ctx, "CreatePictureCopy", newPicture.Origin, id, nil, after)
+	if err != nil {
+		return compensate(err, deleteCopies)
+	}
+	if err := c.DynamodbProcess.CreatePicture(ctx, id, newPicture, audit); err != nil {
+		return compensate(err, deleteCopies)
+	}
 	return nil
 }
 
-func (c ControllerPicture) DeletePictureBlocked(ctx context.Context, primaryKey string, sortKey model.UUID) error {
-	return c.DynamodbBlocked.DeletePicture(ctx, primaryKey, sortKey)
+func (c ControllerPicture) UpdatePictureTransfer(ctx context.Context, primaryKey string, sortKey model.UUID, version int, transition controllerModel.PictureTransition) error {
+	return c.transferPicture(ctx, "UpdatePictureTransfer", primaryKey, sortKey, version, transition)
 }
 
-func fileToBuffer(picture controllerModel.Picture, file image.Image) (*bytes.Buffer, error) {
+func (c ControllerPicture) CreatePictureBlocked(ctx context.Context, primaryKey string, sortKey model.UUID, version int, from, actor, reason string) error {
+	return c.transferPicture(ctx, "CreatePictureBlocked", primaryKey, sortKey, version, controllerModel.PictureTransition{
+		From:   from,
+		To:     controllerModel.PictureStateBlocked,
+		Actor:  actor,
+		Reason: reason,
+	})
+}
+
+func (c ControllerPicture) DeletePictureBlocked(ctx context.Context, primaryKey string, sortKey model.UUID, version int, actor, reason string) error {
+	return c.transferPicture(ctx, "DeletePictureBlocked", primaryKey, sortKey, version, controllerModel.PictureTransition{
+		From:   controllerModel.PictureStateBlocked,
+		To:     controllerModel.PictureStateProcess,
+		Actor:  actor,
+		Reason: reason,
+	})
+}
+
+func (c ControllerPicture) fileToBuffer(file image.Image, extension string) (*bytes.Buffer, error) {
 	// create buffer
 	buffer := new(bytes.Buffer)
 	// encode image to buffer
 
-	if picture.Extension == "jpeg" || picture.Extension == "jpg" {
-		err := jpeg.Encode(buffer, file, nil)
+	switch normalizeExtension(extension) {
+	case "jpg":
+		err := jpeg.Encode(buffer, file, &jpeg.Options{Quality: c.Quality})
 		if err != nil {
 			return nil, fmt.Errorf("jpeg.Encode has failed: %v", err)
 		}
-	} else if picture.Extension == "png" {
+	case "png":
 		err := png.Encode(buffer, file)
 		if err != nil {
 			return nil, fmt.Errorf("png.Encode has failed: %v", err)
 		}
-	} else {
-		return nil, fmt.Errorf("no image extension matching the buffer conversion")
+	case "gif":
+		err := gif.Encode(buffer, file, nil)
+		if err != nil {
+			return nil, fmt.Errorf("gif.Encode has failed: %v", err)
+		}
+	case "tiff":
+		err := tiff.Encode(buffer, file, &tiff.Options{Compression: tiff.Deflate})
+		if err != nil {
+			return nil, fmt.Errorf("tiff.Encode has failed: %v", err)
+		}
+	default:
+		return nil, controllerModel.FormatUnsupportedError{Extension: normalizeExtension(extension)}
 	}
 	return buffer, nil
 }
 
-func (c ControllerPicture) cropPicture(ctx context.Context, box controllerModel.Box, primaryKey string, sortKey model.UUID, pictureSizeID model.UUID) (*controllerModel.Picture, error) {
-	oldPicture, err := c.DynamodbProcess.ReadPicture(ctx, primaryKey, sortKey)
+// uploads the file of the last size of the picture with its renditions, sets its key and returns all the keys created
+func (c ControllerPicture) createSizeFile(ctx context.Context, picture *controllerModel.Picture, file image.Image) ([]string, error) {
+	buffer, err := c.fileToBuffer(file, picture.Extension)
 	if err != nil {
 		return nil, err
 	}
-	return updatePictureTagBoxes(box, *oldPicture, pictureSizeID)
+	size := &picture.Sizes[len(picture.Sizes)-1]
+	size.ObjectKey = objectKey(*picture, *size)
+	if err := c.S3.ItemCreate(ctx, buffer, c.BucketName, size.ObjectKey); err != nil {
+		return nil, err
+	}
+	keys := []string{size.ObjectKey}
+	renditionKeys, err := c.createRenditions(ctx, size.ObjectKey, file)
+	keys = append(keys, renditionKeys...)
+	if err != nil {
+		return nil, compensate(err, func() error { return c.deleteFiles(ctx, keys) })
+	}
+	return keys, nil
+}
+
+func (c ControllerPicture) deleteFiles(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		if err := c.S3.ItemDelete(ctx, c.BucketName, key); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func updatePictureTagBoxes(box controllerModel.Box, picture controllerModel.Picture, pictureSizeID model.UUID) (*controllerModel.Picture, error) {
+	if slices.IndexFunc(picture.Sizes, func(size controllerModel.PictureSize) bool { return size.ID == pictureSizeID }) != -1 {
+		return nil, fmt.Errorf("size %s already exists in picture %s/%s", pictureSizeID, picture.Origin, picture.ID)
+	}
+
 	// new size creation
 	size := controllerModel.PictureSize{
-		ID:           model.NewUUID(),
+		ID:           pictureSizeID,
 		CreationDate: time.Now(),
 		Box:          box, // absolute position
 	}
-	picture.Sizes = append(picture.Sizes, size)
+	picture.Sizes = append(slices.Clone(picture.Sizes), size)
 
-	i := 0
-	for {
-		if i >= len(picture.Tags) {
-			break
-		}
-		tag := &picture.Tags[i]
+	picture.Tags = cropPictureTags(picture.Tags, box, pictureSizeID)
+	annotationSets := make([]controllerModel.AnnotationSet, 0, len(picture.AnnotationSets))
+	for _, annotationSet := range picture.AnnotationSets {
+		annotationSet.Tags = cropPictureTags(annotationSet.Tags, box, pictureSizeID)
+		annotationSets = append(annotationSets, annotationSet)
+	}
+	picture.AnnotationSets = annotationSets
+	return &picture, nil
+}
 
+// the box, segmentation or keypoints of a tag falling outside of the crop are removed,
+// the tag is removed when none of them is left
+func cropPictureTags(pictureTags []controllerModel.PictureTag, box controllerModel.Box, pictureSizeID model.UUID) []controllerModel.PictureTag {
+	tags := make([]controllerModel.PictureTag, 0, len(pictureTags))
+	for _, tag := range pictureTags {
+		located := tag.BoxInformation.Valid || tag.Segmentation.Valid || tag.Keypoints.Valid
 		if tag.BoxInformation.Valid {
-			boxInformation := tag.BoxInformation.Body
-			// relative position of tags
-			tlx := boxInformation.Box.Tlx
-			tly := boxInformation.Box.Tly
-			width := boxInformation.Box.Width
-			height := boxInformation.Box.Height
-
-			// box outside on the image right
-			if tlx > box.Tlx+box.Width {
-				removeSliceElement(picture.Tags, i)
-				continue
+			if tagBox, ok := cropTagBox(tag.BoxInformation.Body.Box, box); ok {
+				// set the new relative reference to the newly cropped image
+				tag.BoxInformation.Body.PictureSizeID = pictureSizeID
+				tag.BoxInformation.Body.Box = tagBox
+			} else {
+				tag.BoxInformation = model.Nullable[controllerModel.BoxInformation]{}
 			}
-			// box left outside on the image left
-			if tlx < box.Tlx {
-				if tlx+width < box.Tlx {
-					// box outside on the image left
-					width = 0
-				} else {
-					// box right inside the image
-					width = width - box.Tlx + tlx
-				}
-				tlx = box.Tlx
-			} else { // box left inside image
-				if tlx+width > box.Tlx+box.Width {
-					// box right outside on the image right
-					width = box.Tlx + box.Width - tlx
-				}
-				tlx = tlx - box.Tlx
+		}
+		if tag.Segmentation.Valid {
+			if segmentation, ok := cropSegmentation(tag.Segmentation.Body, box); ok {
+				segmentation.PictureSizeID = pictureSizeID
+				tag.Segmentation = model.NewNullable(segmentation)
+			} else {
+				tag.Segmentation = model.Nullable[controllerModel.Segmentation]{}
 			}
-			// box width too small
-			if width < 50 {
-				removeSliceElement(picture.Tags, i)
-				continue
+		}
+		if tag.Keypoints.Valid {
+			if keypoints, ok := cropKeypoints(tag.Keypoints.Body, box); ok {
+				keypoints.PictureSizeID = pictureSizeID
+				tag.Keypoints = model.NewNullable(keypoints)
+			} else {
+				tag.Keypoints = model.Nullable[controllerModel.Keypoints]{}
 			}
+		}
+		if located && !tag.BoxInformation.Valid && !tag.Segmentation.Valid && !tag.Keypoints.Valid {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
 
-			// box outside at the image bottom
-			if tly > box.Tly+box.Height {
-				removeSliceElement(picture.Tags, i)
-				continue
-			}
-			// box top outside on the image top
-			if tly < box.Tly {
-				if tly+height < box.Tly {
-					// box outside on the image top
-					height = 0
-				} else {
-					// box bottom inside the image
-					height = height - box.Tly + tly
-				}
-				tly = box.Tly
-			} else { // box top inside image
-				// box bottom outside on the image bottom
-				if tly+height > box.Tly+box.Height {
-					height = box.Tly + box.Height - tly
-				}
-				tly = tly - box.Tly
-			}
-			// box height too small
-			if height < 50 {
-				removeSliceElement(picture.Tags, i)
-				continue
-			}
+// box of a tag relative to the crop, ok is false when it is outside or too small
+func cropTagBox(tagBox controllerModel.Box, box controllerModel.Box) (controllerModel.Box, bool) {
+	// relative position of tags
+	tlx := tagBox.Tlx
+	tly := tagBox.Tly
+	width := tagBox.Width
+	height := tagBox.Height
+
+	// box outside on the image right
+	if tlx > box.Tlx+box.Width {
+		return controllerModel.Box{}, false
+	}
+	// box left outside on the image left
+	if tlx < box.Tlx {
+		if tlx+width < box.Tlx {
+			// box outside on the image left
+			width = 0
+		} else {
+			// box right inside the image
+			width = width - box.Tlx + tlx
+		}
+		tlx = 0
+	} else { // box left inside image
+		if tlx+width > box.Tlx+box.Width {
+			// box right outside on the image right
+			width = box.Tlx + box.Width - tlx
+		}
+		tlx = tlx - box.Tlx
+	}
+	// box width too small
+	if width < boxMinimumSize {
+		return controllerModel.Box{}, false
+	}
 
-			// set the new relative reference to the newly cropped image
-			tag.BoxInformation.Body.PictureSizeID = pictureSizeID
-			tag.BoxInformation.Body.Box.Tlx = tlx
-			tag.BoxInformation.Body.Box.Tly = tly
-			tag.BoxInformation.Body.Box.Width = width
-			tag.BoxInformation.Body.Box.Height = height
+	// box outside at the image bottom
+	if tly > box.Tly+box.Height {
+		return controllerModel.Box{}, false
+	}
+	// box top outside on the image top
+	if tly < box.Tly {
+		if tly+height < box.Tly {
+			// box outside on the image top
+			height = 0
+		} else {
+			// box bottom inside the image
+			height = height - box.Tly + tly
+		}
+		tly = 0
+	} else { // box top inside image
+		// box bottom outside on the image bottom
+		if tly+height > box.Tly+box.Height {
+			height = box.Tly + box.Height - tly
 		}
-		i++
+		tly = tly - box.Tly
 	}
-	return &picture, nil
+	// box height too small
+	if height < boxMinimumSize {
+		return controllerModel.Box{}, false
+	}
+
+	return controllerModel.Box{Tlx: tlx, Tly: tly, Width: width, Height: height}, true
 }
 
-func (c ControllerPicture) cropFile(ctx context.Context, box controllerModel.Box, primaryKey string, name string) (image.Image, error) {
-	path := filepath.Join(primaryKey, name)
-	buffer, err := c.S3.ItemRead(ctx, c.BucketName, path)
+// returns the current file of the picture cropped
+func (c ControllerPicture) cropFile(ctx context.Context, box controllerModel.Box, picture controllerModel.Picture) (image.Image, error) {
+	key, err := currentObjectKey(picture)
+	if err != nil {
+		return nil, err
+	}
+	buffer, err := c.S3.ItemRead(ctx, c.BucketName, key)
 	if err != nil {
 		return nil, err
 	}
 
 	// convert []byte to image
-	img, _, _ := image.Decode(bytes.NewReader(buffer))
-
-	// crop the image with the bounding box rectangle
-	cropRect := image.Rect(box.Tlx, box.Tly, box.Tlx+box.Width, box.Tly+box.Height)
-	img, err = updateFileDimension(img, cropRect)
+	img, _, err := image.Decode(bytes.NewReader(buffer))
 	if err != nil {
 		return nil, err
 	}
-	return img, nil
+
+	// crop the image with the bounding box rectangle
+	cropRect := image.Rect(box.Tlx, box.Tly, box.Tlx+box.Width, box.Tly+box.Height)
+	return updateFileDimension(img, cropRect)
 }
 
 func updateFileDimension(img image.Image, cropRect image.Rectangle) (image.Image, error) {
@@ -425,13 +695,3 @@ func updateFileDimension(img image.Image, cropRect image.Rectangle) (image.Image
 		return nil, fmt.Errorf("cannot crop the image")
 	}
 }
-
-func removeSliceElement[T any](slice []T, i int) {
-	if i == len(slice)-1 {
-		// last element removed
-		slice = slice[:i]
-	} else {
-		// not last element removed
-		slice = append(slice[:i], slice[i+1:]...)
-	}
-}
@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+	"testing"
+)
+
+var cropBox = controllerModel.Box{Tlx: 100, Tly: 100, Width: 200, Height: 200}
+
+func TestCropTagBox(t *testing.T) {
+	tests := []struct {
+		name   string
+		tagBox controllerModel.Box
+		want   controllerModel.Box
+		wantOk bool
+	}{
+		{
+			name:   "inside",
+			tagBox: controllerModel.Box{Tlx: 150, Tly: 150, Width: 60, Height: 60},
+			want:   controllerModel.Box{Tlx: 50, Tly: 50, Width: 60, Height: 60},
+			wantOk: true,
+		},
+		{
+			name:   "crossing the left edge",
+			tagBox: controllerModel.Box{Tlx: 80, Tly: 150, Width: 100, Height: 60},
+			want:   controllerModel.Box{Tlx: 0, Tly: 50, Width: 80, Height: 60},
+			wantOk: true,
+		},
+		{
+			name:   "crossing the top edge",
+			tagBox: controllerModel.Box{Tlx: 150, Tly: 60, Width: 60, Height: 100},
+			want:   controllerModel.Box{Tlx: 50, Tly: 0, Width: 60, Height: 60},
+			wantOk: true,
+		},
+		{
+			name:   "crossing the right and bottom edges",
+			tagBox: controllerModel.Box{Tlx: 250, Tly: 240, Width: 100, Height: 100},
+			want:   controllerModel.Box{Tlx: 150, Tly: 140, Width: 50, Height: 60},
+			wantOk: true,
+		},
+		{
+			name:   "outside on the left",
+			tagBox: controllerModel.Box{Tlx: 0, Tly: 150, Width: 50, Height: 60},
+		},
+		{
+			name:   "outside on the right",
+			tagBox: controllerModel.Box{Tlx: 310, Tly: 150, Width: 60, Height: 60},
+		},
+		{
+			name:   "too small once cropped",
+			tagBox: controllerModel.Box{Tlx: 80, Tly: 150, Width: 60, Height: 60},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := cropTagBox(test.tagBox, cropBox)
+			if ok != test.wantOk || got != test.want {
+				t.Errorf("cropTagBox() = %+v, %v, want %+v, %v", got, ok, test.want, test.wantOk)
+			}
+		})
+	}
+}
+
+func TestCropPictureTags(t *testing.T) {
+	sizeID := model.NewUUID()
+	rectangle := func(box controllerModel.Box) controllerModel.Polygon {
+		left, top := float64(box.Tlx), float64(box.Tly)
+		right, bottom := float64(box.Tlx+box.Width), float64(box.Tly+box.Height)
+		return controllerModel.Polygon{{X: left, Y: top}, {X: right, Y: top}, {X: right, Y: bottom}, {X: left, Y: bottom}}
+	}
+	located := func(box controllerModel.Box, polygon controllerModel.Polygon, point controllerModel.Point) controllerModel.PictureTag {
+		tag := controllerModel.PictureTag{ID: model.NewUUID(), Name: "dog"}
+		tag.BoxInformation = model.NewNullable(controllerModel.BoxInformation{Box: box})
+		tag.Segmentation = model.NewNullable(controllerModel.Segmentation{Polygons: []controllerModel.Polygon{polygon}})
+		tag.Keypoints = model.NewNullable(controllerModel.Keypoints{Points: []controllerModel.Keypoint{{Name: "nose", X: point.X, Y: point.Y, Visibility: controllerModel.KeypointVisible}}})
+		return tag
+	}
+	crossing := controllerModel.Box{Tlx: 80, Tly: 150, Width: 100, Height: 60}
+	outside := controllerModel.Box{Tlx: 0, Tly: 0, Width: 50, Height: 50}
+
+	tests := []struct {
+		name                                     string
+		tag                                      controllerModel.PictureTag
+		wantKept                                 bool
+		wantBox, wantSegmentation, wantKeypoints bool
+	}{
+		{
+			name:             "crossing the edge",
+			tag:              located(crossing, rectangle(crossing), controllerModel.Point{X: 150, Y: 180}),
+			wantKept:         true,
+			wantBox:          true,
+			wantSegmentation: true,
+			wantKeypoints:    true,
+		},
+		{
+			name:          "only the keypoints inside",
+			tag:           located(outside, rectangle(outside), controllerModel.Point{X: 150, Y: 180}),
+			wantKept:      true,
+			wantKeypoints: true,
+		},
+		{
+			name:             "only the box outside",
+			tag:              located(outside, rectangle(crossing), controllerModel.Point{X: 10, Y: 10}),
+			wantKept:         true,
+			wantSegmentation: true,
+		},
+		{
+			name: "everything outside",
+			tag:  located(outside, rectangle(outside), controllerModel.Point{X: 10, Y: 10}),
+		},
+		{
+			name:     "no geometry",
+			tag:      controllerModel.PictureTag{ID: model.NewUUID(), Name: "dog"},
+			wantKept: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tags := cropPictureTags([]controllerModel.PictureTag{test.tag}, cropBox, sizeID)
+			if (len(tags) == 1) != test.wantKept {
+				t.Fatalf("cropPictureTags() kept %d tags, want kept %v", len(tags), test.wantKept)
+			}
+			if !test.wantKept {
+				return
+			}
+			tag := tags[0]
+			if tag.BoxInformation.Valid != test.wantBox || tag.Segmentation.Valid != test.wantSegmentation || tag.Keypoints.Valid != test.wantKeypoints {
+				t.Errorf("geometries box %v, segmentation %v, keypoints %v, want %v, %v, %v",
+					tag.BoxInformation.Valid, tag.Segmentation.Valid, tag.Keypoints.Valid, test.wantBox, test.wantSegmentation, test.wantKeypoints)
+			}
+			// the box and the polygon of the same region stay aligned
+			if tag.BoxInformation.Valid && tag.Segmentation.Valid {
+				if got := segmentationBox(tag.Segmentation.Body); got != tag.BoxInformation.Body.Box {
+					t.Errorf("segmentation box %+v, want the tag box %+v", got, tag.BoxInformation.Body.Box)
+				}
+			}
+			for _, valid := range []struct {
+				ok     bool
+				sizeID model.UUID
+			}{
+				{tag.BoxInformation.Valid, tag.BoxInformation.Body.PictureSizeID},
+				{tag.Segmentation.Valid, tag.Segmentation.Body.PictureSizeID},
+				{tag.Keypoints.Valid, tag.Keypoints.Body.PictureSizeID},
+			} {
+				if valid.ok && valid.sizeID != sizeID {
+					t.Errorf("size %s, want the new size %s", valid.sizeID, sizeID)
+				}
+			}
+		})
+	}
+}
+
+func TestCreatePictureTagVersion(t *testing.T) {
+	outsideTag := drawnBoxTag
+	outsideTag.BoxInformation.Body.Box = controllerModel.Box{Tlx: 350, Tly: 10, Width: 100, Height: 100}
+
+	tests := []struct {
+		name         string
+		missing      bool
+		version      int
+		tag          controllerModel.PictureTag
+		wantConflict int  // version returned by the conflict
+		wantNotFound bool // the picture is not in process
+		wantInvalid  bool // the tag fails the validators
+	}{
+		{name: "current version", version: 3, tag: drawnBoxTag},
+		{name: "stale version", version: 2, tag: drawnBoxTag, wantConflict: 3},
+		{name: "missing picture", missing: true, version: 3, tag: drawnBoxTag, wantNotFound: true},
+		{name: "box outside of its size", version: 3, tag: outsideTag, wantInvalid: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			picture := controllerModel.Picture{Origin: "pexels", ID: model.NewUUID(), Sizes: []controllerModel.PictureSize{transitionSize}, Version: 3}
+			process := &fakeDynamodbPicture{}
+			if !test.missing {
+				process.pictures = []controllerModel.Picture{picture}
+			}
+			c := ControllerPicture{DynamodbProcess: process}
+			tagID := model.NewUUID()
+
+			err := c.CreatePictureTag(context.Background(), picture.Origin, picture.ID, test.version, tagID, test.tag)
+			var conflictErr controllerModel.PictureConflictError
+			if errors.As(err, &conflictErr) != (test.wantConflict != 0) || conflictErr.Version != test.wantConflict {
+				t.Errorf("CreatePictureTag() error = %v, want a conflict at version %d", err, test.wantConflict)
+			}
+			var notFoundErr controllerModel.PictureNotFoundError
+			if errors.As(err, &notFoundErr) != test.wantNotFound {
+				t.Errorf("CreatePictureTag() error = %v, want not found %v", err, test.wantNotFound)
+			}
+			var validationErrors controllerModel.ValidationErrors
+			if errors.As(err, &validationErrors) != test.wantInvalid {
+				t.Errorf("CreatePictureTag() error = %v, want invalid %v", err, test.wantInvalid)
+			}
+			if err != nil {
+				if len(process.audits) != 0 {
+					t.Errorf("%d audits written, want none", len(process.audits))
+				}
+				return
+			}
+			stored := process.pictures[0]
+			if stored.Version != test.version+1 || len(stored.Tags) != 1 || stored.Tags[0].ID != tagID {
+				t.Errorf("stored picture at version %d with tags %+v, want version %d with tag %s", stored.Version, stored.Tags, test.version+1, tagID)
+			}
+			if len(process.audits) != 1 || process.audits[0].Action != "CreatePictureTag" {
+				t.Errorf("audits = %+v, want one CreatePictureTag", process.audits)
+			}
+		})
+	}
+}
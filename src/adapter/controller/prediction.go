@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// writes the predictions as tags of the pictures of the process table, picture by picture.
+// a picture is written once with all of its predictions, or not at all.
+func (c ControllerPicture) CreatePredictions(ctx context.Context, predictionImport controllerModel.PredictionImport) ([]controllerModel.PredictionResult, error) {
+	var keys []controllerModel.PictureKey
+	predictions := map[controllerModel.PictureKey][]controllerModel.Prediction{}
+	for i, prediction := range predictionImport.Predictions {
+		if prediction.Model == "" || prediction.TagName == "" {
+			return nil, fmt.Errorf("prediction %d requires a model and a tag name", i)
+		}
+		if prediction.Confidence < 0 || prediction.Confidence > 1 {
+			return nil, fmt.Errorf("prediction %d has a confidence of %f, it must be between 0 and 1", i, prediction.Confidence)
+		}
+		key := controllerModel.PictureKey{Origin: prediction.Origin, ID: prediction.ID}
+		if _, ok := predictions[key]; !ok {
+			keys = append(keys, key)
+		}
+		predictions[key] = append(predictions[key], prediction)
+	}
+
+	results := make([]controllerModel.PredictionResult, len(keys))
+	semaphore := make(chan struct{}, bulkConcurrency)
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, key controllerModel.PictureKey) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			results[i] = c.createPicturePredictions(ctx, key, predictions[key], predictionImport.Threshold, predictionImport.Replace)
+		}(i, key)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+func (c ControllerPicture) createPicturePredictions(ctx context.Context, key controllerModel.PictureKey, predictions []controllerModel.Prediction, threshold float64, replace bool) controllerModel.PredictionResult {
+	result := controllerModel.PredictionResult{Origin: key.Origin, ID: key.ID, Status: controllerModel.PictureBulkStatusFailed}
+	picture, err := c.readPicture(ctx, controllerModel.PictureStateProcess, key.Origin, key.ID)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	now := time.Now()
+	var tags []controllerModel.PictureTag
+	var models []string
+	for _, prediction := range predictions {
+		if prediction.Confidence < threshold {
+			result.Skipped++
+			continue
+		}
+		tag := controllerModel.PictureTag{
+			ID:           model.NewUUID(),
+			Name:         strings.ToLower(prediction.TagName),
+			CreationDate: now,
+			OriginName:   prediction.Model,
+			BoxInformation: model.NewNullable(controllerModel.BoxInformation{
+				Model:         sql.NullString{String: prediction.Model, Valid: true},
+				Weights:       sql.NullString{String: prediction.Weights, Valid: prediction.Weights != ""},
+				PictureSizeID: prediction.PictureSizeID,
+				Box:           prediction.Box,
+				Confidence:    sql.NullFloat64{Float64: prediction.Confidence, Valid: true},
+			}),
+		}
+		predicted := *picture
+		predicted.Tags = []controllerModel.PictureTag{tag}
+		errs, err := ValidatorBoxBounds{}.Validate(ctx, predicted)
+		if err != nil {
+			result.Message = err.Error()
+			return result
+		}
+		if len(errs) > 0 {
+			result.Invalid = append(result.Invalid, fmt.Sprintf("tag `%s`: %s", tag.Name, errs[0].Message))
+			continue
+		}
+		tags = append(tags, tag)
+		if !slices.Contains(models, prediction.Model) {
+			models = append(models, prediction.Model)
+		}
+	}
+
+	newPicture := *picture
+	newPicture.Tags = make([]controllerModel.PictureTag, 0, len(picture.Tags)+len(tags))
+	for _, tag := range picture.Tags {
+		if replace && predictedTag(tag) && slices.Contains(models, tag.BoxInformation.Body.Model.String) {
+			result.Replaced++
+			continue
+		}
+		newPicture.Tags = append(newPicture.Tags, tag)
+	}
+	newPicture.Tags = append(newPicture.Tags, tags...)
+	result.Created = len(tags)
+
+	if result.Created > 0 || result.Replaced > 0 {
+		after := newPicture
+		after.Version++
+		audit, err := newPictureAudit(ctx, "CreatePredictions", picture.Origin, picture.ID, *picture, after)
+		if err != nil {
+			result.Message = err.Error()
+			return result
+		}
+		if err := c.DynamodbProcess.CreatePicture(ctx, picture.ID, newPicture, audit); err != nil {
+			result.Message = err.Error()
+			return result
+		}
+	}
+	result.Status = controllerModel.PictureBulkStatusDone
+	return result
+}
+
+// the tag was found by a model instead of drawn by hand
+func predictedTag(tag controllerModel.PictureTag) bool {
+	return tag.BoxInformation.Valid && tag.BoxInformation.Body.Model.Valid
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"fmt"
+	"image"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+)
+
+// width of the copy on which the scores are computed so that they are comparable between pictures
+const qualityScoreWidth = 512
+
+// luminance limits of the dark and bright pixels
+const (
+	qualityDarkLuminance   = 32
+	qualityBrightLuminance = 223
+)
+
+// actor of the transitions made by the quality gate
+const qualityGateActor = "qualityGate"
+
+// QualityGate rejects the pictures not worth reviewing, a limit at 0 is not checked
+type QualityGate struct {
+	MinWidth       int
+	MinHeight      int
+	MinBlur        float64
+	MaxDarkRatio   float64
+	MaxBrightRatio float64
+	Block          bool // failing pictures are stored in the blocked table instead of being rejected
+}
+
+// reasons why the picture does not pass the gate
+func (g QualityGate) check(quality controllerModel.PictureQuality) []string {
+	var failures []string
+	if g.MinWidth > 0 && quality.Width < g.MinWidth {
+		failures = append(failures, fmt.Sprintf("width %d under %d", quality.Width, g.MinWidth))
+	}
+	if g.MinHeight > 0 && quality.Height < g.MinHeight {
+		failures = append(failures, fmt.Sprintf("height %d under %d", quality.Height, g.MinHeight))
+	}
+	if g.MinBlur > 0 && quality.Blur < g.MinBlur {
+		failures = append(failures, fmt.Sprintf("blur score %.1f under %.1f", quality.Blur, g.MinBlur))
+	}
+	if g.MaxDarkRatio > 0 && quality.DarkRatio > g.MaxDarkRatio {
+		failures = append(failures, fmt.Sprintf("%.0f%% of dark pixels above %.0f%%", quality.DarkRatio*100, g.MaxDarkRatio*100))
+	}
+	if g.MaxBrightRatio > 0 && quality.BrightRatio > g.MaxBrightRatio {
+		failures = append(failures, fmt.Sprintf("%.0f%% of bright pixels above %.0f%%", quality.BrightRatio*100, g.MaxBrightRatio*100))
+	}
+	return failures
+}
+
+// size of the grid whose neighbour cells are compared by the difference hash, one bit per comparison
+const (
+	hashWidth  = 9
+	hashHeight = 8
+)
+
+// computes the resolution, the blur score and the luminance histogram of the image
+func scoreQuality(img image.Image) controllerModel.PictureQuality {
+	bounds := img.Bounds()
+	quality := controllerModel.PictureQuality{Width: bounds.Dx(), Height: bounds.Dy()}
+	if bounds.Dx() > qualityScoreWidth {
+		img = resizeFile(img, qualityScoreWidth)
+		bounds = img.Bounds()
+	}
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// luminance of each pixel with its histogram
+	var histogram [256]int
+	luminances := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			luminance := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257
+			luminances[y*width+x] = luminance
+			histogram[int(luminance)]++
+		}
+	}
+	pixels := float64(width * height)
+	var sum float64
+	var dark, bright int
+	for level, count := range histogram {
+		sum += float64(level * count)
+		if level < qualityDarkLuminance {
+			dark += count
+		}
+		if level > qualityBrightLuminance {
+			bright += count
+		}
+	}
+	quality.Luminance = sum / pixels
+	quality.DarkRatio = float64(dark) / pixels
+	quality.BrightRatio = float64(bright) / pixels
+	quality.Hash = differenceHash(luminances, width, height)
+
+	// variance of the Laplacian over the inner pixels
+	if width < 3 || height < 3 {
+		return quality
+	}
+	var laplacianSum, laplacianSquares float64
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			i := y*width + x
+			laplacian := luminances[i-width] + luminances[i+width] + luminances[i-1] + luminances[i+1] - 4*luminances[i]
+			laplacianSum += laplacian
+			laplacianSquares += laplacian * laplacian
+		}
+	}
+	n := float64((width - 2) * (height - 2))
+	mean := laplacianSum / n
+	quality.Blur = laplacianSquares/n - mean*mean
+	return quality
+}
+
+// each bit tells whether the mean luminance of a cell of the grid is lower than the one of the next cell on its right.
+// the hash is empty when the image is smaller than the grid.
+func differenceHash(luminances []float64, width, height int) string {
+	if width < hashWidth || height < hashHeight {
+		return ""
+	}
+	var cells [hashHeight][hashWidth]float64
+	for cy := 0; cy < hashHeight; cy++ {
+		for cx := 0; cx < hashWidth; cx++ {
+			x0, x1 := cx*width/hashWidth, (cx+1)*width/hashWidth
+			y0, y1 := cy*height/hashHeight, (cy+1)*height/hashHeight
+			var sum float64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					sum += luminances[y*width+x]
+				}
+			}
+			cells[cy][cx] = sum / float64((x1-x0)*(y1-y0))
+		}
+	}
+	var hash uint64
+	for cy := 0; cy < hashHeight; cy++ {
+		for cx := 0; cx < hashWidth-1; cx++ {
+			hash <<= 1
+			if cells[cy][cx] < cells[cy][cx+1] {
+				hash |= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%016x", hash)
+}
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	interfaceAdapter "scraper-backend/src/adapter/interface"
+	model "scraper-backend/src/driver/model"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+)
+
+// actor of the pictures of the trusted users moved to validation
+const trustedUserActor = "trustedUser"
+
+type userKey struct {
+	origin   string
+	originID string
+}
+
+// trusted users and quotas of the users per searched tag, shared by the concurrent fetches of a search
+type userPolicy struct {
+	mutex  sync.Mutex
+	users  map[userKey]controllerModel.User
+	counts map[userKey]map[string]int // pictures already stored by searched tag
+}
+
+// the pictures stored are only read when a user has a quota, they are counted for each searched tag they have
+func readUserPolicy(ctx context.Context, controllerPicture interfaceAdapter.ControllerPicture, controllerUser interfaceAdapter.ControllerUser, searchedTags []controllerModel.Tag) (*userPolicy, error) {
+	users, err := controllerUser.ReadUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policy := userPolicy{users: map[userKey]controllerModel.User{}, counts: map[userKey]map[string]int{}}
+	for _, user := range users {
+		if userStatus(user) == controllerModel.UserStatusBlocked {
+			continue
+		}
+		key := userKey{origin: user.Origin, originID: user.OriginID}
+		policy.users[key] = user
+		if user.Quota > 0 {
+			policy.counts[key] = map[string]int{}
+		}
+	}
+	if len(policy.counts) == 0 {
+		return &policy, nil
+	}
+
+	canonicalNames := tagCanonicalNames(searchedTags, -1)
+	projEx := expression.NamesList(expression.Name("Origin"), expression.Name("User"), expression.Name("Tags"))
+	// the blocked pictures are not counted, like in the targets
+	for _, state := range targetStates {
+		pictures, err := controllerPicture.ReadPictures(ctx, state, &projEx, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, picture := range pictures {
+			counts, ok := policy.counts[userKey{origin: picture.Origin, originID: picture.User.OriginID}]
+			if !ok {
+				continue
+			}
+			searched := map[string]struct{}{}
+			for _, tag := range picture.Tags {
+				if name, ok := canonicalNames[tag.Name]; ok {
+					searched[name] = struct{}{}
+				}
+			}
+			for name := range searched {
+				counts[name]++
+			}
+		}
+	}
+	return &policy, nil
+}
+
+// whether the user already has as many pictures of the searched tag as its quota, checked again when the picture is created
+func (p *userPolicy) quotaReached(origin, originID, searchedTag string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.full(userKey{origin: origin, originID: originID}, searchedTag)
+}
+
+func (p *userPolicy) full(key userKey, searchedTag string) bool {
+	counts, ok := p.counts[key]
+	return ok && counts[searchedTag] >= p.users[key].Quota
+}
+
+// counts a picture of the user in its quota before it is created, false when the quota is already reached
+func (p *userPolicy) reserve(key userKey, searchedTag string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.full(key, searchedTag) {
+		return false
+	}
+	if counts, ok := p.counts[key]; ok {
+		counts[searchedTag]++
+	}
+	return true
+}
+
+// gives back the reservation of a picture that was not created
+func (p *userPolicy) release(key userKey, searchedTag string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if counts, ok := p.counts[key]; ok {
+		counts[searchedTag]--
+	}
+}
+
+// creates the picture found with the searched tag, counts it in the target of the tag and the quota of its user and moves it to validation when the user is trusted.
+// the picture is counted before it is created so that the concurrent fetches cannot exceed the target or the quota, a picture over one of them returns a PictureRejectedError.
+// a picture blocked by the quality gate is not moved and gives back its reservations
+func (p *userPolicy) createPicture(ctx context.Context, controllerPicture interfaceAdapter.ControllerPicture, budget *searchBudget, searchedTag string, picture controllerModel.Picture, buffer []byte) error {
+	if !budget.reserve(searchedTag) {
+		return controllerModel.PictureRejectedError{Reason: fmt.Sprintf("target of %s reached", searchedTag)}
+	}
+	key := userKey{origin: picture.Origin, originID: picture.User.OriginID}
+	if !p.reserve(key, searchedTag) {
+		budget.release(searchedTag)
+		return controllerModel.PictureRejectedError{Reason: fmt.Sprintf("user `%s` of %s has reached its quota of %s", picture.User.Name, picture.Origin, searchedTag)}
+	}
+	id := model.NewUUID()
+	state, err := controllerPicture.CreatePicture(ctx, id, picture, buffer)
+	if err != nil || state == controllerModel.PictureStateBlocked {
+		p.release(key, searchedTag)
+		budget.release(searchedTag)
+		return err
+	}
+
+	p.mutex.Lock()
+	user, ok := p.users[key]
+	p.mutex.Unlock()
+	if !ok || user.Status != controllerModel.UserStatusTrusted {
+		return nil
+	}
+
+	created, err := controllerPicture.ReadPicture(ctx, controllerModel.PictureStateProcess, picture.Origin, id)
+	if err != nil {
+		return err
+	}
+	// moved or deleted in the meantime
+	if created == nil {
+		return nil
+	}
+	return controllerPicture.UpdatePictureTransfer(ctx, picture.Origin, id, created.Version, controllerModel.PictureTransition{
+		From:   controllerModel.PictureStateProcess,
+		To:     controllerModel.PictureStateValidation,
+		Actor:  trustedUserActor,
+		Reason: fmt.Sprintf("user `%s` of %s is trusted", user.Name, user.Origin),
+	})
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	interfaceAdapter "scraper-backend/src/adapter/interface"
+	model "scraper-backend/src/driver/model"
+	"testing"
+)
+
+// stores the pictures in a state or fails, the other methods are not used
+type fakeControllerPictureCreate struct {
+	interfaceAdapter.ControllerPicture
+	state   string
+	err     error
+	created int
+}
+
+func (f *fakeControllerPictureCreate) CreatePicture(ctx context.Context, id model.UUID, picture controllerModel.Picture, buffer []byte) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	f.created++
+	return f.state, nil
+}
+
+func TestUserPolicyCreatePicture(t *testing.T) {
+	user := controllerModel.User{Origin: "pexels", OriginID: "42", Name: "alice", Status: controllerModel.UserStatusCapped, Quota: 1}
+	key := userKey{origin: user.Origin, originID: user.OriginID}
+	picture := controllerModel.Picture{Origin: user.Origin, User: user}
+
+	tests := []struct {
+		name        string
+		state       string
+		err         error
+		quotaCount  int
+		targetCount int
+		wantErr     bool
+		wantRejects bool
+		wantQuota   int
+		wantTarget  int
+	}{
+		{name: "created", state: controllerModel.PictureStateProcess, wantQuota: 1, wantTarget: 1},
+		{name: "blocked by the quality gate", state: controllerModel.PictureStateBlocked, wantQuota: 0, wantTarget: 0},
+		{name: "failed", err: errors.New("write failed"), wantErr: true, wantQuota: 0, wantTarget: 0},
+		{name: "quota reached", quotaCount: 1, wantErr: true, wantRejects: true, wantQuota: 1, wantTarget: 0},
+		{name: "target reached", targetCount: 2, wantErr: true, wantRejects: true, wantQuota: 0, wantTarget: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy := userPolicy{
+				users:  map[userKey]controllerModel.User{key: user},
+				counts: map[userKey]map[string]int{key: {"dog": test.quotaCount}},
+			}
+			budget := searchBudget{
+				tags:   map[string]controllerModel.Tag{"dog": {Name: "dog", TargetCount: 2}},
+				counts: map[string]int{"dog": test.targetCount},
+				pages:  map[string]int{},
+			}
+			controllerPicture := &fakeControllerPictureCreate{state: test.state, err: test.err}
+
+			err := policy.createPicture(context.Background(), controllerPicture, &budget, "dog", picture, nil)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("createPicture() error = %v, want an error %v", err, test.wantErr)
+			}
+			var rejectedErr controllerModel.PictureRejectedError
+			if errors.As(err, &rejectedErr) != test.wantRejects {
+				t.Errorf("createPicture() error = %v, want rejected %v", err, test.wantRejects)
+			}
+			if test.wantRejects && controllerPicture.created != 0 {
+				t.Errorf("%d pictures created, want none", controllerPicture.created)
+			}
+			if got := policy.counts[key]["dog"]; got != test.wantQuota {
+				t.Errorf("quota count = %d, want %d", got, test.wantQuota)
+			}
+			if got := budget.counts["dog"]; got != test.wantTarget {
+				t.Errorf("target count = %d, want %d", got, test.wantTarget)
+			}
+		})
+	}
+}
+
+// users of the policy, the other methods are not used
+type fakeControllerUser struct {
+	interfaceAdapter.ControllerUser
+	users []controllerModel.User
+}
+
+func (f *fakeControllerUser) ReadUsers(ctx context.Context) ([]controllerModel.User, error) {
+	return f.users, nil
+}
+
+func TestReadUserPolicy(t *testing.T) {
+	capped := controllerModel.User{Origin: "pexels", OriginID: "42", Status: controllerModel.UserStatusCapped, Quota: 3}
+	trusted := controllerModel.User{Origin: "pexels", OriginID: "43", Status: controllerModel.UserStatusTrusted}
+	blocked := controllerModel.User{Origin: "pexels", OriginID: "44", Quota: 3}
+	pictures := &fakeControllerPictureStates{pictures: map[string][]controllerModel.Picture{
+		controllerModel.PictureStateProcess:    {taggedPicture("dog"), taggedPicture("dog", "puppy"), taggedPicture("cat")},
+		controllerModel.PictureStateProduction: {taggedPicture("puppy")},
+		controllerModel.PictureStateBlocked:    {taggedPicture("dog")},
+	}}
+	users := &fakeControllerUser{users: []controllerModel.User{capped, trusted, blocked}}
+	searchedTags := []controllerModel.Tag{{Name: "dog", Synonyms: []string{"puppy"}}, {Name: "cat"}}
+
+	policy, err := readUserPolicy(context.Background(), pictures, users, searchedTags)
+	if err != nil {
+		t.Fatalf("readUserPolicy() error = %v", err)
+	}
+	if len(policy.users) != 2 {
+		t.Errorf("users = %v, want the capped and trusted ones", policy.users)
+	}
+	if len(policy.counts) != 1 {
+		t.Errorf("counts = %v, want the ones of the user with a quota", policy.counts)
+	}
+	// the pictures of taggedPicture belong to the capped user, the blocked ones are not counted
+	counts := policy.counts[userKey{origin: capped.Origin, originID: capped.OriginID}]
+	if counts["dog"] != 3 || counts["cat"] != 1 {
+		t.Errorf("counts of the capped user = %v, want 3 dog and 1 cat", counts)
+	}
+	if !policy.quotaReached(capped.Origin, capped.OriginID, "dog") || policy.quotaReached(capped.Origin, capped.OriginID, "cat") {
+		t.Errorf("quota reached for dog %v and cat %v, want true and false", policy.quotaReached(capped.Origin, capped.OriginID, "dog"), policy.quotaReached(capped.Origin, capped.OriginID, "cat"))
+	}
+	if policy.quotaReached(trusted.Origin, trusted.OriginID, "dog") {
+		t.Errorf("quota reached for the trusted user without quota")
+	}
+}
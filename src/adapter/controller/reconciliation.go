@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	"time"
+)
+
+// a file is written before the row of its picture, the files written shortly before the listing may not have their row yet
+const reconciliationGrace = time.Hour
+
+// compares the bucket with all the picture tables and deletes the orphans when fix is set.
+// the pictures and files written after the listing are not compared, nor the pictures still stored under the legacy keys
+func (c ControllerPicture) ReconcilePictures(ctx context.Context, fix bool) (*controllerModel.Reconciliation, error) {
+	listing := time.Now()
+	paths, err := c.S3.ItemList(ctx, c.BucketName, "")
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[string]bool, len(paths))
+	for path := range paths {
+		referenced[path] = false
+	}
+
+	reconciliation := controllerModel.Reconciliation{}
+	missing := map[string][]controllerModel.Picture{}
+	for _, state := range []string{controllerModel.PictureStateProcess, controllerModel.PictureStateValidation, controllerModel.PictureStateProduction, controllerModel.PictureStateBlocked} {
+		pictures, err := c.ReadPictures(ctx, state, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, picture := range pictures {
+			for _, path := range c.objectKeysWithRenditions(picture) {
+				if _, ok := referenced[path]; ok {
+					referenced[path] = true
+				}
+			}
+			if legacy := legacyObjectKey(picture); len(picture.Sizes) == 0 || picture.Sizes[len(picture.Sizes)-1].ObjectKey == "" {
+				if _, ok := referenced[legacy]; ok {
+					referenced[legacy] = true
+				}
+				reconciliation.Legacy++
+				continue
+			}
+			if pictureWrittenAfter(picture, listing) {
+				continue
+			}
+			// a picture is only missing when its current file is missing
+			path, err := currentObjectKey(picture)
+			if err == nil && referenced[path] {
+				continue
+			}
+			missing[state] = append(missing[state], picture)
+			reconciliation.Pictures = append(reconciliation.Pictures, controllerModel.ReconciliationPicture{
+				State:  state,
+				Origin: picture.Origin,
+				ID:     picture.ID,
+				Path:   path,
+			})
+		}
+	}
+	for path, modified := range paths {
+		if !referenced[path] && modified.Before(listing.Add(-reconciliationGrace)) {
+			reconciliation.Files = append(reconciliation.Files, path)
+		}
+	}
+
+	if !fix {
+		return &reconciliation, nil
+	}
+	if reconciliation.Legacy > 0 {
+		return nil, fmt.Errorf("%d pictures are still stored under the legacy keys, run migrate-keys before fixing", reconciliation.Legacy)
+	}
+	for _, path := range reconciliation.Files {
+		if err := c.S3.ItemDelete(ctx, c.BucketName, path); err != nil {
+			return nil, err
+		}
+	}
+	for state, pictures := range missing {
+		dynamodb, err := c.driverDynamodbMap(state)
+		if err != nil {
+			return nil, err
+		}
+		if err := dynamodb.DeletePictures(ctx, pictures); err != nil {
+			return nil, err
+		}
+		for _, picture := range pictures {
+			if err := c.auditPicture(ctx, "ReconcilePictures", picture.Origin, picture.ID, picture, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	reconciliation.Fixed = true
+	return &reconciliation, nil
+}
+
+// whether the picture or one of its sizes was created after the time
+func pictureWrittenAfter(picture controllerModel.Picture, t time.Time) bool {
+	if picture.CreationDate.After(t) {
+		return true
+	}
+	for _, size := range picture.Sizes {
+		if size.CreationDate.After(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// smallest configured width covering the requested one, 0 when the file itself is served.
+// pictures are never upscaled so the file is also served when it is not wider than the rendition.
+func (c ControllerPicture) renditionWidth(requested, fileWidth int) int {
+	if requested <= 0 {
+		return 0
+	}
+	for _, width := range c.RenditionWidths {
+		if width < requested {
+			continue
+		}
+		if fileWidth > 0 && width >= fileWidth {
+			return 0
+		}
+		return width
+	}
+	return 0
+}
+
+// reads the rendition of a file, it is generated and stored on the first request
+func (c ControllerPicture) readRendition(ctx context.Context, key string, width int) ([]byte, error) {
+	renditionKey := renditionObjectKey(key, width)
+	exists, err := c.S3.ItemExists(ctx, c.BucketName, renditionKey)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return c.S3.ItemRead(ctx, c.BucketName, renditionKey)
+	}
+
+	buffer, err := c.S3.ItemRead(ctx, c.BucketName, key)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(buffer))
+	if err != nil {
+		return nil, err
+	}
+	if img.Bounds().Dx() <= width {
+		return buffer, nil
+	}
+	rendition, err := c.fileToBuffer(resizeFile(img, width), keyExtension(renditionKey))
+	if err != nil {
+		return nil, err
+	}
+	renditionBuffer := rendition.Bytes()
+	if err := c.S3.ItemCreate(ctx, bytes.NewReader(renditionBuffer), c.BucketName, renditionKey); err != nil {
+		return nil, err
+	}
+	return renditionBuffer, nil
+}
+
+// stores the renditions of a new file and returns their keys
+func (c ControllerPicture) createRenditions(ctx context.Context, key string, file image.Image) ([]string, error) {
+	var keys []string
+	for _, width := range c.RenditionWidths {
+		if file.Bounds().Dx() <= width {
+			break
+		}
+		renditionKey := renditionObjectKey(key, width)
+		buffer, err := c.fileToBuffer(resizeFile(file, width), keyExtension(renditionKey))
+		if err != nil {
+			return keys, err
+		}
+		if err := c.S3.ItemCreate(ctx, buffer, c.BucketName, renditionKey); err != nil {
+			return keys, err
+		}
+		keys = append(keys, renditionKey)
+	}
+	return keys, nil
+}
+
+// resizes the image to the width while keeping its aspect ratio
+func resizeFile(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(resized, resized.Bounds(), img, bounds, draw.Over, nil)
+	return resized
+}
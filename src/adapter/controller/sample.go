@@ -0,0 +1,328 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"math/rand"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// pictures whose hashes differ by at most this amount of bits are near duplicates
+const sampleDuplicateDistance = 5
+
+// age after which the frequencies and near duplicates cached for the samples are computed again
+const sampleCacheDuration = 5 * time.Minute
+
+// next pictures to label in the state, the near duplicates and the pictures the strategy cannot score are left out
+func (c ControllerPicture) ReadPicturesSample(ctx context.Context, filter controllerModel.SampleFilter) ([]controllerModel.Sample, error) {
+	if filter.Count <= 0 {
+		return nil, fmt.Errorf("a sample requires a count above 0")
+	}
+	pictures, err := c.ReadPictures(ctx, filter.State, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	ranked, err := c.RankPictures(ctx, filter.Strategy, pictures, filter.Seed)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]controllerModel.Sample, 0, filter.Count)
+	for _, sample := range ranked {
+		if len(samples) == filter.Count {
+			break
+		}
+		if sample.Scored && !sample.Duplicate {
+			samples = append(samples, sample)
+		}
+	}
+	return samples, nil
+}
+
+// every picture in the order of the strategy, the pictures not scored and then the near duplicates come last
+func (c ControllerPicture) RankPictures(ctx context.Context, strategy string, pictures []controllerModel.Picture, seed int64) ([]controllerModel.Sample, error) {
+	samples := make([]controllerModel.Sample, len(pictures))
+	for i, picture := range pictures {
+		samples[i] = controllerModel.Sample{Origin: picture.Origin, ID: picture.ID}
+	}
+
+	switch strategy {
+	case controllerModel.SampleStrategyConfidence:
+		for i, picture := range pictures {
+			for _, tag := range picture.Tags {
+				if !predictedTag(tag) || !tag.BoxInformation.Body.Confidence.Valid {
+					continue
+				}
+				if confidence := tag.BoxInformation.Body.Confidence.Float64; !samples[i].Scored || confidence < samples[i].Score {
+					samples[i].Score = confidence
+					samples[i].Scored = true
+				}
+			}
+		}
+	case controllerModel.SampleStrategyDisagreement:
+		for i, picture := range pictures {
+			sets := modelAnnotationSets(picture)
+			if len(sets) < 2 {
+				continue
+			}
+			samples[i].Score = annotationAgreement(sets)
+			samples[i].Scored = true
+		}
+	case controllerModel.SampleStrategyRare:
+		frequencies, err := c.sampleCache.reviewedTagFrequencies(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		// the searched tags are on every scraped picture, only the predicted classes are compared
+		for i, picture := range pictures {
+			for _, tag := range picture.Tags {
+				if !predictedTag(tag) {
+					continue
+				}
+				if frequency := float64(frequencies[tag.Name]); !samples[i].Scored || frequency < samples[i].Score {
+					samples[i].Score = frequency
+					samples[i].Scored = true
+				}
+			}
+		}
+	case controllerModel.SampleStrategyRandom:
+		for position, i := range stratifiedOrder(pictures, rand.New(rand.NewSource(seed))) {
+			samples[i].Score = float64(position)
+			samples[i].Scored = true
+		}
+	default:
+		return nil, fmt.Errorf("sample strategy %s not available", strategy)
+	}
+
+	order := make([]int, len(pictures))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		if samples[i].Scored != samples[j].Scored {
+			return samples[i].Scored
+		}
+		if samples[i].Score != samples[j].Score {
+			return samples[i].Score < samples[j].Score
+		}
+		return pictures[i].CreationDate.Before(pictures[j].CreationDate)
+	})
+
+	// the first picture of each cluster in the order represents it
+	clusters := c.sampleCache.duplicateClusters(pictures)
+	leaders := map[int]int{}
+	for _, i := range order {
+		leader, ok := leaders[clusters[i]]
+		if !ok {
+			leaders[clusters[i]] = i
+			continue
+		}
+		samples[i].Duplicate = true
+		samples[leader].Duplicates = append(samples[leader].Duplicates, controllerModel.PictureKey{Origin: pictures[i].Origin, ID: pictures[i].ID})
+	}
+
+	ranked := make([]controllerModel.Sample, 0, len(samples))
+	for _, i := range order {
+		if !samples[i].Duplicate {
+			ranked = append(ranked, samples[i])
+		}
+	}
+	for _, i := range order {
+		if samples[i].Duplicate {
+			ranked = append(ranked, samples[i])
+		}
+	}
+	return ranked, nil
+}
+
+// the predicted boxes of each model and weights, as if each model were an annotator
+func modelAnnotationSets(picture controllerModel.Picture) []controllerModel.AnnotationSet {
+	var sets []controllerModel.AnnotationSet
+	index := map[string]int{}
+	for _, tag := range picture.Tags {
+		if !predictedTag(tag) {
+			continue
+		}
+		name := tag.BoxInformation.Body.Model.String + "/" + tag.BoxInformation.Body.Weights.String
+		i, ok := index[name]
+		if !ok {
+			i = len(sets)
+			index[name] = i
+			sets = append(sets, controllerModel.AnnotationSet{Annotator: name})
+		}
+		sets[i].Tags = append(sets[i].Tags, tag)
+	}
+	return sets
+}
+
+// amount of reviewed pictures with each class drawn by hand or accepted by the review
+func (c ControllerPicture) reviewedTagFrequencies(ctx context.Context) (map[string]int, error) {
+	frequencies := map[string]int{}
+	for _, state := range []string{controllerModel.PictureStateValidation, controllerModel.PictureStateProduction} {
+		pictures, err := c.ReadPictures(ctx, state, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, picture := range pictures {
+			names := map[string]struct{}{}
+			for _, tag := range drawnTags(picture) {
+				names[tag.Name] = struct{}{}
+			}
+			for name := range names {
+				frequencies[name]++
+			}
+		}
+	}
+	return frequencies, nil
+}
+
+// the pictures are grouped by their most confident predicted tag, or their first tag without prediction.
+// the groups are shuffled and so are their pictures, then one picture is taken from each group in turn.
+func stratifiedOrder(pictures []controllerModel.Picture, random *rand.Rand) []int {
+	var names []string
+	strata := map[string][]int{}
+	for i, picture := range pictures {
+		var name string
+		confidence := -1.0
+		for _, tag := range picture.Tags {
+			if predictedTag(tag) && tag.BoxInformation.Body.Confidence.Float64 > confidence {
+				name, confidence = tag.Name, tag.BoxInformation.Body.Confidence.Float64
+			}
+		}
+		if name == "" && len(picture.Tags) > 0 {
+			name = picture.Tags[0].Name
+		}
+		if _, ok := strata[name]; !ok {
+			names = append(names, name)
+		}
+		strata[name] = append(strata[name], i)
+	}
+
+	sort.Strings(names)
+	random.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+	for _, name := range names {
+		stratum := strata[name]
+		random.Shuffle(len(stratum), func(i, j int) { stratum[i], stratum[j] = stratum[j], stratum[i] })
+	}
+
+	order := make([]int, 0, len(pictures))
+	for len(order) < len(pictures) {
+		for _, name := range names {
+			if stratum := strata[name]; len(stratum) > 0 {
+				order = append(order, stratum[0])
+				strata[name] = stratum[1:]
+			}
+		}
+	}
+	return order
+}
+
+// frequencies of the reviewed classes and near duplicates kept between the samples, every task would read and compare all the pictures otherwise.
+// both are computed again once they are older than sampleCacheDuration, a nil cache computes them on every call
+type sampleCache struct {
+	mutex           sync.Mutex
+	frequencies     map[string]int
+	frequenciesDate time.Time
+	duplicates      *duplicateIndex
+	duplicatesDate  time.Time
+}
+
+func newSampleCache() *sampleCache {
+	return &sampleCache{}
+}
+
+func (s *sampleCache) reviewedTagFrequencies(ctx context.Context, c ControllerPicture) (map[string]int, error) {
+	if s == nil {
+		return c.reviewedTagFrequencies(ctx)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.frequencies == nil || time.Since(s.frequenciesDate) > sampleCacheDuration {
+		frequencies, err := c.reviewedTagFrequencies(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.frequencies, s.frequenciesDate = frequencies, time.Now()
+	}
+	return s.frequencies, nil
+}
+
+// cluster of each picture, the pictures whose hashes are close are in the same one.
+// only the pictures not seen since the cache was computed are compared with the others.
+func (s *sampleCache) duplicateClusters(pictures []controllerModel.Picture) []int {
+	index := newDuplicateIndex()
+	if s != nil {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if s.duplicates == nil || time.Since(s.duplicatesDate) > sampleCacheDuration {
+			s.duplicates, s.duplicatesDate = newDuplicateIndex(), time.Now()
+		}
+		index = s.duplicates
+	}
+	for _, picture := range pictures {
+		index.add(picture)
+	}
+
+	clusters := make([]int, len(pictures))
+	roots := map[controllerModel.PictureKey]int{}
+	for i, picture := range pictures {
+		root := index.find(controllerModel.PictureKey{Origin: picture.Origin, ID: picture.ID})
+		cluster, ok := roots[root]
+		if !ok {
+			cluster = len(roots)
+			roots[root] = cluster
+		}
+		clusters[i] = cluster
+	}
+	return clusters
+}
+
+// pictures grouped by close hashes, the pictures without hash are alone in their group
+type duplicateIndex struct {
+	parents map[controllerModel.PictureKey]controllerModel.PictureKey
+	hashes  map[controllerModel.PictureKey]uint64
+}
+
+func newDuplicateIndex() *duplicateIndex {
+	return &duplicateIndex{
+		parents: map[controllerModel.PictureKey]controllerModel.PictureKey{},
+		hashes:  map[controllerModel.PictureKey]uint64{},
+	}
+}
+
+// joins the picture to the groups of the pictures already added with a close hash
+func (d *duplicateIndex) add(picture controllerModel.Picture) {
+	key := controllerModel.PictureKey{Origin: picture.Origin, ID: picture.ID}
+	if _, ok := d.parents[key]; ok {
+		return
+	}
+	d.parents[key] = key
+	if !picture.Quality.Valid || picture.Quality.Body.Hash == "" {
+		return
+	}
+	hash, err := strconv.ParseUint(picture.Quality.Body.Hash, 16, 64)
+	if err != nil {
+		return
+	}
+	for other, otherHash := range d.hashes {
+		if bits.OnesCount64(hash^otherHash) <= sampleDuplicateDistance {
+			d.parents[d.find(key)] = d.find(other)
+		}
+	}
+	d.hashes[key] = hash
+}
+
+func (d *duplicateIndex) find(key controllerModel.PictureKey) controllerModel.PictureKey {
+	parent, ok := d.parents[key]
+	if !ok || parent == key {
+		return key
+	}
+	root := d.find(parent)
+	d.parents[key] = root
+	return root
+}
@@ -0,0 +1,240 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+
+	"golang.org/x/exp/slices"
+)
+
+// adds a tag outlined by a segmentation, its size must exist in the picture
+func (c ControllerPicture) CreatePictureTagSegmentation(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tag controllerModel.PictureTag) error {
+	if !tag.Segmentation.Valid {
+		return fmt.Errorf("tag %s has no segmentation", tag.Name)
+	}
+	picture, err := c.readPictureVersion(ctx, controllerModel.PictureStateProcess, primaryKey, sortKey, version)
+	if err != nil {
+		return err
+	}
+	if err := validateSegmentation(*picture, tag.Segmentation.Body); err != nil {
+		return err
+	}
+	tag.ID = model.NewUUID()
+	tag.CreationDate = time.Now()
+	audit, err := newPictureAudit(ctx, "CreatePictureTagSegmentation", primaryKey, sortKey, nil, tag)
+	if err != nil {
+		return err
+	}
+	return c.DynamodbProcess.CreatePictureTag(ctx, primaryKey, sortKey, picture.Version, tag, audit)
+}
+
+// replaces the segmentation of an existing tag
+func (c ControllerPicture) UpdatePictureTagSegmentation(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID, segmentation controllerModel.Segmentation) error {
+	picture, err := c.readPictureVersion(ctx, controllerModel.PictureStateProcess, primaryKey, sortKey, version)
+	if err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(picture.Tags, func(tag controllerModel.PictureTag) bool { return tag.ID == tagID })
+	if idx == -1 {
+		return fmt.Errorf("tag %s not found in picture %s/%s", tagID, primaryKey, sortKey)
+	}
+	if err := validateSegmentation(*picture, segmentation); err != nil {
+		return err
+	}
+	tag := picture.Tags[idx]
+	tag.Segmentation = model.NewNullable(segmentation)
+	audit, err := newPictureAudit(ctx, "UpdatePictureTagSegmentation", primaryKey, sortKey, picture.Tags[idx], tag)
+	if err != nil {
+		return err
+	}
+	return c.DynamodbProcess.UpdatePictureTag(ctx, primaryKey, sortKey, picture.Version, tag, audit)
+}
+
+// the segmentation references an existing size and stays inside of it
+func validateSegmentation(picture controllerModel.Picture, segmentation controllerModel.Segmentation) error {
+	idx := slices.IndexFunc(picture.Sizes, func(size controllerModel.PictureSize) bool { return size.ID == segmentation.PictureSizeID })
+	if idx == -1 {
+		return controllerModel.ValidationErrors{{Rule: "segmentation", Message: fmt.Sprintf("segmentation references the unknown size %s", segmentation.PictureSizeID)}}
+	}
+	size := picture.Sizes[idx].Box
+
+	var errs controllerModel.ValidationErrors
+	if len(segmentation.Polygons) == 0 && !segmentation.Mask.Valid {
+		errs = append(errs, controllerModel.ValidationError{Rule: "segmentation", Message: "segmentation has neither polygons nor mask"})
+	}
+	for i, polygon := range segmentation.Polygons {
+		if len(polygon) < 3 {
+			errs = append(errs, controllerModel.ValidationError{Rule: "segmentation", Message: fmt.Sprintf("polygon %d has %d points, minimum is 3", i, len(polygon))})
+			continue
+		}
+		for _, point := range polygon {
+			if point.X < 0 || point.Y < 0 || point.X > float64(size.Width) || point.Y > float64(size.Height) {
+				errs = append(errs, controllerModel.ValidationError{Rule: "segmentation", Message: fmt.Sprintf("point %+v of polygon %d is outside of size %s %+v", point, i, segmentation.PictureSizeID, size)})
+				break
+			}
+		}
+	}
+	if segmentation.Mask.Valid {
+		mask := segmentation.Mask.Body
+		if mask.Width != size.Width || mask.Height != size.Height {
+			errs = append(errs, controllerModel.ValidationError{Rule: "segmentation", Message: fmt.Sprintf("mask is %dx%d, size %s is %dx%d", mask.Width, mask.Height, segmentation.PictureSizeID, size.Width, size.Height)})
+		}
+		total := 0
+		for _, count := range mask.Counts {
+			if count < 0 {
+				errs = append(errs, controllerModel.ValidationError{Rule: "segmentation", Message: "mask has a negative run"})
+				break
+			}
+			total += count
+		}
+		if total != mask.Width*mask.Height {
+			errs = append(errs, controllerModel.ValidationError{Rule: "segmentation", Message: fmt.Sprintf("mask runs cover %d pixels instead of %d", total, mask.Width*mask.Height)})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// segmentation relative to the crop, ok is false when nothing of it is left inside
+func cropSegmentation(segmentation controllerModel.Segmentation, box controllerModel.Box) (controllerModel.Segmentation, bool) {
+	polygons := make([]controllerModel.Polygon, 0, len(segmentation.Polygons))
+	for _, polygon := range segmentation.Polygons {
+		clipped := clipPolygon(polygon, box)
+		if len(clipped) < 3 || polygonArea(clipped) == 0 {
+			continue
+		}
+		for i := range clipped {
+			clipped[i].X -= float64(box.Tlx)
+			clipped[i].Y -= float64(box.Tly)
+		}
+		polygons = append(polygons, clipped)
+	}
+	segmentation.Polygons = polygons
+
+	if segmentation.Mask.Valid {
+		mask, ok := cropMask(segmentation.Mask.Body, box)
+		if ok {
+			segmentation.Mask = model.NewNullable(mask)
+		} else {
+			segmentation.Mask = model.Nullable[controllerModel.Mask]{}
+		}
+	}
+	return segmentation, len(segmentation.Polygons) > 0 || segmentation.Mask.Valid
+}
+
+// Sutherland–Hodgman clipping of the polygon by the box
+func clipPolygon(polygon controllerModel.Polygon, box controllerModel.Box) controllerModel.Polygon {
+	left, top := float64(box.Tlx), float64(box.Tly)
+	right, bottom := float64(box.Tlx+box.Width), float64(box.Tly+box.Height)
+	edges := []struct {
+		inside    func(p controllerModel.Point) bool
+		intersect func(a, b controllerModel.Point) controllerModel.Point
+	}{
+		{
+			inside:    func(p controllerModel.Point) bool { return p.X >= left },
+			intersect: func(a, b controllerModel.Point) controllerModel.Point { return intersectX(a, b, left) },
+		},
+		{
+			inside:    func(p controllerModel.Point) bool { return p.X <= right },
+			intersect: func(a, b controllerModel.Point) controllerModel.Point { return intersectX(a, b, right) },
+		},
+		{
+			inside:    func(p controllerModel.Point) bool { return p.Y >= top },
+			intersect: func(a, b controllerModel.Point) controllerModel.Point { return intersectY(a, b, top) },
+		},
+		{
+			inside:    func(p controllerModel.Point) bool { return p.Y <= bottom },
+			intersect: func(a, b controllerModel.Point) controllerModel.Point { return intersectY(a, b, bottom) },
+		},
+	}
+
+	output := slices.Clone(polygon)
+	for _, edge := range edges {
+		input := output
+		output = make(controllerModel.Polygon, 0, len(input)+1)
+		for i, current := range input {
+			previous := input[(i+len(input)-1)%len(input)]
+			if edge.inside(current) {
+				if !edge.inside(previous) {
+					output = append(output, edge.intersect(previous, current))
+				}
+				output = append(output, current)
+			} else if edge.inside(previous) {
+				output = append(output, edge.intersect(previous, current))
+			}
+		}
+		if len(output) == 0 {
+			break
+		}
+	}
+	return output
+}
+
+func intersectX(a, b controllerModel.Point, x float64) controllerModel.Point {
+	return controllerModel.Point{X: x, Y: a.Y + (b.Y-a.Y)*(x-a.X)/(b.X-a.X)}
+}
+
+func intersectY(a, b controllerModel.Point, y float64) controllerModel.Point {
+	return controllerModel.Point{X: a.X + (b.X-a.X)*(y-a.Y)/(b.Y-a.Y), Y: y}
+}
+
+// shoelace formula
+func polygonArea(polygon controllerModel.Polygon) float64 {
+	area := 0.
+	for i, current := range polygon {
+		next := polygon[(i+1)%len(polygon)]
+		area += current.X*next.Y - next.X*current.Y
+	}
+	return math.Abs(area) / 2
+}
+
+// mask of the crop, ok is false when none of its pixels is set
+func cropMask(mask controllerModel.Mask, box controllerModel.Box) (controllerModel.Mask, bool) {
+	pixels := decodeMask(mask)
+	cropped := make([]bool, 0, box.Width*box.Height)
+	set := false
+	for x := box.Tlx; x < box.Tlx+box.Width; x++ {
+		for y := box.Tly; y < box.Tly+box.Height; y++ {
+			pixel := x >= 0 && y >= 0 && x < mask.Width && y < mask.Height && pixels[x*mask.Height+y]
+			set = set || pixel
+			cropped = append(cropped, pixel)
+		}
+	}
+	return controllerModel.Mask{Width: box.Width, Height: box.Height, Counts: encodeMask(cropped)}, set
+}
+
+func decodeMask(mask controllerModel.Mask) []bool {
+	pixels := make([]bool, 0, mask.Width*mask.Height)
+	value := false
+	for _, count := range mask.Counts {
+		for i := 0; i < count && len(pixels) < cap(pixels); i++ {
+			pixels = append(pixels, value)
+		}
+		value = !value
+	}
+	// runs shorter than the mask leave the remaining pixels unset
+	for len(pixels) < cap(pixels) {
+		pixels = append(pixels, false)
+	}
+	return pixels
+}
+
+func encodeMask(pixels []bool) []int {
+	counts := []int{0}
+	value := false
+	for _, pixel := range pixels {
+		if pixel != value {
+			counts = append(counts, 0)
+			value = pixel
+		}
+		counts[len(counts)-1]++
+	}
+	return counts
+}
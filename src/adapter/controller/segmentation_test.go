@@ -0,0 +1,195 @@
+package controller
+
+import (
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+// 4x4 mask whose pixels 1 and 2 of the columns 1 and 2 are set
+var squareMask = controllerModel.Mask{Width: 4, Height: 4, Counts: []int{5, 2, 2, 2, 5}}
+
+func TestValidateSegmentation(t *testing.T) {
+	size := controllerModel.PictureSize{ID: model.NewUUID(), Box: controllerModel.Box{Width: 4, Height: 4}}
+	picture := controllerModel.Picture{Sizes: []controllerModel.PictureSize{size}}
+	square := controllerModel.Polygon{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 4}, {X: 0, Y: 4}}
+
+	tests := []struct {
+		name         string
+		segmentation controllerModel.Segmentation
+		wantErrors   int
+	}{
+		{
+			name:         "polygon",
+			segmentation: controllerModel.Segmentation{PictureSizeID: size.ID, Polygons: []controllerModel.Polygon{square}},
+		},
+		{
+			name:         "mask",
+			segmentation: controllerModel.Segmentation{PictureSizeID: size.ID, Mask: model.NewNullable(squareMask)},
+		},
+		{
+			name:         "unknown size",
+			segmentation: controllerModel.Segmentation{PictureSizeID: model.NewUUID(), Polygons: []controllerModel.Polygon{square}},
+			wantErrors:   1,
+		},
+		{
+			name:         "neither polygons nor mask",
+			segmentation: controllerModel.Segmentation{PictureSizeID: size.ID},
+			wantErrors:   1,
+		},
+		{
+			name:         "polygon of two points",
+			segmentation: controllerModel.Segmentation{PictureSizeID: size.ID, Polygons: []controllerModel.Polygon{{{X: 0, Y: 0}, {X: 4, Y: 4}}}},
+			wantErrors:   1,
+		},
+		{
+			name:         "points outside of the size",
+			segmentation: controllerModel.Segmentation{PictureSizeID: size.ID, Polygons: []controllerModel.Polygon{{{X: 0, Y: 0}, {X: 5, Y: 0}, {X: 5, Y: -1}}, square}},
+			wantErrors:   1,
+		},
+		{
+			name:         "mask of another size",
+			segmentation: controllerModel.Segmentation{PictureSizeID: size.ID, Mask: model.NewNullable(controllerModel.Mask{Width: 2, Height: 8, Counts: []int{16}})},
+			wantErrors:   1,
+		},
+		{
+			name:         "mask runs too short",
+			segmentation: controllerModel.Segmentation{PictureSizeID: size.ID, Mask: model.NewNullable(controllerModel.Mask{Width: 4, Height: 4, Counts: []int{5, 2}})},
+			wantErrors:   1,
+		},
+		{
+			name:         "mask with a negative run",
+			segmentation: controllerModel.Segmentation{PictureSizeID: size.ID, Mask: model.NewNullable(controllerModel.Mask{Width: 4, Height: 4, Counts: []int{18, -2}})},
+			wantErrors:   2, // the runs are no longer counted after the negative one
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateSegmentation(picture, test.segmentation)
+			if test.wantErrors == 0 {
+				if err != nil {
+					t.Errorf("validateSegmentation() error = %v, want none", err)
+				}
+				return
+			}
+			validationErrors, ok := err.(controllerModel.ValidationErrors)
+			if !ok || len(validationErrors) != test.wantErrors {
+				t.Errorf("validateSegmentation() error = %v, want %d validation errors", err, test.wantErrors)
+			}
+		})
+	}
+}
+
+func TestCropSegmentation(t *testing.T) {
+	tests := []struct {
+		name         string
+		polygons     []controllerModel.Polygon
+		wantPolygons int
+		wantBox      controllerModel.Box
+		wantArea     float64
+		wantOk       bool
+	}{
+		{
+			name:         "inside",
+			polygons:     []controllerModel.Polygon{{{X: 150, Y: 150}, {X: 200, Y: 150}, {X: 200, Y: 200}, {X: 150, Y: 200}}},
+			wantPolygons: 1,
+			wantBox:      controllerModel.Box{Tlx: 50, Tly: 50, Width: 50, Height: 50},
+			wantArea:     2500,
+			wantOk:       true,
+		},
+		{
+			name:         "crossing the top left corner",
+			polygons:     []controllerModel.Polygon{{{X: 50, Y: 50}, {X: 150, Y: 50}, {X: 150, Y: 150}, {X: 50, Y: 150}}},
+			wantPolygons: 1,
+			wantBox:      controllerModel.Box{Tlx: 0, Tly: 0, Width: 50, Height: 50},
+			wantArea:     2500,
+			wantOk:       true,
+		},
+		{
+			name:         "triangle crossing the right edge",
+			polygons:     []controllerModel.Polygon{{{X: 250, Y: 150}, {X: 350, Y: 150}, {X: 250, Y: 250}}},
+			wantPolygons: 1,
+			wantBox:      controllerModel.Box{Tlx: 150, Tly: 50, Width: 50, Height: 100},
+			wantArea:     3750,
+			wantOk:       true,
+		},
+		{
+			name:     "outside",
+			polygons: []controllerModel.Polygon{{{X: 0, Y: 0}, {X: 50, Y: 0}, {X: 50, Y: 50}}},
+		},
+		{
+			name:     "touching an edge only",
+			polygons: []controllerModel.Polygon{{{X: 50, Y: 150}, {X: 100, Y: 150}, {X: 50, Y: 200}}},
+		},
+		{
+			name:         "one polygon of two left",
+			polygons:     []controllerModel.Polygon{{{X: 0, Y: 0}, {X: 50, Y: 0}, {X: 50, Y: 50}}, {{X: 100, Y: 100}, {X: 110, Y: 100}, {X: 110, Y: 110}, {X: 100, Y: 110}}},
+			wantPolygons: 1,
+			wantBox:      controllerModel.Box{Tlx: 0, Tly: 0, Width: 10, Height: 10},
+			wantArea:     100,
+			wantOk:       true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			segmentation, ok := cropSegmentation(controllerModel.Segmentation{Polygons: test.polygons}, cropBox)
+			if ok != test.wantOk || len(segmentation.Polygons) != test.wantPolygons {
+				t.Fatalf("cropSegmentation() = %d polygons, %v, want %d, %v", len(segmentation.Polygons), ok, test.wantPolygons, test.wantOk)
+			}
+			if !ok {
+				return
+			}
+			if got := segmentationBox(segmentation); got != test.wantBox {
+				t.Errorf("box = %+v, want %+v", got, test.wantBox)
+			}
+			if got := polygonArea(segmentation.Polygons[0]); got != test.wantArea {
+				t.Errorf("area = %v, want %v", got, test.wantArea)
+			}
+		})
+	}
+}
+
+func TestCropMask(t *testing.T) {
+	tests := []struct {
+		name       string
+		box        controllerModel.Box
+		wantCounts []int
+		wantOk     bool
+	}{
+		{name: "whole mask", box: controllerModel.Box{Width: 4, Height: 4}, wantCounts: squareMask.Counts, wantOk: true},
+		{name: "right half", box: controllerModel.Box{Tlx: 2, Width: 2, Height: 4}, wantCounts: []int{1, 2, 5}, wantOk: true},
+		{name: "beyond the bottom right corner", box: controllerModel.Box{Tlx: 2, Tly: 2, Width: 3, Height: 3}, wantCounts: []int{0, 1, 8}, wantOk: true},
+		{name: "before the top left corner", box: controllerModel.Box{Tlx: -1, Tly: -1, Width: 3, Height: 3}, wantCounts: []int{8, 1}, wantOk: true},
+		{name: "nothing set", box: controllerModel.Box{Tlx: 3, Width: 1, Height: 4}, wantCounts: []int{4}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mask, ok := cropMask(squareMask, test.box)
+			if ok != test.wantOk {
+				t.Errorf("cropMask() ok = %v, want %v", ok, test.wantOk)
+			}
+			if mask.Width != test.box.Width || mask.Height != test.box.Height || !slices.Equal(mask.Counts, test.wantCounts) {
+				t.Errorf("cropMask() = %+v, want %dx%d %v", mask, test.box.Width, test.box.Height, test.wantCounts)
+			}
+			if ok && maskArea(mask) == 0 {
+				t.Errorf("cropMask() = %+v, want pixels set", mask)
+			}
+		})
+	}
+}
+
+func TestMaskEncoding(t *testing.T) {
+	pixels := decodeMask(squareMask)
+	if len(pixels) != 16 || !pixels[5] || !pixels[6] || !pixels[9] || !pixels[10] || pixels[0] || pixels[15] {
+		t.Fatalf("decodeMask() = %v, want the pixels 5, 6, 9 and 10 set", pixels)
+	}
+	if counts := encodeMask(pixels); !slices.Equal(counts, squareMask.Counts) {
+		t.Errorf("encodeMask() = %v, want %v", counts, squareMask.Counts)
+	}
+	// the runs always start with the unset pixels
+	if counts := encodeMask([]bool{true, true, false}); !slices.Equal(counts, []int{0, 2, 1}) {
+		t.Errorf("encodeMask() = %v, want [0 2 1]", counts)
+	}
+}
@@ -3,67 +3,328 @@ package controller
 import (
 	"context"
 	"fmt"
-	"regexp"
 	controllerModel "scraper-backend/src/adapter/controller/model"
 	interfaceAdapter "scraper-backend/src/adapter/interface"
+	dynamodbTable "scraper-backend/src/driver/database/dynamodb/table"
 	interfaceDatabase "scraper-backend/src/driver/interface/database"
 	model "scraper-backend/src/driver/model"
+	"sort"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"golang.org/x/exp/slices"
 )
 
 type ControllerTag struct {
 	Dynamodb          interfaceDatabase.DriverDynamodbTag
+	DynamodbAudit     interfaceDatabase.DriverDynamodbAudit
 	ControllerPicture interfaceAdapter.ControllerPicture
 }
 
 func (c ControllerTag) CreateTag(ctx context.Context, tag controllerModel.Tag) error {
+	_, err := c.createTag(ctx, tag)
+	return err
+}
+
+// returns the ID of the tag created
+func (c ControllerTag) createTag(ctx context.Context, tag controllerModel.Tag) (model.UUID, error) {
 	existingTags, err := c.Dynamodb.ScanTags(ctx)
 	if err != nil {
-		return err
+		return model.UUID{}, err
 	}
 
-	idx := slices.IndexFunc(existingTags, func(existingTag controllerModel.Tag) bool {
-		regexpMatch := fmt.Sprintf(`[\-\_\w\d]*%s[\-\_\w\d]*`, strings.ToLower(existingTag.Name))
-		matched, err := regexp.Match(regexpMatch, []byte(tag.Name)) // e.g. match if thisTag has `model` and bodyTag `models`
-		if err != nil {
-			return false
+	if tag.Mode != controllerModel.TagModeRegex {
+		tag.Name = strings.ToLower(tag.Name)
+	}
+	tag.Parent = strings.ToLower(tag.Parent)
+	tag.Synonyms = tagSynonyms(tag, tag.Synonyms)
+	if err := validateTagHierarchy(existingTags, tag); err != nil {
+		return model.UUID{}, err
+	}
+	if err := validateTagMode(tag); err != nil {
+		return model.UUID{}, err
+	}
+	if err := validateTagTarget(tag.TargetCount, tag.MaxPages); err != nil {
+		return model.UUID{}, err
+	}
+
+	if tag.Skeleton.Valid {
+		if err := validateSkeleton(tag.Skeleton.Body); err != nil {
+			return model.UUID{}, err
 		}
-		return matched
-	})
-	if idx != -1 {
-		return fmt.Errorf("tag `%s` is too closely related to `%+#v`", tag.Name, existingTags[idx])
 	}
 
 	tag.ID = model.NewUUID()
 	tag.CreationDate = time.Now()
-	tag.Name = strings.ToLower(tag.Name)
 	tag.OriginName = strings.ToLower(tag.OriginName)
-	return c.Dynamodb.CreateTag(ctx, tag)
+	audit, err := newAudit(ctx, controllerModel.AuditEntityTag, tag.Type, tag.ID, "CreateTag", nil, tag)
+	if err != nil {
+		return model.UUID{}, err
+	}
+	if err := c.Dynamodb.CreateTag(ctx, tag, audit); err != nil {
+		return model.UUID{}, err
+	}
+	return tag.ID, nil
 }
 
+// the pictures already in the process and validation tables matching the tag are moved to the blocked one in the background
 func (c ControllerTag) CreateTagBlocked(ctx context.Context, tag controllerModel.Tag) error {
-	if err := c.CreateTag(ctx, tag); err != nil {
+	tag.Type = dynamodbTable.TagPrimaryKeyBlocked
+	id, err := c.createTag(ctx, tag)
+	if err != nil {
 		return err
 	}
+	sweepPicturesBlocked(ctx, c.ControllerPicture, c.DynamodbAudit, controllerModel.AuditEntityTag, tag.Type, id)
+	return nil
+}
 
-	projEx := expression.NamesList(expression.Name("Origin"), expression.Name("Name"), expression.Name("Tags"))
-	filtEx := expression.Name("Tags.#Name").Contains(tag.Name)
-	pictures, err := c.ControllerPicture.ReadPictures(ctx, "process", &projEx, &filtEx)
+func (c ControllerTag) DeleteTag(ctx context.Context, primaryKey string, sortKey model.UUID) error {
+	tags, err := c.Dynamodb.ReadTags(ctx, primaryKey)
+	if err != nil {
+		return err
+	}
+	if idx := slices.IndexFunc(tags, func(tag controllerModel.Tag) bool { return tag.ID == sortKey }); idx != -1 {
+		name := tags[idx].Name
+		if child := slices.IndexFunc(tags, func(tag controllerModel.Tag) bool { return tag.Parent == name }); child != -1 {
+			return fmt.Errorf("tag `%s` is the parent of `%s`, change its parent first", name, tags[child].Name)
+		}
+	}
+	var before any
+	if idx := slices.IndexFunc(tags, func(tag controllerModel.Tag) bool { return tag.ID == sortKey }); idx != -1 {
+		before = tags[idx]
+	}
+	audit, err := newAudit(ctx, controllerModel.AuditEntityTag, primaryKey, sortKey, "DeleteTag", before, nil)
 	if err != nil {
 		return err
 	}
+	return c.Dynamodb.DeleteTag(ctx, primaryKey, sortKey, audit)
+}
 
-	return c.ControllerPicture.DeletePicturesAndFiles(ctx, pictures)
+func (c ControllerTag) ReadTags(ctx context.Context, primaryKey string) ([]controllerModel.Tag, error) {
+	return c.Dynamodb.ReadTags(ctx, primaryKey)
 }
 
-func (c ControllerTag) DeleteTag(ctx context.Context, primaryKey string, sortKey model.UUID) error {
-	return c.Dynamodb.DeleteTag(ctx, primaryKey, sortKey)
+func (c ControllerTag) ReadTag(ctx context.Context, primaryKey string, sortKey model.UUID) (*controllerModel.Tag, error) {
+	tags, err := c.Dynamodb.ReadTags(ctx, primaryKey)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(tags, func(tag controllerModel.Tag) bool { return tag.ID == sortKey })
+	if idx == -1 {
+		return nil, fmt.Errorf("tag %s/%s not found", primaryKey, sortKey)
+	}
+	return &tags[idx], nil
 }
 
-func (c ControllerTag) ReadTags(ctx context.Context, primaryKey string) ([]controllerModel.Tag, error) {
-	return c.Dynamodb.ReadTags(ctx, primaryKey)
+// replaces the skeleton followed by the keypoints of the tag, the keypoints already stored are kept as they are
+func (c ControllerTag) UpdateTagSkeleton(ctx context.Context, primaryKey string, sortKey model.UUID, skeleton controllerModel.Skeleton) error {
+	if err := validateSkeleton(skeleton); err != nil {
+		return err
+	}
+	tag, err := c.ReadTag(ctx, primaryKey, sortKey)
+	if err != nil {
+		return err
+	}
+	before := *tag
+	tag.Skeleton = model.NewNullable(skeleton)
+	audit, err := newAudit(ctx, controllerModel.AuditEntityTag, primaryKey, sortKey, "UpdateTagSkeleton", before, *tag)
+	if err != nil {
+		return err
+	}
+	return c.Dynamodb.CreateTag(ctx, *tag, audit)
+}
+
+// replaces the parent and the synonyms of the tag
+func (c ControllerTag) UpdateTagHierarchy(ctx context.Context, primaryKey string, sortKey model.UUID, parent string, synonyms []string) error {
+	existingTags, err := c.Dynamodb.ScanTags(ctx)
+	if err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(existingTags, func(tag controllerModel.Tag) bool { return tag.Type == primaryKey && tag.ID == sortKey })
+	if idx == -1 {
+		return fmt.Errorf("tag %s/%s not found", primaryKey, sortKey)
+	}
+	tag := existingTags[idx]
+	before := tag
+	tag.Parent = strings.ToLower(parent)
+	tag.Synonyms = tagSynonyms(tag, synonyms)
+	if err := validateTagHierarchy(slices.Delete(slices.Clone(existingTags), idx, idx+1), tag); err != nil {
+		return err
+	}
+	if err := validateTagMode(tag); err != nil {
+		return err
+	}
+	audit, err := newAudit(ctx, controllerModel.AuditEntityTag, primaryKey, sortKey, "UpdateTagHierarchy", before, tag)
+	if err != nil {
+		return err
+	}
+	return c.Dynamodb.CreateTag(ctx, tag, audit)
+}
+
+// synonyms without duplicates nor the name of the tag, lowercased unless they are regular expressions
+func tagSynonyms(tag controllerModel.Tag, synonyms []string) []string {
+	var cleaned []string
+	for _, synonym := range synonyms {
+		synonym = strings.TrimSpace(synonym)
+		if tag.Mode != controllerModel.TagModeRegex {
+			synonym = strings.ToLower(synonym)
+		}
+		if synonym == "" || synonym == tag.Name || slices.Contains(cleaned, synonym) {
+			continue
+		}
+		cleaned = append(cleaned, synonym)
+	}
+	return cleaned
+}
+
+// name of the tag followed by its synonyms
+func tagNames(tag controllerModel.Tag) []string {
+	return append([]string{tag.Name}, tag.Synonyms...)
+}
+
+// the names and synonyms of the tag must not be used by another tag, whatever its type.
+// its parent must be a searched tag and must not descend from it
+func validateTagHierarchy(existingTags []controllerModel.Tag, tag controllerModel.Tag) error {
+	for _, existingTag := range existingTags {
+		for _, name := range tagNames(tag) {
+			if slices.Contains(tagNames(existingTag), name) {
+				return fmt.Errorf("`%s` is already a name or a synonym of the %s tag `%s`", name, existingTag.Type, existingTag.Name)
+			}
+		}
+	}
+	if tag.Parent == "" {
+		return nil
+	}
+	if tag.Type != dynamodbTable.TagPrimaryKeySearched {
+		return fmt.Errorf("only the searched tags can have a parent")
+	}
+	parents := map[string]string{}
+	for _, existingTag := range existingTags {
+		if existingTag.Type == dynamodbTable.TagPrimaryKeySearched {
+			parents[existingTag.Name] = existingTag.Parent
+		}
+	}
+	if _, ok := parents[tag.Parent]; !ok {
+		return fmt.Errorf("parent `%s` is not a searched tag", tag.Parent)
+	}
+	for ancestor, depth := tag.Parent, 0; ancestor != "" && depth <= len(parents); ancestor, depth = parents[ancestor], depth+1 {
+		if ancestor == tag.Name {
+			return fmt.Errorf("tag `%s` cannot descend from itself through `%s`", tag.Name, tag.Parent)
+		}
+	}
+	return nil
+}
+
+// the searched tags are sent to the hosts so they cannot be regular expressions, the names of the others must compile
+func validateTagMode(tag controllerModel.Tag) error {
+	if tag.Mode == controllerModel.TagModeRegex && tag.Type == dynamodbTable.TagPrimaryKeySearched {
+		return fmt.Errorf("only the blocked tags can be regular expressions")
+	}
+	_, err := NewTagMatcher([]controllerModel.Tag{tag})
+	return err
+}
+
+// name of the tag of each name or synonym, rolled up to its ancestor at the level when it is deeper than it.
+// the roots are at level 0, a negative level keeps every tag
+func tagCanonicalNames(tags []controllerModel.Tag, level int) map[string]string {
+	parents := map[string]string{}
+	for _, tag := range tags {
+		parents[tag.Name] = tag.Parent
+	}
+	canonicalNames := map[string]string{}
+	for _, tag := range tags {
+		name := tag.Name
+		if level >= 0 {
+			lineage := []string{tag.Name}
+			for ancestor := tag.Parent; ancestor != "" && len(lineage) <= len(parents); ancestor = parents[ancestor] {
+				if _, ok := parents[ancestor]; !ok {
+					break
+				}
+				lineage = append([]string{ancestor}, lineage...)
+			}
+			if len(lineage) > level+1 {
+				name = lineage[level]
+			}
+		}
+		for _, synonym := range tagNames(tag) {
+			canonicalNames[synonym] = name
+		}
+	}
+	return canonicalNames
+}
+
+// replaces the pictures wanted and the pages read at most for the searched tag
+func (c ControllerTag) UpdateTagTarget(ctx context.Context, primaryKey string, sortKey model.UUID, targetCount, maxPages int) error {
+	if err := validateTagTarget(targetCount, maxPages); err != nil {
+		return err
+	}
+	tag, err := c.ReadTag(ctx, primaryKey, sortKey)
+	if err != nil {
+		return err
+	}
+	before := *tag
+	tag.TargetCount = targetCount
+	tag.MaxPages = maxPages
+	audit, err := newAudit(ctx, controllerModel.AuditEntityTag, primaryKey, sortKey, "UpdateTagTarget", before, *tag)
+	if err != nil {
+		return err
+	}
+	return c.Dynamodb.CreateTag(ctx, *tag, audit)
+}
+
+func validateTagTarget(targetCount, maxPages int) error {
+	if targetCount < 0 || maxPages < 0 {
+		return fmt.Errorf("the target count and the maximum of pages must not be negative")
+	}
+	return nil
+}
+
+// pictures of every searched tag in the process, validation and production tables, the tags furthest from their target first
+func (c ControllerTag) ReadTagsProgress(ctx context.Context) ([]controllerModel.TagProgress, error) {
+	searchedTags, err := c.Dynamodb.ReadTags(ctx, dynamodbTable.TagPrimaryKeySearched)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]map[string]int{}
+	for _, state := range targetStates {
+		counts[state], err = countSearchedTags(ctx, c.ControllerPicture, state, searchedTags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	progresses := make([]controllerModel.TagProgress, 0, len(searchedTags))
+	for _, tag := range searchedTags {
+		progress := controllerModel.TagProgress{
+			Name:        tag.Name,
+			TargetCount: tag.TargetCount,
+			Process:     counts[controllerModel.PictureStateProcess][tag.Name],
+			Validation:  counts[controllerModel.PictureStateValidation][tag.Name],
+			Production:  counts[controllerModel.PictureStateProduction][tag.Name],
+		}
+		if remaining := tag.TargetCount - progress.Process - progress.Validation - progress.Production; tag.TargetCount > 0 && remaining > 0 {
+			progress.Remaining = remaining
+		}
+		progresses = append(progresses, progress)
+	}
+	sort.SliceStable(progresses, func(i, j int) bool {
+		if progresses[i].Remaining != progresses[j].Remaining {
+			return progresses[i].Remaining > progresses[j].Remaining
+		}
+		return progresses[i].Name < progresses[j].Name
+	})
+	return progresses, nil
+}
+
+// blocked tags hitting the names, with the name or synonym that matched
+func (c ControllerTag) TestTagsBlocked(ctx context.Context, names []string) ([]controllerModel.TagMatch, error) {
+	blockedTags, err := c.Dynamodb.ReadTags(ctx, dynamodbTable.TagPrimaryKeyBlocked)
+	if err != nil {
+		return nil, err
+	}
+	matcher, err := NewTagMatcher(blockedTags)
+	if err != nil {
+		return nil, err
+	}
+	return matcher.Match(names), nil
 }
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	interfaceAdapter "scraper-backend/src/adapter/interface"
+	model "scraper-backend/src/driver/model"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+)
+
+// tables whose pictures are counted towards the target of a searched tag
+var targetStates = []string{controllerModel.PictureStateProcess, controllerModel.PictureStateValidation, controllerModel.PictureStateProduction}
+
+// pictures of a state by searched tag, a picture is counted once for each searched tag or synonym it has
+func countSearchedTags(ctx context.Context, controllerPicture interfaceAdapter.ControllerPicture, state string, searchedTags []controllerModel.Tag) (map[string]int, error) {
+	canonicalNames := tagCanonicalNames(searchedTags, -1)
+	projEx := expression.NamesList(expression.Name("Tags"))
+	pictures, err := controllerPicture.ReadPictures(ctx, state, &projEx, nil)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int{}
+	for _, picture := range pictures {
+		searched := map[string]struct{}{}
+		for _, tag := range picture.Tags {
+			if name, ok := canonicalNames[tag.Name]; ok {
+				searched[name] = struct{}{}
+			}
+		}
+		for name := range searched {
+			counts[name]++
+		}
+	}
+	return counts, nil
+}
+
+// pictures still wanted and pages still allowed for each searched tag during a search, shared by its concurrent fetches
+type searchBudget struct {
+	mutex          sync.Mutex
+	tags           map[string]controllerModel.Tag
+	canonicalNames map[string]string
+	counts         map[string]int
+	pages          map[string]int
+}
+
+func readSearchBudget(ctx context.Context, controllerPicture interfaceAdapter.ControllerPicture, searchedTags []controllerModel.Tag) (*searchBudget, error) {
+	budget := searchBudget{
+		tags:           map[string]controllerModel.Tag{},
+		canonicalNames: tagCanonicalNames(searchedTags, -1),
+		counts:         map[string]int{},
+		pages:          map[string]int{},
+	}
+	hasTarget := false
+	for _, tag := range searchedTags {
+		budget.tags[tag.Name] = tag
+		hasTarget = hasTarget || tag.TargetCount > 0
+	}
+	if !hasTarget {
+		return &budget, nil
+	}
+	for _, state := range targetStates {
+		counts, err := countSearchedTags(ctx, controllerPicture, state, searchedTags)
+		if err != nil {
+			return nil, err
+		}
+		for name, count := range counts {
+			budget.counts[name] += count
+		}
+	}
+	return &budget, nil
+}
+
+// whether the searched tag has reached its target, checked again when a picture is created
+func (b *searchBudget) full(searchedTag string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.targetReached(searchedTag)
+}
+
+// whether the searched tag has reached its target or its maximum of pages
+func (b *searchBudget) exhausted(searchedTag string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.targetReached(searchedTag) || b.pagesReached(searchedTag)
+}
+
+// counts a page of results of the searched tag about to be read, false when none must be read anymore
+func (b *searchBudget) page(searchedTag string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.targetReached(searchedTag) || b.pagesReached(searchedTag) {
+		return false
+	}
+	b.pages[searchedTag]++
+	return true
+}
+
+func (b *searchBudget) targetReached(searchedTag string) bool {
+	tag := b.tags[searchedTag]
+	return tag.TargetCount > 0 && b.counts[searchedTag] >= tag.TargetCount
+}
+
+func (b *searchBudget) pagesReached(searchedTag string) bool {
+	tag := b.tags[searchedTag]
+	return tag.MaxPages > 0 && b.pages[searchedTag] >= tag.MaxPages
+}
+
+// tags the picture found with the searched tag so that it is counted towards it, unless it already has the tag or a synonym
+func (b *searchBudget) tagPicture(picture *controllerModel.Picture, searchedTag string) {
+	for _, tag := range picture.Tags {
+		if b.canonicalNames[tag.Name] == searchedTag {
+			return
+		}
+	}
+	picture.Tags = append(picture.Tags, controllerModel.PictureTag{
+		ID:           model.NewUUID(),
+		Name:         searchedTag,
+		CreationDate: time.Now(),
+		OriginName:   picture.Origin,
+	})
+}
+
+// counts a picture of the searched tag before it is created so that the concurrent fetches cannot exceed the target, false when the target is already reached
+func (b *searchBudget) reserve(searchedTag string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.targetReached(searchedTag) {
+		return false
+	}
+	b.counts[searchedTag]++
+	return true
+}
+
+// gives back the reservation of a picture that was not created
+func (b *searchBudget) release(searchedTag string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.counts[searchedTag]--
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	interfaceAdapter "scraper-backend/src/adapter/interface"
+	model "scraper-backend/src/driver/model"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+)
+
+// pictures of every state, the other methods are not used
+type fakeControllerPictureStates struct {
+	interfaceAdapter.ControllerPicture
+	pictures map[string][]controllerModel.Picture
+}
+
+func (f *fakeControllerPictureStates) ReadPictures(ctx context.Context, state string, projection *expression.ProjectionBuilder, filter *expression.ConditionBuilder) ([]controllerModel.Picture, error) {
+	return f.pictures[state], nil
+}
+
+func taggedPicture(names ...string) controllerModel.Picture {
+	picture := controllerModel.Picture{Origin: "pexels", ID: model.NewUUID(), User: controllerModel.User{OriginID: "42"}}
+	for _, name := range names {
+		picture.Tags = append(picture.Tags, controllerModel.PictureTag{ID: model.NewUUID(), Name: name})
+	}
+	return picture
+}
+
+func TestReadSearchBudget(t *testing.T) {
+	pictures := &fakeControllerPictureStates{pictures: map[string][]controllerModel.Picture{
+		controllerModel.PictureStateProcess:    {taggedPicture("dog"), taggedPicture("dog", "puppy"), taggedPicture("cat")},
+		controllerModel.PictureStateValidation: {taggedPicture("doggy")},
+		controllerModel.PictureStateProduction: {taggedPicture("dog", "grass")},
+		controllerModel.PictureStateBlocked:    {taggedPicture("dog"), taggedPicture("dog")},
+	}}
+	tests := []struct {
+		name       string
+		tags       []controllerModel.Tag
+		wantCounts map[string]int
+	}{
+		{
+			name:       "without target nothing is counted",
+			tags:       []controllerModel.Tag{{Name: "dog"}, {Name: "cat"}},
+			wantCounts: map[string]int{},
+		},
+		{
+			name:       "synonyms counted once per picture, blocked pictures left out",
+			tags:       []controllerModel.Tag{{Name: "dog", Synonyms: []string{"puppy", "doggy"}, TargetCount: 10}, {Name: "cat"}},
+			wantCounts: map[string]int{"dog": 4, "cat": 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			budget, err := readSearchBudget(context.Background(), pictures, test.tags)
+			if err != nil {
+				t.Fatalf("readSearchBudget() error = %v", err)
+			}
+			if len(budget.counts) != len(test.wantCounts) {
+				t.Errorf("counts = %v, want %v", budget.counts, test.wantCounts)
+			}
+			for name, want := range test.wantCounts {
+				if budget.counts[name] != want {
+					t.Errorf("count of %s = %d, want %d", name, budget.counts[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchBudget(t *testing.T) {
+	tests := []struct {
+		name          string
+		tag           controllerModel.Tag
+		count         int
+		wantPages     int // pages read before the search stops, -1 when it never stops
+		wantReserved  int // reservations granted out of 5
+		wantExhausted bool
+	}{
+		{name: "no limit", tag: controllerModel.Tag{Name: "dog"}, wantPages: -1, wantReserved: 5},
+		{name: "pages limited", tag: controllerModel.Tag{Name: "dog", MaxPages: 3}, wantPages: 3, wantReserved: 5, wantExhausted: true},
+		{name: "target almost reached", tag: controllerModel.Tag{Name: "dog", TargetCount: 10}, count: 8, wantPages: -1, wantReserved: 2, wantExhausted: true},
+		{name: "target reached", tag: controllerModel.Tag{Name: "dog", TargetCount: 10, MaxPages: 3}, count: 10, wantPages: 0, wantReserved: 0, wantExhausted: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			budget := searchBudget{
+				tags:   map[string]controllerModel.Tag{"dog": test.tag},
+				counts: map[string]int{"dog": test.count},
+				pages:  map[string]int{},
+			}
+			pages := 0
+			for ; pages < 10 && budget.page("dog"); pages++ {
+			}
+			if test.wantPages == -1 && pages != 10 || test.wantPages != -1 && pages != test.wantPages {
+				t.Errorf("%d pages read, want %d", pages, test.wantPages)
+			}
+			reserved := 0
+			for i := 0; i < 5; i++ {
+				if budget.reserve("dog") {
+					reserved++
+				}
+			}
+			if reserved != test.wantReserved {
+				t.Errorf("%d reservations, want %d", reserved, test.wantReserved)
+			}
+			if budget.exhausted("dog") != test.wantExhausted {
+				t.Errorf("exhausted = %v, want %v", budget.exhausted("dog"), test.wantExhausted)
+			}
+			if test.tag.TargetCount > 0 && reserved > 0 {
+				budget.release("dog")
+				if budget.full("dog") {
+					t.Errorf("full after a release, want a picture wanted again")
+				}
+			}
+		})
+	}
+}
+
+func TestSearchBudgetReserveConcurrently(t *testing.T) {
+	budget := searchBudget{
+		tags:   map[string]controllerModel.Tag{"dog": {Name: "dog", TargetCount: 10}},
+		counts: map[string]int{},
+		pages:  map[string]int{},
+	}
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	reserved := 0
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if budget.reserve("dog") {
+				mutex.Lock()
+				reserved++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if reserved != 10 || budget.counts["dog"] != 10 {
+		t.Errorf("%d reservations and a count of %d, want the target of 10", reserved, budget.counts["dog"])
+	}
+}
+
+func TestSearchBudgetTagPicture(t *testing.T) {
+	budget := searchBudget{canonicalNames: tagCanonicalNames([]controllerModel.Tag{{Name: "dog", Synonyms: []string{"puppy"}}}, -1)}
+	tests := []struct {
+		name     string
+		picture  controllerModel.Picture
+		wantTags int
+	}{
+		{name: "untagged", picture: taggedPicture("grass"), wantTags: 2},
+		{name: "already tagged", picture: taggedPicture("dog"), wantTags: 1},
+		{name: "tagged with a synonym", picture: taggedPicture("puppy"), wantTags: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			budget.tagPicture(&test.picture, "dog")
+			if len(test.picture.Tags) != test.wantTags {
+				t.Fatalf("tags = %+v, want %d", test.picture.Tags, test.wantTags)
+			}
+			if added := test.picture.Tags[len(test.picture.Tags)-1]; test.wantTags == 2 && (added.Name != "dog" || added.OriginName != test.picture.Origin) {
+				t.Errorf("tag added = %+v, want dog from %s", added, test.picture.Origin)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	interfaceAdapter "scraper-backend/src/adapter/interface"
+	interfaceDatabase "scraper-backend/src/driver/interface/database"
+	model "scraper-backend/src/driver/model"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+)
+
+// longest lease given to a user
+const taskLeaseMaximum = 8 * time.Hour
+
+type ControllerTask struct {
+	Dynamodb          interfaceDatabase.DriverDynamodbTask
+	ControllerPicture interfaceAdapter.ControllerPicture
+}
+
+// leases the next picture of the state to the user, nil when the queue is empty.
+// a user holds one lease per state, the running one is returned again.
+func (c ControllerTask) ReadTaskNext(ctx context.Context, state string, priority string, lease time.Duration, user string) (*controllerModel.Task, error) {
+	if state != controllerModel.PictureStateProcess && state != controllerModel.PictureStateValidation {
+		return nil, fmt.Errorf("state %s has no task, only %s and %s have", state, controllerModel.PictureStateProcess, controllerModel.PictureStateValidation)
+	}
+	if lease <= 0 || lease > taskLeaseMaximum {
+		return nil, fmt.Errorf("lease of %s is not between 0 and %s", lease, taskLeaseMaximum)
+	}
+	if user == "" || user == controllerModel.AuditActorUnknown {
+		return nil, fmt.Errorf("a task requires a user")
+	}
+
+	now := time.Now().UTC()
+	filter := expression.Name("State").Equal(expression.Value(state))
+	tasks, err := c.Dynamodb.ScanTasks(ctx, &filter)
+	if err != nil {
+		return nil, err
+	}
+	tasksByPicture := make(map[model.UUID]controllerModel.Task, len(tasks))
+	for _, task := range tasks {
+		if task.Status == controllerModel.TaskStatusLeased && task.User == user && now.Before(task.ExpirationDate) {
+			return &task, nil
+		}
+		tasksByPicture[task.ID] = task
+	}
+
+	pictures, err := c.ControllerPicture.ReadPictures(ctx, state, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	type candidate struct {
+		key   controllerModel.PictureKey
+		score float64
+	}
+	var candidates []candidate
+	available := make(map[model.UUID]bool, len(pictures))
+	for _, picture := range pictures {
+		task, ok := tasksByPicture[picture.ID]
+		available[picture.ID] = !ok || taskAvailable(task, picture, now)
+	}
+	if priority == controllerModel.TaskPriorityOldest {
+		sort.SliceStable(pictures, func(i, j int) bool { return pictures[i].CreationDate.Before(pictures[j].CreationDate) })
+		for _, picture := range pictures {
+			if available[picture.ID] {
+				candidates = append(candidates, candidate{key: controllerModel.PictureKey{Origin: picture.Origin, ID: picture.ID}})
+			}
+		}
+	} else {
+		// the near duplicates of the pictures ranked before come last
+		samples, err := c.ControllerPicture.RankPictures(ctx, priority, pictures, now.UnixNano())
+		if err != nil {
+			return nil, err
+		}
+		for _, sample := range samples {
+			if available[sample.ID] {
+				candidates = append(candidates, candidate{key: controllerModel.PictureKey{Origin: sample.Origin, ID: sample.ID}, score: sample.Score})
+			}
+		}
+	}
+
+	// another user may lease the same picture in the meantime, the next one is tried
+	for _, candidate := range candidates {
+		task, ok := tasksByPicture[candidate.key.ID]
+		if !ok {
+			task = controllerModel.Task{ID: candidate.key.ID, State: state}
+		}
+		task.Origin = candidate.key.Origin
+		task.User = user
+		task.Status = controllerModel.TaskStatusLeased
+		task.Priority = candidate.score
+		task.LeaseDate = now
+		task.ExpirationDate = now.Add(lease)
+		task.CompletionDate = time.Time{}
+		err := c.Dynamodb.CreateTask(ctx, task)
+		var conflictErr controllerModel.TaskConflictError
+		if errors.As(err, &conflictErr) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		task.Version++
+		return &task, nil
+	}
+	return nil, nil
+}
+
+// releases the lease of the user on the picture, an expired lease can be completed until someone else leases the picture
+func (c ControllerTask) CompleteTask(ctx context.Context, id model.UUID, user string) error {
+	if user == "" || user == controllerModel.AuditActorUnknown {
+		return fmt.Errorf("a task requires a user")
+	}
+	tasks, err := c.Dynamodb.ReadTasks(ctx, id)
+	if err != nil {
+		return err
+	}
+	var leased *controllerModel.Task
+	for i, task := range tasks {
+		if task.Status != controllerModel.TaskStatusLeased || task.User != user {
+			continue
+		}
+		if leased == nil || task.LeaseDate.After(leased.LeaseDate) {
+			leased = &tasks[i]
+		}
+	}
+	if leased == nil {
+		return fmt.Errorf("task %s is not leased by %s", id, user)
+	}
+	leased.Status = controllerModel.TaskStatusCompleted
+	leased.CompletionDate = time.Now().UTC()
+	return c.Dynamodb.CreateTask(ctx, *leased)
+}
+
+// throughput of every user, sorted by user
+func (c ControllerTask) ReadTaskStats(ctx context.Context, filter controllerModel.TaskFilter) ([]controllerModel.TaskStats, error) {
+	tasks, err := c.Dynamodb.ScanTasks(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	type userTasks struct {
+		stats    controllerModel.TaskStats
+		duration time.Duration
+		first    time.Time
+		last     time.Time
+	}
+	users := map[string]*userTasks{}
+	for _, task := range tasks {
+		current, ok := users[task.User]
+		if !ok {
+			current = &userTasks{stats: controllerModel.TaskStats{User: task.User}}
+			users[task.User] = current
+		}
+		switch task.Status {
+		case controllerModel.TaskStatusLeased:
+			if now.Before(task.ExpirationDate) {
+				current.stats.Leased++
+			} else {
+				current.stats.Expired++
+			}
+		case controllerModel.TaskStatusCompleted:
+			if !filter.From.IsZero() && task.CompletionDate.Before(filter.From) {
+				continue
+			}
+			if !filter.To.IsZero() && task.CompletionDate.After(filter.To) {
+				continue
+			}
+			current.stats.Completed++
+			current.duration += task.CompletionDate.Sub(task.LeaseDate)
+			if current.first.IsZero() || task.CompletionDate.Before(current.first) {
+				current.first = task.CompletionDate
+			}
+			if task.CompletionDate.After(current.last) {
+				current.last = task.CompletionDate
+			}
+		}
+	}
+
+	stats := make([]controllerModel.TaskStats, 0, len(users))
+	for _, current := range users {
+		if current.stats.Completed > 0 {
+			current.stats.MeanDuration = current.duration / time.Duration(current.stats.Completed)
+			from, to := current.first, current.last
+			if !filter.From.IsZero() {
+				from = filter.From
+				to = now
+			}
+			if !filter.To.IsZero() {
+				to = filter.To
+			}
+			if hours := to.Sub(from).Hours(); hours > 0 {
+				current.stats.PerHour = float64(current.stats.Completed) / hours
+			}
+		}
+		stats = append(stats, current.stats)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].User < stats[j].User })
+	return stats, nil
+}
+
+// an expired lease returns to the queue, so does a completed picture that came back to the state since
+func taskAvailable(task controllerModel.Task, picture controllerModel.Picture, now time.Time) bool {
+	switch task.Status {
+	case controllerModel.TaskStatusLeased:
+		return !now.Before(task.ExpirationDate)
+	case controllerModel.TaskStatusCompleted:
+		if len(picture.Transitions) == 0 {
+			return false
+		}
+		return picture.Transitions[len(picture.Transitions)-1].CreationDate.After(task.CompletionDate)
+	default:
+		return true
+	}
+}
@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"context"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	interfaceDatabase "scraper-backend/src/driver/interface/database"
+	model "scraper-backend/src/driver/model"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+)
+
+type taskKey struct {
+	id    model.UUID
+	state string
+}
+
+// tasks in memory, the writes are guarded by the version like in the table.
+// the first writes of the pictures in conflicts fail as if someone else had leased them in the meantime
+type fakeDynamodbTask struct {
+	interfaceDatabase.DriverDynamodbTask
+	tasks     map[taskKey]controllerModel.Task
+	conflicts map[model.UUID]bool
+}
+
+func (f *fakeDynamodbTask) CreateTask(ctx context.Context, task controllerModel.Task) error {
+	key := taskKey{id: task.ID, state: task.State}
+	current, ok := f.tasks[key]
+	if f.conflicts[task.ID] || (ok && current.Version != task.Version) {
+		delete(f.conflicts, task.ID)
+		return controllerModel.TaskConflictError{ID: task.ID, State: task.State}
+	}
+	task.Version++
+	f.tasks[key] = task
+	return nil
+}
+
+func (f *fakeDynamodbTask) ReadTasks(ctx context.Context, primaryKey model.UUID) ([]controllerModel.Task, error) {
+	var tasks []controllerModel.Task
+	for key, task := range f.tasks {
+		if key.id == primaryKey {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+// the state filter is the only one used by the queue
+func (f *fakeDynamodbTask) ScanTasks(ctx context.Context, filter *expression.ConditionBuilder) ([]controllerModel.Task, error) {
+	var tasks []controllerModel.Task
+	for _, task := range f.tasks {
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func TestReadTaskNext(t *testing.T) {
+	now := time.Now().UTC()
+	pictures := make([]controllerModel.Picture, 3)
+	for i := range pictures {
+		pictures[i] = controllerModel.Picture{Origin: "pexels", ID: model.NewUUID(), CreationDate: now.Add(time.Duration(i) * time.Hour)}
+	}
+	// the second picture came back to process after it was completed
+	pictures[1].Transitions = []controllerModel.PictureTransition{{To: controllerModel.PictureStateProcess, CreationDate: now.Add(-time.Hour)}}
+	state := controllerModel.PictureStateProcess
+	leased := func(picture controllerModel.Picture, user string, expiration time.Time) controllerModel.Task {
+		return controllerModel.Task{ID: picture.ID, State: state, User: user, Status: controllerModel.TaskStatusLeased, LeaseDate: expiration.Add(-time.Hour), ExpirationDate: expiration, Version: 1}
+	}
+	completed := func(picture controllerModel.Picture, completion time.Time) controllerModel.Task {
+		return controllerModel.Task{ID: picture.ID, State: state, User: "bob", Status: controllerModel.TaskStatusCompleted, CompletionDate: completion, Version: 2}
+	}
+
+	tests := []struct {
+		name      string
+		tasks     []controllerModel.Task
+		conflicts []model.UUID
+		want      int // index of the picture leased, -1 for none
+	}{
+		{name: "oldest first", want: 0},
+		{name: "running lease of the user returned again", tasks: []controllerModel.Task{leased(pictures[2], "alice", now.Add(time.Hour))}, want: 2},
+		{name: "lease of another user skipped", tasks: []controllerModel.Task{leased(pictures[0], "bob", now.Add(time.Hour))}, want: 1},
+		{name: "expired lease back in the queue", tasks: []controllerModel.Task{leased(pictures[0], "bob", now.Add(-time.Minute))}, want: 0},
+		{name: "completed picture skipped", tasks: []controllerModel.Task{completed(pictures[0], now.Add(-time.Hour))}, want: 1},
+		{
+			name:  "completed picture back in the state since",
+			tasks: []controllerModel.Task{completed(pictures[0], now), completed(pictures[1], now.Add(-2*time.Hour))},
+			want:  1,
+		},
+		{name: "leased by someone else in the meantime", conflicts: []model.UUID{pictures[0].ID}, want: 1},
+		{
+			name: "empty queue",
+			tasks: []controllerModel.Task{
+				completed(pictures[0], now),
+				leased(pictures[1], "bob", now.Add(time.Hour)),
+				leased(pictures[2], "carol", now.Add(time.Hour)),
+			},
+			want: -1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dynamodb := &fakeDynamodbTask{tasks: map[taskKey]controllerModel.Task{}, conflicts: map[model.UUID]bool{}}
+			for _, task := range test.tasks {
+				dynamodb.tasks[taskKey{id: task.ID, state: task.State}] = task
+			}
+			for _, id := range test.conflicts {
+				dynamodb.conflicts[id] = true
+			}
+			c := ControllerTask{Dynamodb: dynamodb, ControllerPicture: &fakeControllerPictureStates{pictures: map[string][]controllerModel.Picture{state: pictures}}}
+
+			task, err := c.ReadTaskNext(context.Background(), state, controllerModel.TaskPriorityOldest, time.Hour, "alice")
+			if err != nil {
+				t.Fatalf("ReadTaskNext() error = %v", err)
+			}
+			if test.want == -1 {
+				if task != nil {
+					t.Errorf("ReadTaskNext() = %+v, want none", task)
+				}
+				return
+			}
+			if task == nil || task.ID != pictures[test.want].ID {
+				t.Fatalf("ReadTaskNext() = %+v, want the picture %d", task, test.want)
+			}
+			stored := dynamodb.tasks[taskKey{id: task.ID, state: state}]
+			if stored.User != "alice" || stored.Status != controllerModel.TaskStatusLeased || !now.Before(stored.ExpirationDate) || stored.Version != task.Version {
+				t.Errorf("stored task = %+v, want leased by alice at version %d", stored, task.Version)
+			}
+		})
+	}
+}
+
+func TestReadTaskNextArguments(t *testing.T) {
+	c := ControllerTask{}
+	tests := []struct {
+		name  string
+		state string
+		lease time.Duration
+		user  string
+	}{
+		{name: "production has no task", state: controllerModel.PictureStateProduction, lease: time.Hour, user: "alice"},
+		{name: "lease too long", state: controllerModel.PictureStateProcess, lease: taskLeaseMaximum + time.Minute, user: "alice"},
+		{name: "no lease", state: controllerModel.PictureStateProcess, user: "alice"},
+		{name: "unknown user", state: controllerModel.PictureStateProcess, lease: time.Hour, user: controllerModel.AuditActorUnknown},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := c.ReadTaskNext(context.Background(), test.state, controllerModel.TaskPriorityOldest, test.lease, test.user); err == nil {
+				t.Errorf("ReadTaskNext() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestCompleteTask(t *testing.T) {
+	id := model.NewUUID()
+	now := time.Now().UTC()
+	tests := []struct {
+		name    string
+		tasks   []controllerModel.Task
+		user    string
+		wantErr bool
+		want    string // state of the task completed
+	}{
+		{
+			name:  "leased",
+			tasks: []controllerModel.Task{{ID: id, State: controllerModel.PictureStateProcess, User: "alice", Status: controllerModel.TaskStatusLeased, LeaseDate: now}},
+			user:  "alice",
+			want:  controllerModel.PictureStateProcess,
+		},
+		{
+			name:  "expired but not leased again",
+			tasks: []controllerModel.Task{{ID: id, State: controllerModel.PictureStateProcess, User: "alice", Status: controllerModel.TaskStatusLeased, LeaseDate: now.Add(-2 * time.Hour), ExpirationDate: now.Add(-time.Hour)}},
+			user:  "alice",
+			want:  controllerModel.PictureStateProcess,
+		},
+		{
+			name: "latest lease of the user",
+			tasks: []controllerModel.Task{
+				{ID: id, State: controllerModel.PictureStateProcess, User: "alice", Status: controllerModel.TaskStatusLeased, LeaseDate: now.Add(-time.Hour)},
+				{ID: id, State: controllerModel.PictureStateValidation, User: "alice", Status: controllerModel.TaskStatusLeased, LeaseDate: now},
+			},
+			user: "alice",
+			want: controllerModel.PictureStateValidation,
+		},
+		{
+			name:    "leased by someone else",
+			tasks:   []controllerModel.Task{{ID: id, State: controllerModel.PictureStateProcess, User: "bob", Status: controllerModel.TaskStatusLeased, LeaseDate: now}},
+			user:    "alice",
+			wantErr: true,
+		},
+		{
+			name:    "already completed",
+			tasks:   []controllerModel.Task{{ID: id, State: controllerModel.PictureStateProcess, User: "alice", Status: controllerModel.TaskStatusCompleted, LeaseDate: now}},
+			user:    "alice",
+			wantErr: true,
+		},
+		{name: "without user", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dynamodb := &fakeDynamodbTask{tasks: map[taskKey]controllerModel.Task{}}
+			for _, task := range test.tasks {
+				dynamodb.tasks[taskKey{id: task.ID, state: task.State}] = task
+			}
+			c := ControllerTask{Dynamodb: dynamodb}
+
+			err := c.CompleteTask(context.Background(), id, test.user)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("CompleteTask() error = %v, want an error %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			task := dynamodb.tasks[taskKey{id: id, state: test.want}]
+			if task.Status != controllerModel.TaskStatusCompleted || task.CompletionDate.IsZero() || task.Version != 1 {
+				t.Errorf("task in %s = %+v, want completed", test.want, task)
+			}
+		})
+	}
+}
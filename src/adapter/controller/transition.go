@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// allowed transitions between the states of a picture
+var pictureTransitions = map[string][]string{
+	controllerModel.PictureStateProcess:    {controllerModel.PictureStateValidation, controllerModel.PictureStateBlocked},
+	controllerModel.PictureStateValidation: {controllerModel.PictureStateProcess, controllerModel.PictureStateProduction, controllerModel.PictureStateBlocked},
+	controllerModel.PictureStateProduction: {controllerModel.PictureStateValidation},
+	controllerModel.PictureStateBlocked:    {controllerModel.PictureStateProcess},
+}
+
+func checkPictureTransition(transition controllerModel.PictureTransition) error {
+	states, ok := pictureTransitions[transition.From]
+	if !ok {
+		return fmt.Errorf("state %s not available", transition.From)
+	}
+	if !slices.Contains(states, transition.To) {
+		return fmt.Errorf("transition from %s to %s is not allowed, %s can only go to %v", transition.From, transition.To, transition.From, states)
+	}
+	if transition.Actor == "" {
+		return fmt.Errorf("transition from %s to %s requires an actor", transition.From, transition.To)
+	}
+	if transition.Reason == "" {
+		return fmt.Errorf("transition from %s to %s requires a reason", transition.From, transition.To)
+	}
+	return nil
+}
+
+// the validators are only skipped by the admins, the actor of the request must be the one of the transition
+func (c ControllerPicture) checkPictureOverride(ctx context.Context, transition controllerModel.PictureTransition) error {
+	if !transition.Override {
+		return nil
+	}
+	actor := controllerModel.ContextActor(ctx)
+	if !slices.Contains(c.Admins, actor) {
+		return controllerModel.OverrideForbiddenError{Actor: actor}
+	}
+	if transition.Actor != actor {
+		return controllerModel.OverrideForbiddenError{Actor: actor, TransitionActor: transition.Actor}
+	}
+	return nil
+}
+
+// moves a picture from one state table to another and appends the transition to its history.
+// the copy is written with the audit entry of the action, removing it again is audited as a rollback
+func (c ControllerPicture) transferPicture(ctx context.Context, action string, primaryKey string, sortKey model.UUID, version int, transition controllerModel.PictureTransition) error {
+	if err := checkPictureTransition(transition); err != nil {
+		return err
+	}
+	if err := c.checkPictureOverride(ctx, transition); err != nil {
+		return err
+	}
+	original, err := c.readPicture(ctx, transition.From, primaryKey, sortKey)
+	if err != nil {
+		return err
+	}
+	picture, err := c.preparePictureTransfer(ctx, *original, transition)
+	if err != nil {
+		return err
+	}
+	if picture.Version != version {
+		return controllerModel.PictureConflictError{Origin: primaryKey, ID: sortKey, Version: picture.Version}
+	}
+
+	fromDynamodb, err := c.driverDynamodbMap(transition.From)
+	if err != nil {
+		return err
+	}
+	toDynamodb, err := c.driverDynamodbMap(transition.To)
+	if err != nil {
+		return err
+	}
+
+	before, after := pictureTransferDiff(*picture)
+	audit, err := newPictureAudit(ctx, action, primaryKey, sortKey, before, after)
+	if err != nil {
+		return err
+	}
+	if err := toDynamodb.CreatePicture(ctx, picture.ID, *picture, audit); err != nil {
+		return err
+	}
+
+	if err := fromDynamodb.DeletePicture(ctx, primaryKey, sortKey, picture.Version, nil); err != nil {
+		return compensate(err, func() error {
+			rollback, err := newPictureAudit(ctx, action+"Rollback", primaryKey, sortKey, after, before)
+			if err != nil {
+				return err
+			}
+			// the copy was written with the next version
+			return toDynamodb.DeletePicture(ctx, primaryKey, sortKey, after.Version, rollback)
+		})
+	}
+	return nil
+}
+
+// returns a copy of the picture read from the table of the transition with the transition appended, nothing is written
+func (c ControllerPicture) preparePictureTransfer(ctx context.Context, original controllerModel.Picture, transition controllerModel.PictureTransition) (*controllerModel.Picture, error) {
+	picture := original
+	picture.Transitions = slices.Clone(original.Transitions)
+	if transition.From == controllerModel.PictureStateValidation && transition.To == controllerModel.PictureStateProduction {
+		// the tags of the picture in production are the ones accepted by the review, the predictions are kept to be evaluated
+		if annotationSet, ok := acceptedAnnotationSet(picture); ok {
+			picture.Tags = annotationSet.Tags
+		}
+		if !transition.Override {
+			// the predictions are not reviewed, a predicted box alone must not pass the validators
+			validated := picture
+			validated.Tags = nil
+			for _, tag := range picture.Tags {
+				if !predictedTag(tag) {
+					validated.Tags = append(validated.Tags, tag)
+				}
+			}
+			if err := c.validatePicture(ctx, validated); err != nil {
+				return nil, err
+			}
+		}
+		picture.Tags = append(slices.Clone(picture.Tags), missingPredictedTags(picture.Tags, original.Tags)...)
+	}
+
+	transition.ID = model.NewUUID()
+	transition.CreationDate = time.Now()
+	picture.Transitions = append(picture.Transitions, transition)
+	return &picture, nil
+}
+
+// the predictions of the previous tags absent from the new ones
+func missingPredictedTags(tags, previousTags []controllerModel.PictureTag) []controllerModel.PictureTag {
+	var missing []controllerModel.PictureTag
+	for _, tag := range previousTags {
+		if predictedTag(tag) && slices.IndexFunc(tags, func(t controllerModel.PictureTag) bool { return t.ID == tag.ID }) == -1 {
+			missing = append(missing, tag)
+		}
+	}
+	return missing
+}
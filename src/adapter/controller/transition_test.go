@@ -0,0 +1,348 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	interfaceDatabase "scraper-backend/src/driver/interface/database"
+	model "scraper-backend/src/driver/model"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"golang.org/x/exp/slices"
+)
+
+// pictures of one table in memory, the single writes are guarded by the version like in the table
+type fakeDynamodbPicture struct {
+	interfaceDatabase.DriverDynamodbPicture
+	pictures  []controllerModel.Picture
+	audits    []controllerModel.Audit
+	deleteErr error
+}
+
+func (f *fakeDynamodbPicture) index(sortKey model.UUID) int {
+	return slices.IndexFunc(f.pictures, func(picture controllerModel.Picture) bool { return picture.ID == sortKey })
+}
+
+func (f *fakeDynamodbPicture) audit(audit *controllerModel.Audit) {
+	if audit != nil {
+		f.audits = append(f.audits, *audit)
+	}
+}
+
+func (f *fakeDynamodbPicture) conflict(primaryKey string, sortKey model.UUID, version int) error {
+	idx := f.index(sortKey)
+	if idx == -1 {
+		if version == 0 {
+			return nil
+		}
+		return controllerModel.PictureConflictError{Origin: primaryKey, ID: sortKey}
+	}
+	if f.pictures[idx].Version != version {
+		return controllerModel.PictureConflictError{Origin: primaryKey, ID: sortKey, Version: f.pictures[idx].Version}
+	}
+	return nil
+}
+
+func (f *fakeDynamodbPicture) ReadPicture(ctx context.Context, primaryKey string, sortKey model.UUID) (*controllerModel.Picture, error) {
+	idx := f.index(sortKey)
+	if idx == -1 {
+		return nil, nil
+	}
+	picture := f.pictures[idx]
+	return &picture, nil
+}
+
+func (f *fakeDynamodbPicture) ReadPictures(ctx context.Context, projection *expression.ProjectionBuilder, filter *expression.ConditionBuilder) ([]controllerModel.Picture, error) {
+	return slices.Clone(f.pictures), nil
+}
+
+func (f *fakeDynamodbPicture) CreatePicture(ctx context.Context, id model.UUID, picture controllerModel.Picture, audit *controllerModel.Audit) error {
+	idx := f.index(id)
+	if idx != -1 && f.pictures[idx].Version != picture.Version {
+		return controllerModel.PictureConflictError{Origin: picture.Origin, ID: id, Version: f.pictures[idx].Version}
+	}
+	picture.ID = id
+	picture.Version++
+	if idx == -1 {
+		f.pictures = append(f.pictures, picture)
+	} else {
+		f.pictures[idx] = picture
+	}
+	f.audit(audit)
+	return nil
+}
+
+func (f *fakeDynamodbPicture) DeletePicture(ctx context.Context, primaryKey string, sortKey model.UUID, version int, audit *controllerModel.Audit) error {
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	if err := f.conflict(primaryKey, sortKey, version); err != nil {
+		return err
+	}
+	if idx := f.index(sortKey); idx != -1 {
+		f.pictures = slices.Delete(f.pictures, idx, idx+1)
+	}
+	f.audit(audit)
+	return nil
+}
+
+func (f *fakeDynamodbPicture) CreatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tag controllerModel.PictureTag, audit *controllerModel.Audit) error {
+	if err := f.conflict(primaryKey, sortKey, version); err != nil {
+		return err
+	}
+	idx := f.index(sortKey)
+	f.pictures[idx].Tags = append(f.pictures[idx].Tags, tag)
+	f.pictures[idx].Version++
+	f.audit(audit)
+	return nil
+}
+
+var (
+	transitionSize = controllerModel.PictureSize{ID: model.NewUUID(), Box: controllerModel.Box{Width: 400, Height: 300}}
+	drawnBoxTag    = controllerModel.PictureTag{
+		ID:   model.NewUUID(),
+		Name: "dog",
+		BoxInformation: model.NewNullable(controllerModel.BoxInformation{
+			PictureSizeID: transitionSize.ID,
+			Box:           controllerModel.Box{Tlx: 10, Tly: 10, Width: 100, Height: 100},
+		}),
+	}
+	predictedBoxTag = controllerModel.PictureTag{
+		ID:   model.NewUUID(),
+		Name: "dog",
+		BoxInformation: model.NewNullable(controllerModel.BoxInformation{
+			Model:         sql.NullString{String: "yolov5", Valid: true},
+			Weights:       sql.NullString{String: "v5s", Valid: true},
+			PictureSizeID: transitionSize.ID,
+			Box:           controllerModel.Box{Tlx: 20, Tly: 20, Width: 100, Height: 100},
+			Confidence:    sql.NullFloat64{Float64: 0.9, Valid: true},
+		}),
+	}
+	labelTag = controllerModel.PictureTag{ID: model.NewUUID(), Name: "dog"}
+)
+
+func TestCheckPictureTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		actor   string
+		reason  string
+		wantErr bool
+	}{
+		{name: "process to validation", from: controllerModel.PictureStateProcess, to: controllerModel.PictureStateValidation, actor: "alice", reason: "labeled"},
+		{name: "validation to production", from: controllerModel.PictureStateValidation, to: controllerModel.PictureStateProduction, actor: "alice", reason: "reviewed"},
+		{name: "validation back to process", from: controllerModel.PictureStateValidation, to: controllerModel.PictureStateProcess, actor: "alice", reason: "wrong box"},
+		{name: "production back to validation", from: controllerModel.PictureStateProduction, to: controllerModel.PictureStateValidation, actor: "alice", reason: "wrong box"},
+		{name: "blocked to process", from: controllerModel.PictureStateBlocked, to: controllerModel.PictureStateProcess, actor: "alice", reason: "unblocked"},
+		{name: "process to production skips validation", from: controllerModel.PictureStateProcess, to: controllerModel.PictureStateProduction, actor: "alice", reason: "labeled", wantErr: true},
+		{name: "production to blocked", from: controllerModel.PictureStateProduction, to: controllerModel.PictureStateBlocked, actor: "alice", reason: "nudity", wantErr: true},
+		{name: "blocked to validation", from: controllerModel.PictureStateBlocked, to: controllerModel.PictureStateValidation, actor: "alice", reason: "unblocked", wantErr: true},
+		{name: "same state", from: controllerModel.PictureStateProcess, to: controllerModel.PictureStateProcess, actor: "alice", reason: "labeled", wantErr: true},
+		{name: "unknown state", from: "archive", to: controllerModel.PictureStateProcess, actor: "alice", reason: "restored", wantErr: true},
+		{name: "without actor", from: controllerModel.PictureStateProcess, to: controllerModel.PictureStateValidation, reason: "labeled", wantErr: true},
+		{name: "without reason", from: controllerModel.PictureStateProcess, to: controllerModel.PictureStateValidation, actor: "alice", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkPictureTransition(controllerModel.PictureTransition{From: test.from, To: test.to, Actor: test.actor, Reason: test.reason})
+			if (err != nil) != test.wantErr {
+				t.Errorf("checkPictureTransition() error = %v, want an error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckPictureOverride(t *testing.T) {
+	tests := []struct {
+		name            string
+		contextActor    string
+		actor           string
+		override        bool
+		wantErr         bool
+		wantErrorActor  string
+		wantErrorTarget string
+	}{
+		{name: "without override", contextActor: "bob", actor: "bob"},
+		{name: "admin", contextActor: "alice", actor: "alice", override: true},
+		{name: "not an admin", contextActor: "bob", actor: "bob", override: true, wantErr: true, wantErrorActor: "bob"},
+		{name: "without actor", actor: "alice", override: true, wantErr: true, wantErrorActor: controllerModel.AuditActorUnknown},
+		{name: "admin recording another actor", contextActor: "alice", actor: "bob", override: true, wantErr: true, wantErrorActor: "alice", wantErrorTarget: "bob"},
+	}
+	c := ControllerPicture{Admins: []string{"alice"}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.contextActor != "" {
+				ctx = controllerModel.ContextWithActor(ctx, test.contextActor)
+			}
+			transition := controllerModel.PictureTransition{
+				From:     controllerModel.PictureStateValidation,
+				To:       controllerModel.PictureStateProduction,
+				Actor:    test.actor,
+				Reason:   "reviewed",
+				Override: test.override,
+			}
+			err := c.checkPictureOverride(ctx, transition)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("checkPictureOverride() error = %v, want an error %v", err, test.wantErr)
+			}
+			if !test.wantErr {
+				return
+			}
+			var overrideErr controllerModel.OverrideForbiddenError
+			if !errors.As(err, &overrideErr) {
+				t.Fatalf("checkPictureOverride() error = %v, want an OverrideForbiddenError", err)
+			}
+			if overrideErr.Actor != test.wantErrorActor || overrideErr.TransitionActor != test.wantErrorTarget {
+				t.Errorf("checkPictureOverride() error = %+v, want actor %s and transition actor %s", overrideErr, test.wantErrorActor, test.wantErrorTarget)
+			}
+		})
+	}
+}
+
+func TestPreparePictureTransfer(t *testing.T) {
+	promotion := controllerModel.PictureTransition{From: controllerModel.PictureStateValidation, To: controllerModel.PictureStateProduction, Actor: "alice", Reason: "reviewed"}
+	override := promotion
+	override.Override = true
+	labeling := controllerModel.PictureTransition{From: controllerModel.PictureStateProcess, To: controllerModel.PictureStateValidation, Actor: "alice", Reason: "labeled"}
+	acceptedSet := controllerModel.AnnotationSet{ID: model.NewUUID(), Annotator: "bob", Status: controllerModel.AnnotationSetAccepted, Tags: []controllerModel.PictureTag{drawnBoxTag}}
+
+	tests := []struct {
+		name       string
+		tags       []controllerModel.PictureTag
+		sets       []controllerModel.AnnotationSet
+		transition controllerModel.PictureTransition
+		wantErr    bool
+		wantTags   []model.UUID
+	}{
+		{
+			name:       "drawn box",
+			tags:       []controllerModel.PictureTag{drawnBoxTag, predictedBoxTag},
+			transition: promotion,
+			wantTags:   []model.UUID{drawnBoxTag.ID, predictedBoxTag.ID},
+		},
+		{
+			name:       "predicted box only",
+			tags:       []controllerModel.PictureTag{labelTag, predictedBoxTag},
+			transition: promotion,
+			wantErr:    true,
+		},
+		{
+			name:       "predicted box only overridden by an admin",
+			tags:       []controllerModel.PictureTag{labelTag, predictedBoxTag},
+			transition: override,
+			wantTags:   []model.UUID{labelTag.ID, predictedBoxTag.ID},
+		},
+		{
+			name:       "accepted set replaces the tags and keeps the predictions",
+			tags:       []controllerModel.PictureTag{labelTag, predictedBoxTag},
+			sets:       []controllerModel.AnnotationSet{acceptedSet},
+			transition: promotion,
+			wantTags:   []model.UUID{drawnBoxTag.ID, predictedBoxTag.ID},
+		},
+		{
+			name:       "only the promotion is validated",
+			tags:       []controllerModel.PictureTag{labelTag},
+			transition: labeling,
+			wantTags:   []model.UUID{labelTag.ID},
+		},
+	}
+	c := ControllerPicture{Validators: []PictureValidator{ValidatorBoxRequired{}}, Admins: []string{"alice"}}
+	ctx := controllerModel.ContextWithActor(context.Background(), "alice")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			original := controllerModel.Picture{
+				Origin:         "pexels",
+				ID:             model.NewUUID(),
+				Sizes:          []controllerModel.PictureSize{transitionSize},
+				Tags:           test.tags,
+				AnnotationSets: test.sets,
+				Version:        2,
+			}
+			picture, err := c.preparePictureTransfer(ctx, original, test.transition)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("preparePictureTransfer() error = %v, want an error %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				var validationErrors controllerModel.ValidationErrors
+				if !errors.As(err, &validationErrors) {
+					t.Errorf("preparePictureTransfer() error = %v, want ValidationErrors", err)
+				}
+				return
+			}
+			var tagIDs []model.UUID
+			for _, tag := range picture.Tags {
+				tagIDs = append(tagIDs, tag.ID)
+			}
+			if !slices.Equal(tagIDs, test.wantTags) {
+				t.Errorf("tags = %v, want %v", tagIDs, test.wantTags)
+			}
+			if len(picture.Transitions) != 1 || picture.Transitions[0].To != test.transition.To || picture.Transitions[0].ID == (model.UUID{}) {
+				t.Errorf("transitions = %+v, want the transition to %s", picture.Transitions, test.transition.To)
+			}
+			if len(original.Transitions) != 0 {
+				t.Errorf("the transitions of the original were changed: %+v", original.Transitions)
+			}
+		})
+	}
+}
+
+func TestTransferPicture(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     int
+		deleteErr   error
+		wantErr     bool
+		wantVersion int // version of the conflict
+		wantMoved   bool
+		wantActions []string // audit entries of the destination
+	}{
+		{name: "moved", version: 3, wantMoved: true, wantActions: []string{"UpdatePictureTransfer"}},
+		{name: "stale version", version: 2, wantErr: true, wantVersion: 3},
+		{name: "source not deleted", version: 3, deleteErr: errors.New("delete failed"), wantErr: true, wantActions: []string{"UpdatePictureTransfer", "UpdatePictureTransferRollback"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			picture := controllerModel.Picture{Origin: "pexels", ID: model.NewUUID(), Tags: []controllerModel.PictureTag{labelTag}, Version: 3}
+			process := &fakeDynamodbPicture{pictures: []controllerModel.Picture{picture}, deleteErr: test.deleteErr}
+			validation := &fakeDynamodbPicture{}
+			c := ControllerPicture{DynamodbProcess: process, DynamodbValidation: validation}
+			ctx := controllerModel.ContextWithActor(context.Background(), "alice")
+			transition := controllerModel.PictureTransition{From: controllerModel.PictureStateProcess, To: controllerModel.PictureStateValidation, Actor: "alice", Reason: "labeled"}
+
+			err := c.UpdatePictureTransfer(ctx, picture.Origin, picture.ID, test.version, transition)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("UpdatePictureTransfer() error = %v, want an error %v", err, test.wantErr)
+			}
+			var conflictErr controllerModel.PictureConflictError
+			if errors.As(err, &conflictErr) != (test.wantVersion != 0) || conflictErr.Version != test.wantVersion {
+				t.Errorf("UpdatePictureTransfer() error = %v, want a conflict at version %d", err, test.wantVersion)
+			}
+			if moved := len(process.pictures) == 0 && len(validation.pictures) == 1; moved != test.wantMoved {
+				t.Fatalf("%d pictures in process and %d in validation, want moved %v", len(process.pictures), len(validation.pictures), test.wantMoved)
+			}
+			if test.wantMoved {
+				stored := validation.pictures[0]
+				if stored.Version != picture.Version+1 {
+					t.Errorf("copy version = %d, want %d", stored.Version, picture.Version+1)
+				}
+				if len(stored.Transitions) != 1 || stored.Transitions[0].From != transition.From || stored.Transitions[0].To != transition.To {
+					t.Errorf("copy transitions = %+v, want the transition", stored.Transitions)
+				}
+			}
+			var actions []string
+			for _, audit := range validation.audits {
+				actions = append(actions, audit.Action)
+				if audit.Actor != "alice" {
+					t.Errorf("audit %s actor = %s, want alice", audit.Action, audit.Actor)
+				}
+			}
+			if !slices.Equal(actions, test.wantActions) {
+				t.Errorf("audit actions = %v, want %v", actions, test.wantActions)
+			}
+		})
+	}
+}
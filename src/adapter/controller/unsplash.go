@@ -2,24 +2,21 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"golang.org/x/exp/slices"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	typeUnsplash "github.com/hbagdi/go-unsplash/unsplash"
 
 	"strings"
 
-	"strconv"
-
 	controllerModel "scraper-backend/src/adapter/controller/model"
 	interfaceAdapter "scraper-backend/src/adapter/interface"
 	interfaceHost "scraper-backend/src/driver/interface/host"
 	model "scraper-backend/src/driver/model"
-	"scraper-backend/src/util"
 )
 
 type ControllerUnsplash struct {
@@ -40,7 +37,10 @@ type InputImage struct {
 	Photo       typeUnsplash.Photo
 	Origin      string
 	Quality     string
-	BlockedTags []controllerModel.Tag
+	Policy      *blockPolicy
+	Users       *userPolicy
+	SearchedTag string
+	Budget      *searchBudget
 }
 
 type OutputPage struct {
@@ -51,7 +51,10 @@ type OutputPage struct {
 type InputPage struct {
 	Origin      string
 	Quality     string
-	BlockedTags []controllerModel.Tag
+	Policy      *blockPolicy
+	Users       *userPolicy
+	SearchedTag string
+	Budget      *searchBudget
 	Pictures    []typeUnsplash.Photo
 }
 
@@ -78,13 +81,37 @@ func (c *ControllerUnsplash) SearchPhotos(ctx context.Context, quality string, i
 		return nil, fmt.Errorf("only one searched tag is allowed")
 	}
 
-	blockedTags, err := c.ControllerTag.ReadTags(ctx, "blocked")
+	policy, err := readBlockPolicy(ctx, c.ControllerTag, c.ControllerUser)
+	if err != nil {
+		return nil, err
+	}
+	users, err := readUserPolicy(ctx, c.ControllerPicture, c.ControllerUser, searchedTags)
+	if err != nil {
+		return nil, err
+	}
+	budget, err := readSearchBudget(ctx, c.ControllerPicture, searchedTags)
 	if err != nil {
 		return nil, err
 	}
 
+	// the synonyms are searched in the same range as the name
+	originIDs := []string{}
+	for _, searchedName := range tagNames(searchedTags[0]) {
+		if budget.exhausted(searchedTags[0].Name) {
+			break // enough pictures or pages of the tag
+		}
+		searchedIDs, err := c.searchPhotosName(ctx, searchedName, searchedTags[0].Name, origin, quality, imageStart, imageEnd, policy, users, budget)
+		if err != nil {
+			return nil, fmt.Errorf("search of `%s` has failed: %v", searchedName, err)
+		}
+		originIDs = append(originIDs, searchedIDs...)
+	}
+	return originIDs, nil
+}
+
+func (c *ControllerUnsplash) searchPhotosName(ctx context.Context, searchedName, searchedTag, origin, quality string, imageStart, imageEnd int, policy *blockPolicy, users *userPolicy, budget *searchBudget) ([]string, error) {
 	perPage := c.Api.GetPerPage()
-	searchPerPage, err := c.Api.SearchPhotosPerPage(searchedTags[0].Name, 0)
+	searchPerPage, err := c.Api.SearchPhotosPerPage(searchedName, 0)
 	if err != nil {
 		return nil, fmt.Errorf("searchPhotosPerPageUnsplash has failed: %v", err)
 	}
@@ -117,9 +144,10 @@ func (c *ControllerUnsplash) SearchPhotos(ctx context.Context, quality string, i
 		}()
 	}
 
-	// Send the inputs to the worker goroutines
-	for page := pageFrom; page <= pageTo; page++ {
-		searchPerPage, err := c.Api.SearchPhotosPerPage(searchedTags[0].Name, page)
+	// Send the inputs to the worker goroutines until the tag has enough pictures or pages
+	sent := 0
+	for page := pageFrom; page <= pageTo && budget.page(searchedTag); page++ {
+		searchPerPage, err := c.Api.SearchPhotosPerPage(searchedName, page)
 		if err != nil {
 			return nil, fmt.Errorf("searchPhotosPerPageUnsplash has failed: %v", err)
 		}
@@ -137,16 +165,20 @@ func (c *ControllerUnsplash) SearchPhotos(ctx context.Context, quality string, i
 		inputsPage <- InputPage{
 			Origin:      origin,
 			Quality:     quality,
-			BlockedTags: blockedTags,
+			Policy:      policy,
+			Users:       users,
+			SearchedTag: searchedTag,
+			Budget:      budget,
 			Pictures:    pictures,
 		}
+		sent++
 		fmt.Printf("page %v, with %v pictures\n", page, len(pictures))
 	}
 	close(inputsPage)
 
 	OriginIDs := []string{}
 	// Read the outputs from the output channel
-	for page := pageFrom; page <= pageTo; page++ {
+	for i := 0; i < sent; i++ {
 		outputPage := <-outputsPage
 		if outputPage.Error != nil {
 			return nil, outputPage.Error
@@ -162,7 +194,10 @@ func (c *ControllerUnsplash) fetchPage(ctx context.Context, inputPage InputPage,
 	pictures := inputPage.Pictures
 	origin := inputPage.Origin
 	quality := inputPage.Quality
-	blockedTags := inputPage.BlockedTags
+	policy := inputPage.Policy
+	users := inputPage.Users
+	searchedTag := inputPage.SearchedTag
+	budget := inputPage.Budget
 
 	// Init waitgroup variables
 	var wgImage sync.WaitGroup // synchronize all channels
@@ -186,7 +221,10 @@ func (c *ControllerUnsplash) fetchPage(ctx context.Context, inputPage InputPage,
 			Photo:       photo,
 			Origin:      origin,
 			Quality:     quality,
-			BlockedTags: blockedTags,
+			Policy:      policy,
+			Users:       users,
+			SearchedTag: searchedTag,
+			Budget:      budget,
 		}
 	}
 	close(inputsImage)
@@ -215,7 +253,10 @@ func (c *ControllerUnsplash) fetchImage(ctx context.Context, inputImage InputIma
 	photo := inputImage.Photo
 	origin := inputImage.Origin
 	quality := inputImage.Quality
-	blockedTags := inputImage.BlockedTags
+	policy := inputImage.Policy
+	users := inputImage.Users
+	searchedTag := inputImage.SearchedTag
+	budget := inputImage.Budget
 
 	// look for existing image
 	var originID string
@@ -223,39 +264,17 @@ func (c *ControllerUnsplash) fetchImage(ctx context.Context, inputImage InputIma
 		originID = *photo.ID
 	}
 
-	// look for existing images
-	for _, state := range []string{"production", "validation", "process", "blocked"} {
-		projEx := expression.NamesList(expression.Name("OriginID"))
-		filtEx := expression.Name("OriginID").Equal(expression.Value(photo.ID))
-		pictures, err := c.ControllerPicture.ReadPictures(ctx, state, &projEx, &filtEx)
-		if err != nil {
-			outputImage <- OutputImage{
-				OriginID: &originID,
-				Error:    err,
-			}
-			wgImage.Done()
-			return
-		}
-		if len(pictures) > 0 {
-			outputImage <- OutputImage{
-				OriginID: nil,
-				Error:    nil,
-			}
-			wgImage.Done()
-			return // skip existing image
+	if budget.full(searchedTag) {
+		outputImage <- OutputImage{
+			OriginID: nil,
+			Error:    nil,
 		}
+		wgImage.Done()
+		return // enough pictures of the tag
 	}
 
-	// look for unwanted user
-	var UserID string
-	if photo.Photographer.ID != nil {
-		UserID = *photo.Photographer.ID
-	}
-	var userName string
-	if photo.Photographer.Username != nil {
-		userName = *photo.Photographer.Username
-	}
-	users, err := c.ControllerUser.ReadUsers(ctx)
+	// look for existing images
+	exists, err := pictureExists(ctx, c.ControllerPicture, originID)
 	if err != nil {
 		outputImage <- OutputImage{
 			OriginID: &originID,
@@ -264,34 +283,51 @@ func (c *ControllerUnsplash) fetchImage(ctx context.Context, inputImage InputIma
 		wgImage.Done()
 		return
 	}
-	for _, user := range users {
-		if user.OriginID == UserID {
-			outputImage <- OutputImage{
-				OriginID: nil,
-				Error:    nil,
-			}
-			wgImage.Done()
-			return // skip the image with unwanted user
+	if exists {
+		outputImage <- OutputImage{
+			OriginID: nil,
+			Error:    nil,
 		}
+		wgImage.Done()
+		return // skip existing image
 	}
 
-	// look for unwanted tag
+	// user of the photo
+	var UserID string
+	if photo.Photographer.ID != nil {
+		UserID = *photo.Photographer.ID
+	}
+	var userName string
+	if photo.Photographer.Username != nil {
+		userName = *photo.Photographer.Username
+	}
+
+	// look for unwanted user or tag
 	var photoTags []string
 	for _, tag := range *photo.Tags {
 		photoTags = append(photoTags, strings.ToLower(*tag.Title))
 	}
-	var blockedTagsString []string
-	for _, tag := range blockedTags {
-		blockedTagsString = append(blockedTagsString, tag.Name)
+	var texts []string
+	for _, text := range []*string{photo.Description, photo.AltDescription} {
+		if text != nil {
+			texts = append(texts, *text)
+		}
 	}
-	idx := util.FindIndexRegExp(blockedTagsString, photoTags)
-	if idx != -1 {
+	if reason := policy.reason(origin, UserID, photoTags, texts...); reason != "" {
 		outputImage <- OutputImage{
 			OriginID: nil,
 			Error:    nil,
 		}
 		wgImage.Done()
-		return // skip image with unwanted tag
+		return // skip the image with unwanted user or tag
+	}
+	if users.quotaReached(origin, UserID, searchedTag) {
+		outputImage <- OutputImage{
+			OriginID: nil,
+			Error:    nil,
+		}
+		wgImage.Done()
+		return // skip the image of a user with enough of them
 	}
 
 	//find download link and extension
@@ -325,27 +361,6 @@ func (c *ControllerUnsplash) fetchImage(ctx context.Context, inputImage InputIma
 	}
 
 	// tags creation
-	width, err := strconv.Atoi(link.Query().Get("w"))
-	if err != nil {
-		outputImage <- OutputImage{
-			OriginID: &originID,
-			Error:    err,
-		}
-		wgImage.Done()
-		return
-	}
-	var height int
-	if photo.Height != nil && photo.Width != nil {
-		height = *photo.Height * width / *photo.Width
-	}
-	zero := 0
-	box := controllerModel.Box{
-		Tlx:    zero, // original x anchor
-		Tly:    zero, // original y anchor
-		Width:  width,
-		Height: height,
-	}
-
 	tags := make([]controllerModel.PictureTag, 0, len(*photo.Tags))
 	now := time.Now()
 	pictureSizeID := model.NewUUID()
@@ -375,7 +390,7 @@ func (c *ControllerUnsplash) fetchImage(ctx context.Context, inputImage InputIma
 		{
 			ID:           pictureSizeID,
 			CreationDate: now,
-			Box:          box,
+			// Box set from the file
 		},
 	}
 	var title string
@@ -400,7 +415,18 @@ func (c *ControllerUnsplash) fetchImage(ctx context.Context, inputImage InputIma
 		Tags:         tags,
 	}
 
-	if err := c.ControllerPicture.CreatePicture(ctx, model.NewUUID(), picture, buffer); err != nil {
+	budget.tagPicture(&picture, searchedTag)
+	if err := users.createPicture(ctx, c.ControllerPicture, budget, searchedTag, picture, buffer); err != nil {
+		var rejected controllerModel.PictureRejectedError
+		if errors.As(err, &rejected) {
+			fmt.Printf("%v\n", rejected)
+			outputImage <- OutputImage{
+				OriginID: nil,
+				Error:    nil,
+			}
+			wgImage.Done()
+			return // skip the rejected image
+		}
 		outputImage <- OutputImage{
 			OriginID: &originID,
 			Error:    fmt.Errorf("CreatePicture has failed: %v", err),
@@ -2,27 +2,154 @@ package controller
 
 import (
 	"context"
+	"fmt"
 	controllerModel "scraper-backend/src/adapter/controller/model"
+	interfaceAdapter "scraper-backend/src/adapter/interface"
 	databaseInterface "scraper-backend/src/driver/interface/database"
 	model "scraper-backend/src/driver/model"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"golang.org/x/exp/slices"
 )
 
 type ControllerUser struct {
-	Dynamodb databaseInterface.DriverDynamodbUser
+	Dynamodb          databaseInterface.DriverDynamodbUser
+	DynamodbAudit     databaseInterface.DriverDynamodbAudit
+	ControllerPicture interfaceAdapter.ControllerPicture
 }
 
+// a user of a host is stored once, whatever its status.
+// the pictures of a blocked user already in the process and validation tables are moved to the blocked one in the background
 func (c ControllerUser) CreateUser(ctx context.Context, user controllerModel.User) error {
-	return c.Dynamodb.CreateUser(ctx, user)
+	if user.Status == "" {
+		user.Status = controllerModel.UserStatusBlocked
+	}
+	if err := validateUser(user); err != nil {
+		return err
+	}
+	users, err := c.Dynamodb.ScanUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if idx := slices.IndexFunc(users, func(existingUser controllerModel.User) bool {
+		return existingUser.Origin == user.Origin && existingUser.OriginID == user.OriginID
+	}); idx != -1 {
+		return fmt.Errorf("user `%s` of %s already is %s, delete it first", user.OriginID, user.Origin, userStatus(users[idx]))
+	}
+
+	audit, err := newAudit(ctx, controllerModel.AuditEntityUser, user.Origin, user.ID, "CreateUser", nil, user)
+	if err != nil {
+		return err
+	}
+	if err := c.Dynamodb.CreateUser(ctx, user, audit); err != nil {
+		return err
+	}
+	if user.Status == controllerModel.UserStatusBlocked {
+		sweepPicturesBlocked(ctx, c.ControllerPicture, c.DynamodbAudit, controllerModel.AuditEntityUser, user.Origin, user.ID)
+	}
+	return nil
+}
+
+func validateUser(user controllerModel.User) error {
+	if user.Origin == "" || user.OriginID == "" {
+		return fmt.Errorf("the origin and the originID of the user must not be empty")
+	}
+	switch user.Status {
+	case controllerModel.UserStatusBlocked, controllerModel.UserStatusTrusted:
+	case controllerModel.UserStatusCapped:
+		if user.Quota == 0 {
+			return fmt.Errorf("a capped user must have a quota")
+		}
+	default:
+		return fmt.Errorf("status must be `%s`, `%s` or `%s` and is `%s`", controllerModel.UserStatusBlocked, controllerModel.UserStatusTrusted, controllerModel.UserStatusCapped, user.Status)
+	}
+	if user.Quota < 0 {
+		return fmt.Errorf("quota must not be negative")
+	}
+	return nil
+}
+
+// the users stored before the statuses are blocked
+func userStatus(user controllerModel.User) string {
+	if user.Status == "" {
+		return controllerModel.UserStatusBlocked
+	}
+	return user.Status
 }
 
 func (c ControllerUser) DeleteUser(ctx context.Context, primaryKey string, sortKey model.UUID) error {
-	return c.Dynamodb.DeleteUser(ctx, primaryKey, sortKey)
+	user, err := c.Dynamodb.ReadUser(ctx, primaryKey, sortKey)
+	if err != nil {
+		return err
+	}
+	audit, err := newAudit(ctx, controllerModel.AuditEntityUser, primaryKey, sortKey, "DeleteUser", *user, nil)
+	if err != nil {
+		return err
+	}
+	return c.Dynamodb.DeleteUser(ctx, primaryKey, sortKey, audit)
 }
 
 func (c ControllerUser) ReadUsers(ctx context.Context) ([]controllerModel.User, error) {
 	return c.Dynamodb.ScanUsers(ctx)
 }
 
+// users with a status, or every user when it is empty
+func (c ControllerUser) ReadUsersStatus(ctx context.Context, status string) ([]controllerModel.User, error) {
+	users, err := c.Dynamodb.ScanUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status == "" {
+		return users, nil
+	}
+	filtered := []controllerModel.User{}
+	for _, user := range users {
+		if userStatus(user) == status {
+			filtered = append(filtered, user)
+		}
+	}
+	return filtered, nil
+}
+
+// pictures of every user in the tables, the pictures of a user are matched by its origin and originID
+func (c ControllerUser) ReadUsersStats(ctx context.Context) ([]controllerModel.UserStats, error) {
+	users, err := c.Dynamodb.ScanUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]controllerModel.UserStats, len(users))
+	indexes := make(map[userKey]int, len(users))
+	for i, user := range users {
+		stats[i].User = user
+		indexes[userKey{origin: user.Origin, originID: user.OriginID}] = i
+	}
+	if len(users) == 0 {
+		return stats, nil
+	}
+
+	projEx := expression.NamesList(expression.Name("Origin"), expression.Name("User"))
+	for _, state := range []string{controllerModel.PictureStateProcess, controllerModel.PictureStateValidation, controllerModel.PictureStateProduction, controllerModel.PictureStateBlocked} {
+		pictures, err := c.ControllerPicture.ReadPictures(ctx, state, &projEx, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, picture := range pictures {
+			i, ok := indexes[userKey{origin: picture.Origin, originID: picture.User.OriginID}]
+			if !ok {
+				continue
+			}
+			stats[i].Ingested++
+			switch state {
+			case controllerModel.PictureStateBlocked:
+				stats[i].Blocked++
+			case controllerModel.PictureStateValidation, controllerModel.PictureStateProduction:
+				stats[i].Promoted++
+			}
+		}
+	}
+	return stats, nil
+}
+
 func (c ControllerUser) ReadUser(ctx context.Context, primaryKey string, sortKey model.UUID) (*controllerModel.User, error) {
 	return c.Dynamodb.ReadUser(ctx, primaryKey, sortKey)
 }
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	dynamodbTable "scraper-backend/src/driver/database/dynamodb/table"
+	interfaceDatabase "scraper-backend/src/driver/interface/database"
+	interfaceStorage "scraper-backend/src/driver/interface/storage"
+
+	"golang.org/x/exp/slices"
+)
+
+// smallest width and height of a box, in pixels
+const boxMinimumSize = 50
+
+// all the licenses given to the pictures by the scrapers
+var licensesAvailable = []string{
+	"Attribution License",
+	"Attribution-ShareAlike License",
+	"No known copyright restrictions",
+	"Public Domain Dedication (CC0)",
+	"Public Domain Mark",
+}
+
+// PictureValidator checks a picture before it is promoted to production
+type PictureValidator interface {
+	Validate(ctx context.Context, picture controllerModel.Picture) ([]controllerModel.ValidationError, error)
+}
+
+func (c ControllerPicture) validatePicture(ctx context.Context, picture controllerModel.Picture) error {
+	var validationErrors controllerModel.ValidationErrors
+	for _, validator := range c.Validators {
+		errs, err := validator.Validate(ctx, picture)
+		if err != nil {
+			return err
+		}
+		validationErrors = append(validationErrors, errs...)
+	}
+	if len(validationErrors) > 0 {
+		return validationErrors
+	}
+	return nil
+}
+
+// the box, segmentation and keypoints of a tag written as a whole are checked like on their own routes
+func (c ControllerPicture) validatePictureTag(ctx context.Context, picture controllerModel.Picture, tag controllerModel.PictureTag) error {
+	validationErrors, err := ValidatorBoxBounds{}.Validate(ctx, controllerModel.Picture{Sizes: picture.Sizes, Tags: []controllerModel.PictureTag{tag}})
+	if err != nil {
+		return err
+	}
+	var errs []error
+	if tag.Segmentation.Valid {
+		errs = append(errs, validateSegmentation(picture, tag.Segmentation.Body))
+	}
+	if tag.Keypoints.Valid {
+		errs = append(errs, c.validateKeypoints(ctx, picture, tag.Name, tag.Keypoints.Body))
+	}
+	for _, err := range errs {
+		var tagErrors controllerModel.ValidationErrors
+		if errors.As(err, &tagErrors) {
+			validationErrors = append(validationErrors, tagErrors...)
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(validationErrors) > 0 {
+		return controllerModel.ValidationErrors(validationErrors)
+	}
+	return nil
+}
+
+// at least one tag has a box
+type ValidatorBoxRequired struct{}
+
+func (v ValidatorBoxRequired) Validate(ctx context.Context, picture controllerModel.Picture) ([]controllerModel.ValidationError, error) {
+	idx := slices.IndexFunc(picture.Tags, func(tag controllerModel.PictureTag) bool { return tag.BoxInformation.Valid })
+	if idx == -1 {
+		return []controllerModel.ValidationError{{Rule: "boxRequired", Message: "the picture has no box"}}, nil
+	}
+	return nil, nil
+}
+
+// every box references an existing size and stays inside of it
+type ValidatorBoxBounds struct{}
+
+func (v ValidatorBoxBounds) Validate(ctx context.Context, picture controllerModel.Picture) ([]controllerModel.ValidationError, error) {
+	var errs []controllerModel.ValidationError
+	for _, tag := range picture.Tags {
+		if !tag.BoxInformation.Valid {
+			continue
+		}
+		boxInformation := tag.BoxInformation.Body
+		idx := slices.IndexFunc(picture.Sizes, func(size controllerModel.PictureSize) bool { return size.ID == boxInformation.PictureSizeID })
+		if idx == -1 {
+			errs = append(errs, controllerModel.ValidationError{
+				Rule:    "boxBounds",
+				Message: fmt.Sprintf("box of tag %s references the unknown size %s", tag.ID, boxInformation.PictureSizeID),
+			})
+			continue
+		}
+		size := picture.Sizes[idx]
+		box := boxInformation.Box
+		if box.Tlx < 0 || box.Tly < 0 || box.Tlx+box.Width > size.Box.Width || box.Tly+box.Height > size.Box.Height {
+			errs = append(errs, controllerModel.ValidationError{
+				Rule:    "boxBounds",
+				Message: fmt.Sprintf("box of tag %s %+v is outside of size %s %+v", tag.ID, box, size.ID, size.Box),
+			})
+		}
+	}
+	return errs, nil
+}
+
+// every box is big enough to be used
+type ValidatorBoxMinimumSize struct {
+	Width  int
+	Height int
+}
+
+func (v ValidatorBoxMinimumSize) Validate(ctx context.Context, picture controllerModel.Picture) ([]controllerModel.ValidationError, error) {
+	var errs []controllerModel.ValidationError
+	for _, tag := range picture.Tags {
+		if !tag.BoxInformation.Valid {
+			continue
+		}
+		box := tag.BoxInformation.Body.Box
+		if box.Width < v.Width || box.Height < v.Height {
+			errs = append(errs, controllerModel.ValidationError{
+				Rule:    "boxMinimumSize",
+				Message: fmt.Sprintf("box of tag %s is %dx%d, minimum is %dx%d", tag.ID, box.Width, box.Height, v.Width, v.Height),
+			})
+		}
+	}
+	return errs, nil
+}
+
+// every tag with a box is one of the searched tags
+type ValidatorTagSearched struct {
+	Dynamodb interfaceDatabase.DriverDynamodbTag
+}
+
+func (v ValidatorTagSearched) Validate(ctx context.Context, picture controllerModel.Picture) ([]controllerModel.ValidationError, error) {
+	searchedTags, err := v.Dynamodb.ReadTags(ctx, dynamodbTable.TagPrimaryKeySearched)
+	if err != nil {
+		return nil, err
+	}
+	var errs []controllerModel.ValidationError
+	for _, tag := range picture.Tags {
+		if !tag.BoxInformation.Valid {
+			continue
+		}
+		idx := slices.IndexFunc(searchedTags, func(searchedTag controllerModel.Tag) bool { return slices.Contains(tagNames(searchedTag), tag.Name) })
+		if idx == -1 {
+			errs = append(errs, controllerModel.ValidationError{
+				Rule:    "tagSearched",
+				Message: fmt.Sprintf("tag %s `%s` is not a searched tag", tag.ID, tag.Name),
+			})
+		}
+	}
+	return errs, nil
+}
+
+// a picture labeled by annotators has a set accepted by a reviewer
+type ValidatorAnnotationAccepted struct{}
+
+func (v ValidatorAnnotationAccepted) Validate(ctx context.Context, picture controllerModel.Picture) ([]controllerModel.ValidationError, error) {
+	if len(picture.AnnotationSets) == 0 {
+		return nil, nil
+	}
+	if _, ok := acceptedAnnotationSet(picture); !ok {
+		return []controllerModel.ValidationError{{Rule: "annotationAccepted", Message: "no annotation set of the picture has been accepted"}}, nil
+	}
+	return nil, nil
+}
+
+// the license is one given by the scrapers
+type ValidatorLicense struct {
+	Licenses []string
+}
+
+func (v ValidatorLicense) Validate(ctx context.Context, picture controllerModel.Picture) ([]controllerModel.ValidationError, error) {
+	if !slices.Contains(v.Licenses, picture.License) {
+		return []controllerModel.ValidationError{{Rule: "license", Message: fmt.Sprintf("license `%s` is unknown", picture.License)}}, nil
+	}
+	return nil, nil
+}
+
+// the file of the picture is in the bucket
+type ValidatorFile struct {
+	S3         interfaceStorage.DriverS3
+	BucketName string
+}
+
+func (v ValidatorFile) Validate(ctx context.Context, picture controllerModel.Picture) ([]controllerModel.ValidationError, error) {
+	key, err := currentObjectKey(picture)
+	if err != nil {
+		return []controllerModel.ValidationError{{Rule: "file", Message: err.Error()}}, nil
+	}
+	exists, err := v.S3.ItemExists(ctx, v.BucketName, key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return []controllerModel.ValidationError{{Rule: "file", Message: fmt.Sprintf("file %s is missing from the bucket", key)}}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"context"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	dynamodbTable "scraper-backend/src/driver/database/dynamodb/table"
+	interfaceDatabase "scraper-backend/src/driver/interface/database"
+	interfaceStorage "scraper-backend/src/driver/interface/storage"
+	model "scraper-backend/src/driver/model"
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+// tags by primary key, the other methods are not used
+type fakeDynamodbTag struct {
+	interfaceDatabase.DriverDynamodbTag
+	tags map[string][]controllerModel.Tag
+}
+
+func (f *fakeDynamodbTag) ReadTags(ctx context.Context, primaryKey string) ([]controllerModel.Tag, error) {
+	return f.tags[primaryKey], nil
+}
+
+// files by key, the other methods are not used
+type fakeS3 struct {
+	interfaceStorage.DriverS3
+	files map[string][]byte
+}
+
+func (f *fakeS3) ItemExists(ctx context.Context, bucketName, path string) (bool, error) {
+	_, ok := f.files[path]
+	return ok, nil
+}
+
+func (f *fakeS3) ItemRead(ctx context.Context, bucketName, path string) ([]byte, error) {
+	return f.files[path], nil
+}
+
+func boxTag(name string, sizeID model.UUID, box controllerModel.Box) controllerModel.PictureTag {
+	return controllerModel.PictureTag{
+		ID:             model.NewUUID(),
+		Name:           name,
+		BoxInformation: model.NewNullable(controllerModel.BoxInformation{PictureSizeID: sizeID, Box: box}),
+	}
+}
+
+func TestPictureValidators(t *testing.T) {
+	size := controllerModel.PictureSize{ID: model.NewUUID(), Box: controllerModel.Box{Width: 400, Height: 300}, ObjectKey: "pexels/picture/size.jpg"}
+	inside := controllerModel.Box{Tlx: 10, Tly: 10, Width: 100, Height: 100}
+	searched := &fakeDynamodbTag{tags: map[string][]controllerModel.Tag{
+		dynamodbTable.TagPrimaryKeySearched: {{Name: "dog", Synonyms: []string{"puppy"}}},
+	}}
+	s3 := &fakeS3{files: map[string][]byte{size.ObjectKey: {}}}
+
+	tests := []struct {
+		name      string
+		validator PictureValidator
+		picture   controllerModel.Picture
+		wantRules []string
+	}{
+		{
+			name:      "box required with a box",
+			validator: ValidatorBoxRequired{},
+			picture:   controllerModel.Picture{Tags: []controllerModel.PictureTag{labelTag, boxTag("dog", size.ID, inside)}},
+		},
+		{
+			name:      "box required without box",
+			validator: ValidatorBoxRequired{},
+			picture:   controllerModel.Picture{Tags: []controllerModel.PictureTag{labelTag}},
+			wantRules: []string{"boxRequired"},
+		},
+		{
+			name:      "box inside of its size",
+			validator: ValidatorBoxBounds{},
+			picture:   controllerModel.Picture{Sizes: []controllerModel.PictureSize{size}, Tags: []controllerModel.PictureTag{boxTag("dog", size.ID, controllerModel.Box{Width: 400, Height: 300})}},
+		},
+		{
+			name:      "boxes outside of their size or of an unknown one",
+			validator: ValidatorBoxBounds{},
+			picture: controllerModel.Picture{Sizes: []controllerModel.PictureSize{size}, Tags: []controllerModel.PictureTag{
+				boxTag("dog", size.ID, controllerModel.Box{Tlx: 350, Tly: 10, Width: 100, Height: 100}),
+				boxTag("dog", size.ID, controllerModel.Box{Tlx: -1, Tly: 10, Width: 100, Height: 100}),
+				boxTag("dog", model.NewUUID(), inside),
+			}},
+			wantRules: []string{"boxBounds", "boxBounds", "boxBounds"},
+		},
+		{
+			name:      "boxes of the minimum size",
+			validator: ValidatorBoxMinimumSize{Width: 50, Height: 50},
+			picture:   controllerModel.Picture{Tags: []controllerModel.PictureTag{labelTag, boxTag("dog", size.ID, controllerModel.Box{Width: 50, Height: 50})}},
+		},
+		{
+			name:      "boxes too narrow or too low",
+			validator: ValidatorBoxMinimumSize{Width: 50, Height: 50},
+			picture: controllerModel.Picture{Tags: []controllerModel.PictureTag{
+				boxTag("dog", size.ID, controllerModel.Box{Width: 49, Height: 100}),
+				boxTag("dog", size.ID, controllerModel.Box{Width: 100, Height: 49}),
+			}},
+			wantRules: []string{"boxMinimumSize", "boxMinimumSize"},
+		},
+		{
+			name:      "boxes of searched tags and synonyms",
+			validator: ValidatorTagSearched{Dynamodb: searched},
+			picture: controllerModel.Picture{Tags: []controllerModel.PictureTag{
+				boxTag("dog", size.ID, inside),
+				boxTag("puppy", size.ID, inside),
+				{ID: model.NewUUID(), Name: "cat"}, // without box
+			}},
+		},
+		{
+			name:      "box of a tag not searched",
+			validator: ValidatorTagSearched{Dynamodb: searched},
+			picture:   controllerModel.Picture{Tags: []controllerModel.PictureTag{boxTag("cat", size.ID, inside)}},
+			wantRules: []string{"tagSearched"},
+		},
+		{
+			name:      "no annotation set",
+			validator: ValidatorAnnotationAccepted{},
+			picture:   controllerModel.Picture{},
+		},
+		{
+			name:      "annotation set accepted",
+			validator: ValidatorAnnotationAccepted{},
+			picture: controllerModel.Picture{AnnotationSets: []controllerModel.AnnotationSet{
+				{Status: controllerModel.AnnotationSetPending},
+				{Status: controllerModel.AnnotationSetAccepted},
+			}},
+		},
+		{
+			name:      "annotation sets pending",
+			validator: ValidatorAnnotationAccepted{},
+			picture:   controllerModel.Picture{AnnotationSets: []controllerModel.AnnotationSet{{Status: controllerModel.AnnotationSetPending}}},
+			wantRules: []string{"annotationAccepted"},
+		},
+		{
+			name:      "known license",
+			validator: ValidatorLicense{Licenses: licensesAvailable},
+			picture:   controllerModel.Picture{License: licensesAvailable[0]},
+		},
+		{
+			name:      "unknown license",
+			validator: ValidatorLicense{Licenses: licensesAvailable},
+			picture:   controllerModel.Picture{License: "all rights reserved"},
+			wantRules: []string{"license"},
+		},
+		{
+			name:      "file in the bucket",
+			validator: ValidatorFile{S3: s3},
+			picture:   controllerModel.Picture{Sizes: []controllerModel.PictureSize{size}},
+		},
+		{
+			name:      "file missing from the bucket",
+			validator: ValidatorFile{S3: s3},
+			picture:   controllerModel.Picture{Sizes: []controllerModel.PictureSize{{ID: model.NewUUID(), ObjectKey: "pexels/picture/other.jpg"}}},
+			wantRules: []string{"file"},
+		},
+		{
+			name:      "size without file",
+			validator: ValidatorFile{S3: s3},
+			picture:   controllerModel.Picture{Sizes: []controllerModel.PictureSize{{ID: model.NewUUID()}}},
+			wantRules: []string{"file"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs, err := test.validator.Validate(context.Background(), test.picture)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			var rules []string
+			for _, validationError := range errs {
+				rules = append(rules, validationError.Rule)
+			}
+			if !slices.Equal(rules, test.wantRules) {
+				t.Errorf("Validate() rules = %v, want %v", rules, test.wantRules)
+			}
+		})
+	}
+}
+
+func TestValidatePicture(t *testing.T) {
+	c := ControllerPicture{Validators: []PictureValidator{
+		ValidatorBoxRequired{},
+		ValidatorLicense{Licenses: licensesAvailable},
+	}}
+	err := c.validatePicture(context.Background(), controllerModel.Picture{License: "all rights reserved"})
+	validationErrors, ok := err.(controllerModel.ValidationErrors)
+	if !ok {
+		t.Fatalf("validatePicture() error = %v, want ValidationErrors", err)
+	}
+	if len(validationErrors) != 2 || validationErrors[0].Rule != "boxRequired" || validationErrors[1].Rule != "license" {
+		t.Errorf("validatePicture() errors = %+v, want the errors of every validator in order", validationErrors)
+	}
+
+	size := controllerModel.PictureSize{ID: model.NewUUID(), Box: controllerModel.Box{Width: 400, Height: 300}}
+	valid := controllerModel.Picture{License: licensesAvailable[0], Sizes: []controllerModel.PictureSize{size}, Tags: []controllerModel.PictureTag{boxTag("dog", size.ID, controllerModel.Box{Width: 100, Height: 100})}}
+	if err := c.validatePicture(context.Background(), valid); err != nil {
+		t.Errorf("validatePicture() error = %v, want none", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	controllerModel "scraper-backend/src/adapter/controller/model"
 	"scraper-backend/src/driver/model"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 )
@@ -11,26 +12,52 @@ import (
 type ControllerPicture interface {
 	ReadPictures(ctx context.Context, state string, projection *expression.ProjectionBuilder, filter *expression.ConditionBuilder) ([]controllerModel.Picture, error)
 	ReadPicture(ctx context.Context, state string, primaryKey string, sortKey model.UUID) (*controllerModel.Picture, error)
-	ReadPictureFile(ctx context.Context, origin, name, extension string) ([]byte, error)
-	CreatePicture(ctx context.Context, id model.UUID, picture controllerModel.Picture, buffer []byte) error
-	DeletePicture(ctx context.Context, primaryKey string, sortKey model.UUID) error
-	DeletePictureAndFile(ctx context.Context, primaryKey string, sortKey model.UUID, name string) error
+	ReadPictureFile(ctx context.Context, state string, primaryKey string, sortKey model.UUID, width int, cachedTag string) (*controllerModel.PictureFile, error)
+	ReadPictureFileByName(ctx context.Context, primaryKey string, name string, width int, cachedTag string) (*controllerModel.PictureFile, error)
+	CreatePicture(ctx context.Context, id model.UUID, picture controllerModel.Picture, buffer []byte) (string, error)
+	DeletePicture(ctx context.Context, primaryKey string, sortKey model.UUID, version int) error
+	DeletePictureAndFile(ctx context.Context, primaryKey string, sortKey model.UUID, version int) error
 	DeletePicturesAndFiles(ctx context.Context, pictures []controllerModel.Picture) error
-	CreatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, tagID model.UUID, tag controllerModel.PictureTag) error
-	UpdatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, tagID model.UUID, tag controllerModel.PictureTag) error
-	DeletePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, tagID model.UUID) error
-	UpdatePictureCrop(ctx context.Context, primaryKey string, sortKey model.UUID, name string, pictureSizeID model.UUID, box controllerModel.Box) error
+	CreatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID, tag controllerModel.PictureTag) error
+	UpdatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID, tag controllerModel.PictureTag) error
+	DeletePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID) error
+	CreatePictureTagSegmentation(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tag controllerModel.PictureTag) error
+	UpdatePictureTagSegmentation(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID, segmentation controllerModel.Segmentation) error
+	CreatePictureTagKeypoints(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tag controllerModel.PictureTag) error
+	UpdatePictureTagKeypoints(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID, keypoints controllerModel.Keypoints) error
+	UpdatePictureCrop(ctx context.Context, primaryKey string, sortKey model.UUID, version int, pictureSizeID model.UUID, box controllerModel.Box) error
 	CreatePictureCrop(ctx context.Context, primaryKey string, sortKey model.UUID, id model.UUID, pictureSizeID model.UUID, box controllerModel.Box) error
 	CreatePictureCopy(ctx context.Context, primaryKey string, sortKey model.UUID, id model.UUID) error
-	UpdatePictureTransfer(ctx context.Context, primaryKey string, sortKey model.UUID, from, to string) error
-	CreatePictureBlocked(ctx context.Context, primaryKey string, sortKey model.UUID) error
-	DeletePictureBlocked(ctx context.Context, primaryKey string, sortKey model.UUID) error
+	UpdatePictureAnnotationSet(ctx context.Context, primaryKey string, sortKey model.UUID, version int, annotator string, tags []controllerModel.PictureTag) error
+	UpdatePictureAnnotationReview(ctx context.Context, primaryKey string, sortKey model.UUID, version int, review controllerModel.AnnotationReview) error
+	ReadPicturesAnnotationSecond(ctx context.Context, annotator string) ([]controllerModel.Picture, error)
+	ReadPicturesAnnotationDisagreement(ctx context.Context, threshold float64) ([]controllerModel.AnnotationAgreement, error)
+	CreatePredictions(ctx context.Context, predictionImport controllerModel.PredictionImport) ([]controllerModel.PredictionResult, error)
+	EvaluatePredictions(ctx context.Context, filter controllerModel.EvaluationFilter) (*controllerModel.Evaluation, error)
+	ReadPicturesSample(ctx context.Context, filter controllerModel.SampleFilter) ([]controllerModel.Sample, error)
+	RankPictures(ctx context.Context, strategy string, pictures []controllerModel.Picture, seed int64) ([]controllerModel.Sample, error)
+	UpdatePictureTransfer(ctx context.Context, primaryKey string, sortKey model.UUID, version int, transition controllerModel.PictureTransition) error
+	CreatePictureBlocked(ctx context.Context, primaryKey string, sortKey model.UUID, version int, from, actor, reason string) error
+	DeletePictureBlocked(ctx context.Context, primaryKey string, sortKey model.UUID, version int, actor, reason string) error
+	UpdatePicturesBulk(ctx context.Context, bulk controllerModel.PictureBulk) ([]controllerModel.PictureBulkResult, error)
+	ReconcilePictures(ctx context.Context, fix bool) (*controllerModel.Reconciliation, error)
+	MigratePictureKeys(ctx context.Context) (*controllerModel.KeyMigration, error)
+	MigratePictureTags(ctx context.Context) (*controllerModel.TagMigration, error)
+	BackfillPictureSizes(ctx context.Context, fix bool) (*controllerModel.SizeBackfill, error)
+	ExportPictures(ctx context.Context, state string, level int) (*controllerModel.Export, error)
+	SweepPicturesBlocked(ctx context.Context) (*controllerModel.BlockSweep, error)
 }
 
 type ControllerTag interface {
 	CreateTag(ctx context.Context, tag controllerModel.Tag) error
 	CreateTagBlocked(ctx context.Context, tag controllerModel.Tag) error
 	DeleteTag(ctx context.Context, primaryKey string, sortKey model.UUID) error
+	ReadTag(ctx context.Context, primaryKey string, sortKey model.UUID) (*controllerModel.Tag, error)
+	UpdateTagSkeleton(ctx context.Context, primaryKey string, sortKey model.UUID, skeleton controllerModel.Skeleton) error
+	UpdateTagHierarchy(ctx context.Context, primaryKey string, sortKey model.UUID, parent string, synonyms []string) error
+	TestTagsBlocked(ctx context.Context, names []string) ([]controllerModel.TagMatch, error)
+	UpdateTagTarget(ctx context.Context, primaryKey string, sortKey model.UUID, targetCount, maxPages int) error
+	ReadTagsProgress(ctx context.Context) ([]controllerModel.TagProgress, error)
 	ReadTags(ctx context.Context, primaryKey string) ([]controllerModel.Tag, error)
 }
 
@@ -39,6 +66,19 @@ type ControllerUser interface {
 	CreateUser(ctx context.Context, user controllerModel.User) error
 	DeleteUser(ctx context.Context, primaryKey string, sortKey model.UUID) error
 	ReadUsers(ctx context.Context) ([]controllerModel.User, error)
+	ReadUsersStatus(ctx context.Context, status string) ([]controllerModel.User, error)
+	ReadUsersStats(ctx context.Context) ([]controllerModel.UserStats, error)
+}
+
+type ControllerAudit interface {
+	ReadPictureHistory(ctx context.Context, primaryKey string, sortKey model.UUID) ([]controllerModel.Audit, error)
+	ReadAudits(ctx context.Context, filter controllerModel.AuditFilter) ([]controllerModel.Audit, error)
+}
+
+type ControllerTask interface {
+	ReadTaskNext(ctx context.Context, state string, priority string, lease time.Duration, user string) (*controllerModel.Task, error)
+	CompleteTask(ctx context.Context, id model.UUID, user string) error
+	ReadTaskStats(ctx context.Context, filter controllerModel.TaskFilter) ([]controllerModel.TaskStats, error)
 }
 
 type ControllerFlickr interface {
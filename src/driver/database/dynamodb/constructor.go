@@ -14,6 +14,8 @@ func ConstructorPicture(
 	PrimaryKeyType string,
 	SortKeyName string,
 	SortKeyType string,
+	AuditTableName string,
+	AuditPrimaryKeyName string,
 ) interfaceDatabase.DriverDynamodbPicture {
 	return &table.TablePicture{
 		DynamoDbClient: client,
@@ -22,6 +24,11 @@ func ConstructorPicture(
 		PrimaryKeyType: PrimaryKeyType,
 		SortKeyName:    SortKeyName,
 		SortKeyType:    SortKeyType,
+		Audit: table.TableAudit{
+			DynamoDbClient: client,
+			TableName:      AuditTableName,
+			PrimaryKeyName: AuditPrimaryKeyName,
+		},
 	}
 }
 
@@ -32,6 +39,8 @@ func ConstructorTag(
 	PrimaryKeyType string,
 	SortKeyName string,
 	SortKeyType string,
+	AuditTableName string,
+	AuditPrimaryKeyName string,
 ) interfaceDatabase.DriverDynamodbTag {
 	return &table.TableTag{
 		DynamoDbClient: client,
@@ -40,6 +49,11 @@ func ConstructorTag(
 		PrimaryKeyType: PrimaryKeyType,
 		SortKeyName:    SortKeyName,
 		SortKeyType:    SortKeyType,
+		Audit: table.TableAudit{
+			DynamoDbClient: client,
+			TableName:      AuditTableName,
+			PrimaryKeyName: AuditPrimaryKeyName,
+		},
 	}
 }
 
@@ -50,6 +64,8 @@ func ConstructorUser(
 	PrimaryKeyType string,
 	SortKeyName string,
 	SortKeyType string,
+	AuditTableName string,
+	AuditPrimaryKeyName string,
 ) interfaceDatabase.DriverDynamodbUser {
 	return &table.TableUser{
 		DynamoDbClient: client,
@@ -58,5 +74,46 @@ func ConstructorUser(
 		PrimaryKeyType: PrimaryKeyType,
 		SortKeyName:    SortKeyName,
 		SortKeyType:    SortKeyType,
+		Audit: table.TableAudit{
+			DynamoDbClient: client,
+			TableName:      AuditTableName,
+			PrimaryKeyName: AuditPrimaryKeyName,
+		},
+	}
+}
+
+func ConstructorAudit(
+	client *awsDynamodb.Client,
+	TableName string,
+	PrimaryKeyName string,
+	PrimaryKeyType string,
+	SortKeyName string,
+	SortKeyType string,
+) interfaceDatabase.DriverDynamodbAudit {
+	return &table.TableAudit{
+		DynamoDbClient: client,
+		TableName:      TableName,
+		PrimaryKeyName: PrimaryKeyName,
+		PrimaryKeyType: PrimaryKeyType,
+		SortKeyName:    SortKeyName,
+		SortKeyType:    SortKeyType,
+	}
+}
+
+func ConstructorTask(
+	client *awsDynamodb.Client,
+	TableName string,
+	PrimaryKeyName string,
+	PrimaryKeyType string,
+	SortKeyName string,
+	SortKeyType string,
+) interfaceDatabase.DriverDynamodbTask {
+	return &table.TableTask{
+		DynamoDbClient: client,
+		TableName:      TableName,
+		PrimaryKeyName: PrimaryKeyName,
+		PrimaryKeyType: PrimaryKeyType,
+		SortKeyName:    SortKeyName,
+		SortKeyType:    SortKeyType,
 	}
 }
@@ -0,0 +1,50 @@
+package dynamodb
+
+import (
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+	"time"
+)
+
+type AnnotationSet struct {
+	ID           model.UUID
+	Annotator    string
+	Tags         []PictureTag
+	Status       string
+	CreationDate time.Time
+	Reviewer     string
+	ReviewDate   time.Time
+}
+
+func (as *AnnotationSet) DriverMarshal(value controllerModel.AnnotationSet) {
+	as.ID = value.ID
+	as.Annotator = value.Annotator
+	as.Status = value.Status
+	as.CreationDate = value.CreationDate
+	as.Reviewer = value.Reviewer
+	as.ReviewDate = value.ReviewDate
+
+	tags := make([]PictureTag, 0, len(value.Tags))
+	for _, controllerTag := range value.Tags {
+		var driverTag PictureTag
+		driverTag.DriverMarshal(controllerTag)
+		tags = append(tags, driverTag)
+	}
+	as.Tags = tags
+}
+
+func (as AnnotationSet) DriverUnmarshal() controllerModel.AnnotationSet {
+	tags := make([]controllerModel.PictureTag, 0, len(as.Tags))
+	for _, driverTag := range as.Tags {
+		tags = append(tags, driverTag.DriverUnmarshal())
+	}
+	return controllerModel.AnnotationSet{
+		ID:           as.ID,
+		Annotator:    as.Annotator,
+		Tags:         tags,
+		Status:       as.Status,
+		CreationDate: as.CreationDate,
+		Reviewer:     as.Reviewer,
+		ReviewDate:   as.ReviewDate,
+	}
+}
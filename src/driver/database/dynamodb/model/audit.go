@@ -0,0 +1,53 @@
+package dynamodb
+
+import (
+	"fmt"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+	"time"
+)
+
+type Audit struct {
+	Key          string     `dynamodbav:"Key"` // PK entity/origin/id
+	ID           model.UUID `dynamodbav:"ID"`  // SK
+	Entity       string     `dynamodbav:"Entity"`
+	Origin       string     `dynamodbav:"Origin"`
+	EntityID     model.UUID `dynamodbav:"EntityID"`
+	Action       string     `dynamodbav:"Action"`
+	Actor        string     `dynamodbav:"Actor"`
+	CreationDate time.Time  `dynamodbav:"CreationDate"`
+	Before       string     `dynamodbav:"Before"`
+	After        string     `dynamodbav:"After"`
+}
+
+// the entries of one entity share their primary key
+func AuditKey(entity string, origin string, id model.UUID) string {
+	return fmt.Sprintf("%s/%s/%s", entity, origin, id)
+}
+
+func (a *Audit) DriverMarshal(value controllerModel.Audit) {
+	a.Key = AuditKey(value.Entity, value.Origin, value.EntityID)
+	a.ID = value.ID
+	a.Entity = value.Entity
+	a.Origin = value.Origin
+	a.EntityID = value.EntityID
+	a.Action = value.Action
+	a.Actor = value.Actor
+	a.CreationDate = value.CreationDate
+	a.Before = value.Before
+	a.After = value.After
+}
+
+func (a Audit) DriverUnmarshal() controllerModel.Audit {
+	return controllerModel.Audit{
+		ID:           a.ID,
+		Entity:       a.Entity,
+		Origin:       a.Origin,
+		EntityID:     a.EntityID,
+		Action:       a.Action,
+		Actor:        a.Actor,
+		CreationDate: a.CreationDate,
+		Before:       a.Before,
+		After:        a.After,
+	}
+}
@@ -2,25 +2,34 @@ package dynamodb
 
 import (
 	"database/sql"
+	"sort"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
 	controllerModel "scraper-backend/src/adapter/controller/model"
 	model "scraper-backend/src/driver/model"
 )
 
 type Picture struct {
-	Origin       string        `dynamodbav:"Origin"`   // PK original werbsite
-	ID           model.UUID    `dynamodbav:"ID"`       // SK
-	Name         string        `dynamodbav:"Name"`     // name <originID>_time
-	OriginID     string        `dynamodbav:"OriginID"` // id from original website
-	User         User          `dynamodbav:"User"`
-	Extension    string        `dynamodbav:"Extension"` // type of file
-	Sizes        []PictureSize `dynamodbav:"Sizes"`     // size cropping history
-	Title        string        `dynamodbav:"Title"`
-	Description  string        `dynamodbav:"Description"` // decription of picture
-	License      string        `dynamodbav:"License"`     // type of public license
-	CreationDate time.Time     `dynamodbav:"CreationDate"`
-	Tags         []PictureTag  `dynamodbav:"Tags"`
+	Origin         string                         `dynamodbav:"Origin"`   // PK original werbsite
+	ID             model.UUID                     `dynamodbav:"ID"`       // SK
+	Name           string                         `dynamodbav:"Name"`     // name <originID>_time
+	OriginID       string                         `dynamodbav:"OriginID"` // id from original website
+	User           User                           `dynamodbav:"User"`
+	Extension      string                         `dynamodbav:"Extension"` // type of file
+	Sizes          []PictureSize                  `dynamodbav:"Sizes"`     // size cropping history
+	Title          string                         `dynamodbav:"Title"`
+	Description    string                         `dynamodbav:"Description"` // decription of picture
+	License        string                         `dynamodbav:"License"`     // type of public license
+	CreationDate   time.Time                      `dynamodbav:"CreationDate"`
+	Tags           PictureTags                    `dynamodbav:"Tags"` // by tag ID so that they are written one by one
+	AnnotationSets []AnnotationSet                `dynamodbav:"AnnotationSets"`
+	Transitions    []PictureTransition            `dynamodbav:"Transitions"` // state history
+	Metadata       map[string]string              `dynamodbav:"Metadata"`    // EXIF fields of the original file
+	Quality        model.Nullable[PictureQuality] `dynamodbav:"Quality"`     // scores computed on ingestion
+	Version        int                            `dynamodbav:"Version"`     // condition of the writes
 }
 
 func (p *Picture) DriverMarshal(value controllerModel.Picture) {
@@ -33,6 +42,12 @@ func (p *Picture) DriverMarshal(value controllerModel.Picture) {
 	p.Description = value.Description
 	p.License = value.License
 	p.CreationDate = value.CreationDate
+	p.Metadata = value.Metadata
+	if value.Quality.Valid {
+		var quality PictureQuality
+		quality.DriverMarshal(value.Quality.Body)
+		p.Quality = model.NewNullable(quality)
+	}
 
 	var user User
 	user.DriverMarshal(value.User)
@@ -50,17 +65,30 @@ func (p *Picture) DriverMarshal(value controllerModel.Picture) {
 	}
 	p.Sizes = sizes
 
-	// tags, err := ConvertMap[model.UUID, controllerModel.PictureTag, PictureTag](value.Tags)
-	// if err != nil {
-	// 	return err
-	// }
-	tags := make([]PictureTag, 0, len(value.Tags))
+	tags := make(PictureTags, len(value.Tags))
 	for _, controllerTag := range value.Tags {
 		var driverTag PictureTag
 		driverTag.DriverMarshal(controllerTag)
-		tags = append(tags, driverTag)
+		tags[controllerTag.ID.String()] = driverTag
 	}
 	p.Tags = tags
+	p.Version = value.Version
+
+	annotationSets := make([]AnnotationSet, 0, len(value.AnnotationSets))
+	for _, controllerSet := range value.AnnotationSets {
+		var driverSet AnnotationSet
+		driverSet.DriverMarshal(controllerSet)
+		annotationSets = append(annotationSets, driverSet)
+	}
+	p.AnnotationSets = annotationSets
+
+	transitions := make([]PictureTransition, 0, len(value.Transitions))
+	for _, controllerTransition := range value.Transitions {
+		var driverTransition PictureTransition
+		driverTransition.DriverMarshal(controllerTransition)
+		transitions = append(transitions, driverTransition)
+	}
+	p.Transitions = transitions
 }
 
 func (p Picture) DriverUnmarshal() *controllerModel.Picture {
@@ -73,32 +101,88 @@ func (p Picture) DriverUnmarshal() *controllerModel.Picture {
 	for _, pictureTag := range p.Tags {
 		tags = append(tags, pictureTag.DriverUnmarshal())
 	}
+	// maps are not ordered
+	sort.SliceStable(tags, func(i, j int) bool {
+		if !tags[i].CreationDate.Equal(tags[j].CreationDate) {
+			return tags[i].CreationDate.Before(tags[j].CreationDate)
+		}
+		return tags[i].ID.String() < tags[j].ID.String()
+	})
 
+	annotationSets := make([]controllerModel.AnnotationSet, 0, len(p.AnnotationSets))
+	for _, annotationSet := range p.AnnotationSets {
+		annotationSets = append(annotationSets, annotationSet.DriverUnmarshal())
+	}
+
+	transitions := make([]controllerModel.PictureTransition, 0, len(p.Transitions))
+	for _, pictureTransition := range p.Transitions {
+		transitions = append(transitions, pictureTransition.DriverUnmarshal())
+	}
+
+	var quality model.Nullable[controllerModel.PictureQuality]
+	if p.Quality.Valid {
+		quality = model.NewNullable(p.Quality.Body.DriverUnmarshal())
+	}
 	return &controllerModel.Picture{
-		Origin:       p.Origin,
-		ID:           p.ID,
-		OriginID:     p.OriginID,
-		Name:         p.Name,
-		User:         p.User.DriverUnmarshal(),
-		Extension:    p.Extension,
-		Sizes:        sizes,
-		Title:        p.Title,
-		Description:  p.Description,
-		License:      p.License,
-		CreationDate: p.CreationDate,
-		Tags:         tags,
+		Origin:         p.Origin,
+		ID:             p.ID,
+		OriginID:       p.OriginID,
+		Name:           p.Name,
+		User:           p.User.DriverUnmarshal(),
+		Extension:      p.Extension,
+		Sizes:          sizes,
+		Title:          p.Title,
+		Description:    p.Description,
+		License:        p.License,
+		CreationDate:   p.CreationDate,
+		Tags:           tags,
+		AnnotationSets: annotationSets,
+		Transitions:    transitions,
+		Metadata:       p.Metadata,
+		Quality:        quality,
+		Version:        p.Version,
+	}
+}
+
+type PictureTags map[string]PictureTag
+
+// the tags were first stored in a list, they are read from both
+func (pt *PictureTags) UnmarshalDynamoDBAttributeValue(value types.AttributeValue) error {
+	switch value.(type) {
+	case *types.AttributeValueMemberL:
+		var tags []PictureTag
+		if err := attributevalue.Unmarshal(value, &tags); err != nil {
+			return err
+		}
+		*pt = make(PictureTags, len(tags))
+		for _, tag := range tags {
+			(*pt)[tag.ID.String()] = tag
+		}
+		return nil
+	case *types.AttributeValueMemberNULL:
+		*pt = PictureTags{}
+		return nil
+	default:
+		tags := map[string]PictureTag{}
+		if err := attributevalue.Unmarshal(value, &tags); err != nil {
+			return err
+		}
+		*pt = tags
+		return nil
 	}
 }
 
 type PictureSize struct {
 	ID           model.UUID
 	CreationDate time.Time
-	Box          Box // absolut reference of the top left of new box based on the original sizes
+	Box          Box    // absolut reference of the top left of new box based on the original sizes
+	ObjectKey    string // key of the file in the bucket
 }
 
 func (ps *PictureSize) DriverMarshal(value controllerModel.PictureSize) {
 	ps.ID = value.ID
 	ps.CreationDate = value.CreationDate
+	ps.ObjectKey = value.ObjectKey
 
 	var box Box
 	box.DriverMarshal(value.Box)
@@ -110,6 +194,7 @@ func (ps PictureSize) DriverUnmarshal() controllerModel.PictureSize {
 		ID:           ps.ID,
 		CreationDate: ps.CreationDate,
 		Box:          ps.Box.DriverUnmarshal(),
+		ObjectKey:    ps.ObjectKey,
 	}
 }
 
@@ -142,6 +227,8 @@ type PictureTag struct {
 	CreationDate   time.Time
 	OriginName     string
 	BoxInformation model.Nullable[BoxInformation] // origin informations
+	Segmentation   model.Nullable[Segmentation]
+	Keypoints      model.Nullable[Keypoints]
 }
 
 func (pt *PictureTag) DriverMarshal(value controllerModel.PictureTag) {
@@ -155,6 +242,18 @@ func (pt *PictureTag) DriverMarshal(value controllerModel.PictureTag) {
 		boxInformation.DriverMarshal(value.BoxInformation.Body)
 		pt.BoxInformation = model.NewNullable(boxInformation)
 	}
+
+	if value.Segmentation.Valid {
+		var segmentation Segmentation
+		segmentation.DriverMarshal(value.Segmentation.Body)
+		pt.Segmentation = model.NewNullable(segmentation)
+	}
+
+	if value.Keypoints.Valid {
+		var keypoints Keypoints
+		keypoints.DriverMarshal(value.Keypoints.Body)
+		pt.Keypoints = model.NewNullable(keypoints)
+	}
 }
 
 func (pt PictureTag) DriverUnmarshal() controllerModel.PictureTag {
@@ -162,6 +261,14 @@ func (pt PictureTag) DriverUnmarshal() controllerModel.PictureTag {
 	if pt.BoxInformation.Valid {
 		boxInformation = model.NewNullable(pt.BoxInformation.Body.DriverUnmarshal())
 	}
+	var segmentation model.Nullable[controllerModel.Segmentation]
+	if pt.Segmentation.Valid {
+		segmentation = model.NewNullable(pt.Segmentation.Body.DriverUnmarshal())
+	}
+	var keypoints model.Nullable[controllerModel.Keypoints]
+	if pt.Keypoints.Valid {
+		keypoints = model.NewNullable(pt.Keypoints.Body.DriverUnmarshal())
+	}
 
 	return controllerModel.PictureTag{
 		ID:             pt.ID,
@@ -169,6 +276,8 @@ func (pt PictureTag) DriverUnmarshal() controllerModel.PictureTag {
 		CreationDate:   pt.CreationDate,
 		OriginName:     pt.OriginName,
 		BoxInformation: boxInformation,
+		Segmentation:   segmentation,
+		Keypoints:      keypoints,
 	}
 }
 
@@ -201,3 +310,168 @@ func (bi BoxInformation) DriverUnmarshal() controllerModel.BoxInformation {
 		Confidence:    bi.Confidence,
 	}
 }
+
+type PictureTransition struct {
+	ID           model.UUID
+	From         string // state before the transition
+	To           string // state after the transition
+	Actor        string // who made the transition
+	Reason       string // why the transition was made
+	Override     bool   // validators skipped
+	CreationDate time.Time
+}
+
+func (pt *PictureTransition) DriverMarshal(value controllerModel.PictureTransition) {
+	pt.ID = value.ID
+	pt.From = value.From
+	pt.To = value.To
+	pt.Actor = value.Actor
+	pt.Reason = value.Reason
+	pt.Override = value.Override
+	pt.CreationDate = value.CreationDate
+}
+
+func (pt PictureTransition) DriverUnmarshal() controllerModel.PictureTransition {
+	return controllerModel.PictureTransition{
+		ID:           pt.ID,
+		From:         pt.From,
+		To:           pt.To,
+		Actor:        pt.Actor,
+		Reason:       pt.Reason,
+		Override:     pt.Override,
+		CreationDate: pt.CreationDate,
+	}
+}
+
+type PictureQuality struct {
+	Width       int
+	Height      int
+	Blur        float64 // variance of the Laplacian
+	Luminance   float64 // mean luminance
+	DarkRatio   float64
+	BrightRatio float64
+	Hash        string // difference hash of the luminance
+}
+
+func (pq *PictureQuality) DriverMarshal(value controllerModel.PictureQuality) {
+	pq.Width = value.Width
+	pq.Height = value.Height
+	pq.Blur = value.Blur
+	pq.Luminance = value.Luminance
+	pq.DarkRatio = value.DarkRatio
+	pq.BrightRatio = value.BrightRatio
+	pq.Hash = value.Hash
+}
+
+func (pq PictureQuality) DriverUnmarshal() controllerModel.PictureQuality {
+	return controllerModel.PictureQuality{
+		Width:       pq.Width,
+		Height:      pq.Height,
+		Blur:        pq.Blur,
+		Luminance:   pq.Luminance,
+		DarkRatio:   pq.DarkRatio,
+		BrightRatio: pq.BrightRatio,
+		Hash:        pq.Hash,
+	}
+}
+
+type Segmentation struct {
+	Model         sql.NullString // name of the model used for the segmentation
+	Weights       sql.NullString // weights of the model used for the segmentation
+	PictureSizeID model.UUID     // reference to the anchor point
+	Polygons      [][]Point      // outlines relative to the anchor
+	Mask          model.Nullable[Mask]
+	Confidence    sql.NullFloat64 // accuracy of the model
+}
+
+func (s *Segmentation) DriverMarshal(value controllerModel.Segmentation) {
+	s.Model = value.Model
+	s.Weights = value.Weights
+	s.PictureSizeID = value.PictureSizeID
+	s.Polygons = make([][]Point, 0, len(value.Polygons))
+	for _, polygon := range value.Polygons {
+		points := make([]Point, 0, len(polygon))
+		for _, point := range polygon {
+			points = append(points, Point{X: point.X, Y: point.Y})
+		}
+		s.Polygons = append(s.Polygons, points)
+	}
+	if value.Mask.Valid {
+		s.Mask = model.NewNullable(Mask{Width: value.Mask.Body.Width, Height: value.Mask.Body.Height, Counts: value.Mask.Body.Counts})
+	}
+	s.Confidence = value.Confidence
+}
+
+func (s Segmentation) DriverUnmarshal() controllerModel.Segmentation {
+	polygons := make([]controllerModel.Polygon, 0, len(s.Polygons))
+	for _, points := range s.Polygons {
+		polygon := make(controllerModel.Polygon, 0, len(points))
+		for _, point := range points {
+			polygon = append(polygon, controllerModel.Point{X: point.X, Y: point.Y})
+		}
+		polygons = append(polygons, polygon)
+	}
+	var mask model.Nullable[controllerModel.Mask]
+	if s.Mask.Valid {
+		mask = model.NewNullable(controllerModel.Mask{Width: s.Mask.Body.Width, Height: s.Mask.Body.Height, Counts: s.Mask.Body.Counts})
+	}
+	return controllerModel.Segmentation{
+		Model:         s.Model,
+		Weights:       s.Weights,
+		PictureSizeID: s.PictureSizeID,
+		Polygons:      polygons,
+		Mask:          mask,
+		Confidence:    s.Confidence,
+	}
+}
+
+type Point struct {
+	X float64
+	Y float64
+}
+
+type Mask struct {
+	Width  int
+	Height int
+	Counts []int // run-length encoding in column-major order, starting with zeros
+}
+
+type Keypoints struct {
+	Model         sql.NullString  // name of the model used for the pose estimation
+	Weights       sql.NullString  // weights of the model used for the pose estimation
+	PictureSizeID model.UUID      // reference to the anchor point
+	Points        []Keypoint      // positions relative to the anchor
+	Confidence    sql.NullFloat64 // accuracy of the model
+}
+
+func (k *Keypoints) DriverMarshal(value controllerModel.Keypoints) {
+	k.Model = value.Model
+	k.Weights = value.Weights
+	k.PictureSizeID = value.PictureSizeID
+	k.Points = make([]Keypoint, 0, len(value.Points))
+	for _, point := range value.Points {
+		k.Points = append(k.Points, Keypoint{Name: point.Name, X: point.X, Y: point.Y, Visibility: point.Visibility})
+	}
+	k.Confidence = value.Confidence
+}
+
+func (k Keypoints) DriverUnmarshal() controllerModel.Keypoints {
+	points := make([]controllerModel.Keypoint, 0, len(k.Points))
+	for _, point := range k.Points {
+		points = append(points, controllerModel.Keypoint{Name: point.Name, X: point.X, Y: point.Y, Visibility: point.Visibility})
+	}
+	return controllerModel.Keypoints{
+		Model:         k.Model,
+		Weights:       k.Weights,
+		PictureSizeID: k.PictureSizeID,
+		Points:        points,
+		Confidence:    k.Confidence,
+	}
+}
+
+type Keypoint struct {
+	Name       string
+	X          float64
+	Y          float64
+	Visibility int // 0 not labeled, 1 occluded, 2 visible
+}
@@ -7,11 +7,17 @@ import (
 )
 
 type Tag struct {
-	Type         string     `dynamodbav:"Type"` // PK
-	ID           model.UUID `dynamodbav:"ID"`   // SK
-	Name         string     `dynamodbav:"Name"`
-	CreationDate time.Time  `dynamodbav:"CreationDate"`
-	OriginName   string     `dynamodbav:"OriginName"` // user to create tag
+	Type         string                   `dynamodbav:"Type"` // PK
+	ID           model.UUID               `dynamodbav:"ID"`   // SK
+	Name         string                   `dynamodbav:"Name"`
+	CreationDate time.Time                `dynamodbav:"CreationDate"`
+	OriginName   string                   `dynamodbav:"OriginName"` // user to create tag
+	Skeleton     model.Nullable[Skeleton] `dynamodbav:"Skeleton"`
+	Parent       string                   `dynamodbav:"Parent"`
+	Synonyms     []string                 `dynamodbav:"Synonyms"`
+	Mode         string                   `dynamodbav:"Mode"`
+	TargetCount  int                      `dynamodbav:"TargetCount"`
+	MaxPages     int                      `dynamodbav:"MaxPages"`
 }
 
 func (t *Tag) DriverMarshal(value controllerModel.Tag) {
@@ -20,14 +26,37 @@ func (t *Tag) DriverMarshal(value controllerModel.Tag) {
 	t.Name = value.Name
 	t.CreationDate = value.CreationDate
 	t.OriginName = value.OriginName
+	t.Parent = value.Parent
+	t.Synonyms = value.Synonyms
+	t.Mode = value.Mode
+	t.TargetCount = value.TargetCount
+	t.MaxPages = value.MaxPages
+	if value.Skeleton.Valid {
+		t.Skeleton = model.NewNullable(Skeleton{Keypoints: value.Skeleton.Body.Keypoints, Links: value.Skeleton.Body.Links})
+	}
 }
 
 func (t Tag) DriverUnmarshal() controllerModel.Tag {
+	var skeleton model.Nullable[controllerModel.Skeleton]
+	if t.Skeleton.Valid {
+		skeleton = model.NewNullable(controllerModel.Skeleton{Keypoints: t.Skeleton.Body.Keypoints, Links: t.Skeleton.Body.Links})
+	}
 	return controllerModel.Tag{
 		Type:         t.Type,
 		ID:           t.ID,
 		Name:         t.Name,
 		CreationDate: t.CreationDate,
 		OriginName:   t.OriginName,
+		Skeleton:     skeleton,
+		Parent:       t.Parent,
+		Synonyms:     t.Synonyms,
+		Mode:         t.Mode,
+		TargetCount:  t.TargetCount,
+		MaxPages:     t.MaxPages,
 	}
 }
+
+type Skeleton struct {
+	Keypoints []string
+	Links     [][2]int // pairs of indexes in Keypoints
+}
@@ -0,0 +1,48 @@
+package dynamodb
+
+import (
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+	"time"
+)
+
+type Task struct {
+	ID             model.UUID `dynamodbav:"ID"`    // PK picture ID
+	State          string     `dynamodbav:"State"` // SK
+	Origin         string     `dynamodbav:"Origin"`
+	User           string     `dynamodbav:"User"`
+	Status         string     `dynamodbav:"Status"`
+	Priority       float64    `dynamodbav:"Priority"`
+	LeaseDate      time.Time  `dynamodbav:"LeaseDate"`
+	ExpirationDate time.Time  `dynamodbav:"ExpirationDate"`
+	CompletionDate time.Time  `dynamodbav:"CompletionDate"`
+	Version        int        `dynamodbav:"Version"`
+}
+
+func (t *Task) DriverMarshal(value controllerModel.Task) {
+	t.ID = value.ID
+	t.State = value.State
+	t.Origin = value.Origin
+	t.User = value.User
+	t.Status = value.Status
+	t.Priority = value.Priority
+	t.LeaseDate = value.LeaseDate
+	t.ExpirationDate = value.ExpirationDate
+	t.CompletionDate = value.CompletionDate
+	t.Version = value.Version
+}
+
+func (t Task) DriverUnmarshal() controllerModel.Task {
+	return controllerModel.Task{
+		ID:             t.ID,
+		State:          t.State,
+		Origin:         t.Origin,
+		User:           t.User,
+		Status:         t.Status,
+		Priority:       t.Priority,
+		LeaseDate:      t.LeaseDate,
+		ExpirationDate: t.ExpirationDate,
+		CompletionDate: t.CompletionDate,
+		Version:        t.Version,
+	}
+}
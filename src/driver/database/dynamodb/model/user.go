@@ -12,6 +12,8 @@ type User struct {
 	Name         string     `dynamodbav:"Name"`     // userName
 	OriginID     string     `dynamodbav:"OriginID"` // ID from the original website
 	CreationDate time.Time  `dynamodbav:"CreationDate"`
+	Status       string     `dynamodbav:"Status"`
+	Quota        int        `dynamodbav:"Quota"`
 }
 
 func (u *User) DriverMarshal(value controllerModel.User) {
@@ -20,6 +22,8 @@ func (u *User) DriverMarshal(value controllerModel.User) {
 	u.Name = value.Name
 	u.OriginID = value.OriginID
 	u.CreationDate = value.CreationDate
+	u.Status = value.Status
+	u.Quota = value.Quota
 }
 
 func (u User) DriverUnmarshal() controllerModel.User {
@@ -29,5 +33,7 @@ func (u User) DriverUnmarshal() controllerModel.User {
 		Name:         u.Name,
 		OriginID:     u.OriginID,
 		CreationDate: u.CreationDate,
+		Status:       u.Status,
+		Quota:        u.Quota,
 	}
 }
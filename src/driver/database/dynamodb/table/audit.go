@@ -0,0 +1,173 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	awsDynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	dynamodbModel "scraper-backend/src/driver/database/dynamodb/model"
+	"scraper-backend/src/driver/model"
+)
+
+// the audit log is append-only, there is no update nor delete
+type TableAudit struct {
+	DynamoDbClient *awsDynamodb.Client
+	TableName      string
+	PrimaryKeyName string // Key
+	PrimaryKeyType string
+	SortKeyName    string // ID
+	SortKeyType    string
+}
+
+// an entry is never overwritten
+func (table TableAudit) CreateAudit(ctx context.Context, audit controllerModel.Audit) error {
+	put, err := table.putAudit(audit)
+	if err != nil {
+		return err
+	}
+	_, err = table.DynamoDbClient.PutItem(ctx, &awsDynamodb.PutItemInput{
+		TableName:                 put.TableName,
+		Item:                      put.Item,
+		ConditionExpression:       put.ConditionExpression,
+		ExpressionAttributeNames:  put.ExpressionAttributeNames,
+		ExpressionAttributeValues: put.ExpressionAttributeValues,
+	})
+	return err
+}
+
+func (table TableAudit) putAudit(audit controllerModel.Audit) (*types.Put, error) {
+	var driverAudit dynamodbModel.Audit
+	driverAudit.DriverMarshal(audit)
+
+	item, err := attributevalue.MarshalMap(driverAudit)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(expression.AttributeNotExists(expression.Name(table.PrimaryKeyName))).Build()
+	if err != nil {
+		return nil, err
+	}
+	return &types.Put{
+		TableName:                 aws.String(table.TableName),
+		Item:                      item,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}, nil
+}
+
+// the condition of the item written with its audit has failed
+var errConditionFailed = errors.New("condition failed")
+
+// writes the item of a mutation and its audit entry in the same transaction, the item alone when there is no entry.
+// errConditionFailed is returned when the condition of the item fails
+func (table TableAudit) writeAudited(ctx context.Context, item types.TransactWriteItem, audit *controllerModel.Audit) error {
+	items := []types.TransactWriteItem{item}
+	if audit != nil {
+		put, err := table.putAudit(*audit)
+		if err != nil {
+			return err
+		}
+		items = append(items, types.TransactWriteItem{Put: put})
+	}
+	_, err := table.DynamoDbClient.TransactWriteItems(ctx, &awsDynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	// the cancellation reasons are not always returned, the caller reads the item again to tell
+	var canceledErr *types.TransactionCanceledException
+	if errors.As(err, &canceledErr) && (len(canceledErr.CancellationReasons) == 0 || aws.ToString(canceledErr.CancellationReasons[0].Code) == "ConditionalCheckFailed") {
+		return fmt.Errorf("%w: %v", errConditionFailed, err)
+	}
+	return err
+}
+
+// entries of one entity
+func (table TableAudit) ReadAudits(ctx context.Context, entity string, primaryKey string, sortKey model.UUID) ([]controllerModel.Audit, error) {
+	keyEx := expression.Key(table.PrimaryKeyName).Equal(expression.Value(dynamodbModel.AuditKey(entity, primaryKey, sortKey)))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	queryInput := awsDynamodb.QueryInput{
+		TableName:                 aws.String(table.TableName),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	}
+
+	var audits []dynamodbModel.Audit
+	for {
+		response, err := table.DynamoDbClient.Query(ctx, &queryInput)
+		if err != nil {
+			return nil, err
+		}
+
+		var pageAudits []dynamodbModel.Audit
+		if err := attributevalue.UnmarshalListOfMaps(response.Items, &pageAudits); err != nil {
+			return nil, err
+		}
+		audits = append(audits, pageAudits...)
+
+		if len(response.LastEvaluatedKey) == 0 {
+			break
+		}
+		queryInput.ExclusiveStartKey = response.LastEvaluatedKey
+	}
+
+	return driverUnmarshalAudits(audits), nil
+}
+
+// entries of every entity matching the filter
+func (table TableAudit) ScanAudits(ctx context.Context, filter *expression.ConditionBuilder) ([]controllerModel.Audit, error) {
+	scanInput := awsDynamodb.ScanInput{
+		TableName: aws.String(table.TableName),
+	}
+	if filter != nil {
+		expr, err := expression.NewBuilder().WithFilter(*filter).Build()
+		if err != nil {
+			return nil, err
+		}
+		scanInput.ExpressionAttributeNames = expr.Names()
+		scanInput.ExpressionAttributeValues = expr.Values()
+		scanInput.FilterExpression = expr.Filter()
+	}
+
+	// a scan stops after 1MB, continue from the last key until the end of the table
+	var audits []dynamodbModel.Audit
+	for {
+		response, err := table.DynamoDbClient.Scan(ctx, &scanInput)
+		if err != nil {
+			return nil, err
+		}
+
+		var pageAudits []dynamodbModel.Audit
+		if err := attributevalue.UnmarshalListOfMaps(response.Items, &pageAudits); err != nil {
+			return nil, err
+		}
+		audits = append(audits, pageAudits...)
+
+		if len(response.LastEvaluatedKey) == 0 {
+			break
+		}
+		scanInput.ExclusiveStartKey = response.LastEvaluatedKey
+	}
+
+	return driverUnmarshalAudits(audits), nil
+}
+
+func driverUnmarshalAudits(audits []dynamodbModel.Audit) []controllerModel.Audit {
+	controllerAudits := make([]controllerModel.Audit, 0, len(audits))
+	for _, audit := range audits {
+		controllerAudits = append(controllerAudits, audit.DriverUnmarshal())
+	}
+	return controllerAudits
+}
@@ -2,12 +2,15 @@ package dynamodb
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	awsDynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"golang.org/x/exp/slices"
 
 	controllerModel "scraper-backend/src/adapter/controller/model"
 	dynamodbModel "scraper-backend/src/driver/database/dynamodb/model"
@@ -21,9 +24,16 @@ type TablePicture struct {
 	PrimaryKeyType string
 	SortKeyName    string // ID
 	SortKeyType    string
+	Audit          TableAudit // entries written in the same transaction as the mutations
 }
 
 func (table TablePicture) ReadPicture(ctx context.Context, primaryKey string, sortKey model.UUID) (*controllerModel.Picture, error) {
+	picture, _, err := table.readPictureItem(ctx, primaryKey, sortKey)
+	return picture, err
+}
+
+// the picture and whether its tags are still stored in a list, as they were before being stored by ID
+func (table TablePicture) readPictureItem(ctx context.Context, primaryKey string, sortKey model.UUID) (*controllerModel.Picture, bool, error) {
 	input := &awsDynamodb.GetItemInput{
 		TableName: aws.String(table.TableName),
 		Key: map[string]types.AttributeValue{
@@ -38,16 +48,20 @@ func (table TablePicture) ReadPicture(ctx context.Context, primaryKey string, so
 
 	response, err := table.DynamoDbClient.GetItem(ctx, input)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	if response.Item == nil {
+		return nil, false, nil // no picture found
 	}
 
 	var picture dynamodbModel.Picture
 	err = attributevalue.UnmarshalMap(response.Item, &picture)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
+	_, listed := response.Item["Tags"].(*types.AttributeValueMemberL)
 
-	return picture.DriverUnmarshal(), nil
+	return picture.DriverUnmarshal(), listed, nil
 }
 
 func (table TablePicture) ReadPictures(ctx context.Context, projection *expression.ProjectionBuilder, filter *expression.ConditionBuilder) ([]controllerModel.Picture, error) {
@@ -83,14 +97,24 @@ func (table TablePicture) ReadPictures(ctx context.Context, projection *expressi
 		scanInput.ProjectionExpression = expr.Projection()
 	}
 
-	response, err = table.DynamoDbClient.Scan(ctx, &scanInput)
-	if err != nil {
-		return nil, err
-	}
+	// a scan stops after 1MB, continue from the last key until the end of the table
+	for {
+		response, err = table.DynamoDbClient.Scan(ctx, &scanInput)
+		if err != nil {
+			return nil, err
+		}
 
-	err = attributevalue.UnmarshalListOfMaps(response.Items, &pictures)
-	if err != nil {
-		return nil, err
+		var pagePictures []dynamodbModel.Picture
+		err = attributevalue.UnmarshalListOfMaps(response.Items, &pagePictures)
+		if err != nil {
+			return nil, err
+		}
+		pictures = append(pictures, pagePictures...)
+
+		if len(response.LastEvaluatedKey) == 0 {
+			break
+		}
+		scanInput.ExclusiveStartKey = response.LastEvaluatedKey
 	}
 
 	var controllerPictures []controllerModel.Picture
@@ -102,124 +126,158 @@ func (table TablePicture) ReadPictures(ctx context.Context, projection *expressi
 	return controllerPictures, nil
 }
 
-func (table TablePicture) CreatePicture(ctx context.Context, id model.UUID, picture controllerModel.Picture) error {
-	var driverPicture dynamodbModel.Picture
-	driverPicture.DriverMarshal(picture)
-	driverPicture.ID = id
-
-	item, err := attributevalue.MarshalMap(driverPicture)
+// puts the picture when it is missing or still at its version, the stored version is incremented
+func (table TablePicture) CreatePicture(ctx context.Context, id model.UUID, picture controllerModel.Picture, audit *controllerModel.Audit) error {
+	picture.ID = id
+	put, err := table.putPicture(picture)
 	if err != nil {
 		return err
 	}
-	if _, err := table.DynamoDbClient.PutItem(ctx, &awsDynamodb.PutItemInput{
-		TableName: aws.String(table.TableName),
-		Item:      item,
-	}); err != nil {
-		return err
+	err = table.Audit.writeAudited(ctx, types.TransactWriteItem{Put: put}, audit)
+	if errors.Is(err, errConditionFailed) {
+		return table.conflictError(ctx, picture.Origin, picture.ID)
 	}
-	return nil
+	return err
 }
 
-func (table TablePicture) DeletePicture(ctx context.Context, primaryKey string, sortKey model.UUID) error {
-	_, err := table.DynamoDbClient.DeleteItem(ctx, &awsDynamodb.DeleteItemInput{
-		TableName: aws.String(table.TableName),
-		Key: map[string]types.AttributeValue{
-			table.PrimaryKeyName: &types.AttributeValueMemberS{
-				Value: primaryKey,
-			},
-			table.SortKeyName: &types.AttributeValueMemberB{
-				Value: sortKey[:],
-			},
-		},
-	})
-	if err != nil {
-		return err
+// maximum amount of items in one TransactWriteItems request
+const transactWriteSize = 25
+
+func (table TablePicture) CreatePictures(ctx context.Context, pictures []controllerModel.Picture) error {
+	items := make([]types.TransactWriteItem, 0, len(pictures))
+	for _, picture := range pictures {
+		put, err := table.putPicture(picture)
+		if err != nil {
+			return err
+		}
+		items = append(items, types.TransactWriteItem{Put: put})
 	}
-	return err
+	return table.transactWrite(ctx, pictures, items)
 }
 
-func (table TablePicture) DeletePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, tagID model.UUID) error {
-	// Build the update expression
-	updateExpr, err := expression.NewBuilder().
-		WithUpdate(expression.Delete(expression.Name("tags"), expression.Value(tagID.String()))).
-		Build()
-	if err != nil {
-		return err
+func (table TablePicture) DeletePictures(ctx context.Context, pictures []controllerModel.Picture) error {
+	items := make([]types.TransactWriteItem, 0, len(pictures))
+	for _, picture := range pictures {
+		delete, err := table.deletePicture(picture.Origin, picture.ID, picture.Version)
+		if err != nil {
+			return err
+		}
+		items = append(items, types.TransactWriteItem{Delete: delete})
 	}
+	return table.transactWrite(ctx, pictures, items)
+}
 
-	// Set the primary key values
-	key := map[string]types.AttributeValue{
-		table.PrimaryKeyName: &types.AttributeValueMemberS{
-			Value: primaryKey,
-		},
-		table.SortKeyName: &types.AttributeValueMemberB{
-			Value: sortKey[:],
-		},
+// writes the items of the pictures by chunks, every chunk is written entirely or not at all
+func (table TablePicture) transactWrite(ctx context.Context, pictures []controllerModel.Picture, items []types.TransactWriteItem) error {
+	for start := 0; start < len(items); start += transactWriteSize {
+		end := start + transactWriteSize
+		if end > len(items) {
+			end = len(items)
+		}
+		_, err := table.DynamoDbClient.TransactWriteItems(ctx, &awsDynamodb.TransactWriteItemsInput{
+			TransactItems: items[start:end],
+		})
+		var canceledErr *types.TransactionCanceledException
+		if errors.As(err, &canceledErr) {
+			// the cancellation reasons are not always returned, the versions are read again instead
+			for _, picture := range pictures[start:end] {
+				current, readErr := table.ReadPicture(ctx, picture.Origin, picture.ID)
+				if readErr != nil {
+					return readErr
+				}
+				if current != nil && current.Version != picture.Version {
+					return controllerModel.PictureConflictError{Origin: picture.Origin, ID: picture.ID, Version: current.Version}
+				}
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// Update the item in the table
-	_, err = table.DynamoDbClient.UpdateItem(ctx, &awsDynamodb.UpdateItemInput{
-		TableName:        aws.String(table.TableName),
-		Key:              key,
-		UpdateExpression: updateExpr.Update(),
-	})
+func (table TablePicture) DeletePicture(ctx context.Context, primaryKey string, sortKey model.UUID, version int, audit *controllerModel.Audit) error {
+	delete, err := table.deletePicture(primaryKey, sortKey, version)
 	if err != nil {
 		return err
 	}
-
-	return nil
+	err = table.Audit.writeAudited(ctx, types.TransactWriteItem{Delete: delete}, audit)
+	if errors.Is(err, errConditionFailed) {
+		return table.conflictError(ctx, primaryKey, sortKey)
+	}
+	return err
 }
 
-func (table TablePicture) CreatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, tagID model.UUID, tag controllerModel.PictureTag) error {
-	var driverTag dynamodbModel.PictureTag
-	driverTag.DriverMarshal(tag)
+func (table TablePicture) putPicture(picture controllerModel.Picture) (*types.Put, error) {
+	var driverPicture dynamodbModel.Picture
+	driverPicture.DriverMarshal(picture)
+	driverPicture.Version = picture.Version + 1
 
-	// Build the update expression
-	tagMap := map[model.UUID]dynamodbModel.PictureTag{tagID: driverTag}
-	updateExpr, err := expression.NewBuilder().
-		WithUpdate(expression.Add(expression.Name("tags"), expression.Value(tagMap))).
-		Build()
+	item, err := attributevalue.MarshalMap(driverPicture)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	// Set the primary key values
-	key := map[string]types.AttributeValue{
-		table.PrimaryKeyName: &types.AttributeValueMemberS{
-			Value: primaryKey,
-		},
-		table.SortKeyName: &types.AttributeValueMemberB{
-			Value: sortKey[:],
-		},
+	expr, err := expression.NewBuilder().WithCondition(table.missingOrVersionCondition(picture.Version)).Build()
+	if err != nil {
+		return nil, err
 	}
+	return &types.Put{
+		TableName:                 aws.String(table.TableName),
+		Item:                      item,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}, nil
+}
 
-	// Update the item in the table
-	_, err = table.DynamoDbClient.UpdateItem(ctx, &awsDynamodb.UpdateItemInput{
-		TableName:        aws.String(table.TableName),
-		Key:              key,
-		UpdateExpression: updateExpr.Update(),
-	})
+// deleting a missing picture does nothing
+func (table TablePicture) deletePicture(primaryKey string, sortKey model.UUID, version int) (*types.Delete, error) {
+	expr, err := expression.NewBuilder().WithCondition(table.missingOrVersionCondition(version)).Build()
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	return nil
+	return &types.Delete{
+		TableName:                 aws.String(table.TableName),
+		Key:                       table.key(primaryKey, sortKey),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}, nil
 }
 
-func (table TablePicture) UpdatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, tagID model.UUID, tag controllerModel.PictureTag) error {
-	var driverTag dynamodbModel.PictureTag
-	driverTag.DriverMarshal(tag)
+func (table TablePicture) missingOrVersionCondition(version int) expression.ConditionBuilder {
+	return expression.Name(table.PrimaryKeyName).AttributeNotExists().Or(versionCondition(version))
+}
 
-	// Build the update expression
-	updateExpr, err := expression.NewBuilder().
-		WithUpdate(expression.Set(expression.Name("tags"), expression.Value(map[model.UUID]dynamodbModel.PictureTag{tagID: driverTag}))).
-		Build()
+// the version of the picture after a failed condition
+func (table TablePicture) conflictError(ctx context.Context, primaryKey string, sortKey model.UUID) error {
+	picture, err := table.ReadPicture(ctx, primaryKey, sortKey)
 	if err != nil {
 		return err
 	}
+	if picture == nil {
+		return fmt.Errorf("picture %s/%s not found", primaryKey, sortKey)
+	}
+	return controllerModel.PictureConflictError{Origin: primaryKey, ID: sortKey, Version: picture.Version}
+}
 
-	// Set the primary key values
-	key := map[string]types.AttributeValue{
+// the picture has not been written since the version was read, the items written before the versions have none
+func versionCondition(version int) expression.ConditionBuilder {
+	condition := expression.Name("Version").Equal(expression.Value(version))
+	if version == 0 {
+		condition = expression.Name("Version").AttributeNotExists().Or(condition)
+	}
+	return condition
+}
+
+func versionUpdate(update expression.UpdateBuilder) expression.UpdateBuilder {
+	return update.Set(expression.Name("Version"), expression.Plus(expression.Name("Version").IfNotExists(expression.Value(0)), expression.Value(1)))
+}
+
+func (table TablePicture) key(primaryKey string, sortKey model.UUID) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
 		table.PrimaryKeyName: &types.AttributeValueMemberS{
 			Value: primaryKey,
 		},
@@ -227,52 +285,96 @@ func (table TablePicture) UpdatePictureTag(ctx context.Context, primaryKey strin
 			Value: sortKey[:],
 		},
 	}
+}
 
-	// Update the item in the table
-	_, err = table.DynamoDbClient.UpdateItem(ctx, &awsDynamodb.UpdateItemInput{
-		TableName:        aws.String(table.TableName),
-		Key:              key,
-		UpdateExpression: updateExpr.Update(),
-	})
+// writes one tag of the picture, the other tags are left untouched. the tag is removed when it is nil.
+// a picture whose tags are still in a list is written whole instead, its tags are then stored by ID
+func (table TablePicture) updatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID, tagExists bool, tag *controllerModel.PictureTag, audit *controllerModel.Audit) error {
+	tagName := expression.Name("Tags." + tagID.String())
+	update := expression.Remove(tagName)
+	if tag != nil {
+		var driverTag dynamodbModel.PictureTag
+		driverTag.DriverMarshal(*tag)
+		update = expression.Set(tagName, expression.Value(driverTag))
+	}
+	condition := versionCondition(version).And(expression.Name("Tags").AttributeType(expression.Map))
+	if tagExists {
+		condition = condition.And(tagName.AttributeExists())
+	} else {
+		condition = condition.And(tagName.AttributeNotExists())
+	}
+	expr, err := expression.NewBuilder().
+		WithUpdate(versionUpdate(update)).
+		WithCondition(condition).
+		Build()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	err = table.Audit.writeAudited(ctx, types.TransactWriteItem{Update: &types.Update{
+		TableName:                 aws.String(table.TableName),
+		Key:                       table.key(primaryKey, sortKey),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}}, audit)
+	if errors.Is(err, errConditionFailed) {
+		return table.conditionError(ctx, primaryKey, sortKey, version, tagID, tagExists, tag, audit)
+	}
+	return err
 }
 
-func (table TablePicture) CreatePictureSize(ctx context.Context, primaryKey string, sortKey model.UUID, size controllerModel.PictureSize) error {
-	var driverSize dynamodbModel.PictureSize
-	driverSize.DriverMarshal(size)
-
-	// Build the update expression
-	sizeMap := map[model.UUID]dynamodbModel.PictureSize{model.NewUUID(): driverSize}
-	updateExpr, err := expression.NewBuilder().
-		WithUpdate(expression.Add(expression.Name("size"), expression.Value(sizeMap))).
-		Build()
+// tells which part of the condition of a tag write has failed, or writes the picture whole when its tags are still in a list
+func (table TablePicture) conditionError(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID, tagExists bool, tag *controllerModel.PictureTag, audit *controllerModel.Audit) error {
+	picture, listed, err := table.readPictureItem(ctx, primaryKey, sortKey)
 	if err != nil {
 		return err
 	}
-
-	// Set the primary key values
-	key := map[string]types.AttributeValue{
-		table.PrimaryKeyName: &types.AttributeValueMemberS{
-			Value: primaryKey,
-		},
-		table.SortKeyName: &types.AttributeValueMemberB{
-			Value: sortKey[:],
-		},
+	if picture == nil {
+		return fmt.Errorf("picture %s/%s not found", primaryKey, sortKey)
+	}
+	if picture.Version != version {
+		return controllerModel.PictureConflictError{Origin: primaryKey, ID: sortKey, Version: picture.Version}
+	}
+	idx := slices.IndexFunc(picture.Tags, func(tag controllerModel.PictureTag) bool { return tag.ID == tagID })
+	if tagExists && idx == -1 {
+		return fmt.Errorf("tag %s not found in picture %s/%s", tagID, primaryKey, sortKey)
+	}
+	if !tagExists && idx != -1 {
+		return fmt.Errorf("tag %s already exists in picture %s/%s", tagID, primaryKey, sortKey)
+	}
+	if !listed {
+		return table.conflictError(ctx, primaryKey, sortKey)
 	}
 
-	// Update the item in the table
-	_, err = table.DynamoDbClient.UpdateItem(ctx, &awsDynamodb.UpdateItemInput{
-		TableName:        aws.String(table.TableName),
-		Key:              key,
-		UpdateExpression: updateExpr.Update(),
-	})
+	switch {
+	case tag == nil:
+		picture.Tags = slices.Delete(picture.Tags, idx, idx+1)
+	case idx == -1:
+		picture.Tags = append(picture.Tags, *tag)
+	default:
+		picture.Tags[idx] = *tag
+	}
+	put, err := table.putPicture(*picture)
 	if err != nil {
 		return err
 	}
+	err = table.Audit.writeAudited(ctx, types.TransactWriteItem{Put: put}, audit)
+	if errors.Is(err, errConditionFailed) {
+		return table.conflictError(ctx, primaryKey, sortKey)
+	}
+	return err
+}
 
-	return nil
+func (table TablePicture) DeletePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID, audit *controllerModel.Audit) error {
+	return table.updatePictureTag(ctx, primaryKey, sortKey, version, tagID, true, nil, audit)
+}
+
+func (table TablePicture) CreatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tag controllerModel.PictureTag, audit *controllerModel.Audit) error {
+	return table.updatePictureTag(ctx, primaryKey, sortKey, version, tag.ID, false, &tag, audit)
+}
+
+func (table TablePicture) UpdatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tag controllerModel.PictureTag, audit *controllerModel.Audit) error {
+	return table.updatePictureTag(ctx, primaryKey, sortKey, version, tag.ID, true, &tag, audit)
 }
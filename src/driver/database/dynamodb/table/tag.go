@@ -27,6 +27,7 @@ type TableTag struct {
 	PrimaryKeyType string
 	SortKeyName    string // ID
 	SortKeyType    string
+	Audit          TableAudit // entries written in the same transaction as the mutations
 }
 
 func checkTablePK(primarykey string) error {
@@ -39,7 +40,7 @@ func checkTablePK(primarykey string) error {
 	}
 }
 
-func (table TableTag) CreateTag(ctx context.Context, tag controllerModel.Tag) error {
+func (table TableTag) CreateTag(ctx context.Context, tag controllerModel.Tag, audit *controllerModel.Audit) error {
 	var driverTag dynamodbModel.Tag
 	driverTag.DriverMarshal(tag)
 
@@ -48,22 +49,17 @@ func (table TableTag) CreateTag(ctx context.Context, tag controllerModel.Tag) er
 		return err
 	}
 
-	_, err = table.DynamoDbClient.PutItem(ctx, &dynamodb.PutItemInput{
+	return table.Audit.writeAudited(ctx, types.TransactWriteItem{Put: &types.Put{
 		TableName: aws.String(table.TableName),
 		Item:      item,
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	}}, audit)
 }
 
-func (table TableTag) DeleteTag(ctx context.Context, primaryKey string, sortKey model.UUID) error {
+func (table TableTag) DeleteTag(ctx context.Context, primaryKey string, sortKey model.UUID, audit *controllerModel.Audit) error {
 	if err := checkTablePK(primaryKey); err != nil {
 		return err
 	}
-	_, err := table.DynamoDbClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+	return table.Audit.writeAudited(ctx, types.TransactWriteItem{Delete: &types.Delete{
 		TableName: aws.String(table.TableName),
 		Key: map[string]types.AttributeValue{
 			table.PrimaryKeyName: &types.AttributeValueMemberS{
@@ -73,11 +69,7 @@ func (table TableTag) DeleteTag(ctx context.Context, primaryKey string, sortKey
 				Value: sortKey[:],
 			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-	return nil
+	}}, audit)
 }
 
 func (table TableTag) ReadTags(ctx context.Context, primaryKey string) ([]controllerModel.Tag, error) {
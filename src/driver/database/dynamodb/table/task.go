@@ -0,0 +1,128 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	awsDynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	dynamodbModel "scraper-backend/src/driver/database/dynamodb/model"
+	"scraper-backend/src/driver/model"
+)
+
+// one task per picture and state, it is overwritten when the picture is leased again
+type TableTask struct {
+	DynamoDbClient *awsDynamodb.Client
+	TableName      string
+	PrimaryKeyName string // ID
+	PrimaryKeyType string
+	SortKeyName    string // State
+	SortKeyType    string
+}
+
+// puts the task when it is missing or still at its version, the stored version is incremented
+func (table TableTask) CreateTask(ctx context.Context, task controllerModel.Task) error {
+	var driverTask dynamodbModel.Task
+	driverTask.DriverMarshal(task)
+	driverTask.Version = task.Version + 1
+
+	item, err := attributevalue.MarshalMap(driverTask)
+	if err != nil {
+		return err
+	}
+
+	condition := expression.Name(table.PrimaryKeyName).AttributeNotExists().Or(expression.Name("Version").Equal(expression.Value(task.Version)))
+	expr, err := expression.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = table.DynamoDbClient.PutItem(ctx, &awsDynamodb.PutItemInput{
+		TableName:                 aws.String(table.TableName),
+		Item:                      item,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return controllerModel.TaskConflictError{ID: task.ID, State: task.State}
+	}
+	return err
+}
+
+// tasks of the picture in every state
+func (table TableTask) ReadTasks(ctx context.Context, primaryKey model.UUID) ([]controllerModel.Task, error) {
+	keyEx := expression.Key(table.PrimaryKeyName).Equal(expression.Value(primaryKey[:]))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := table.DynamoDbClient.Query(ctx, &awsDynamodb.QueryInput{
+		TableName:                 aws.String(table.TableName),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []dynamodbModel.Task
+	if err := attributevalue.UnmarshalListOfMaps(response.Items, &tasks); err != nil {
+		return nil, err
+	}
+	return driverUnmarshalTasks(tasks), nil
+}
+
+// tasks of every picture matching the filter
+func (table TableTask) ScanTasks(ctx context.Context, filter *expression.ConditionBuilder) ([]controllerModel.Task, error) {
+	scanInput := awsDynamodb.ScanInput{
+		TableName: aws.String(table.TableName),
+	}
+	if filter != nil {
+		expr, err := expression.NewBuilder().WithFilter(*filter).Build()
+		if err != nil {
+			return nil, err
+		}
+		scanInput.ExpressionAttributeNames = expr.Names()
+		scanInput.ExpressionAttributeValues = expr.Values()
+		scanInput.FilterExpression = expr.Filter()
+	}
+
+	// a scan stops after 1MB, continue from the last key until the end of the table
+	var tasks []dynamodbModel.Task
+	for {
+		response, err := table.DynamoDbClient.Scan(ctx, &scanInput)
+		if err != nil {
+			return nil, err
+		}
+
+		var pageTasks []dynamodbModel.Task
+		if err := attributevalue.UnmarshalListOfMaps(response.Items, &pageTasks); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, pageTasks...)
+
+		if len(response.LastEvaluatedKey) == 0 {
+			break
+		}
+		scanInput.ExclusiveStartKey = response.LastEvaluatedKey
+	}
+
+	return driverUnmarshalTasks(tasks), nil
+}
+
+func driverUnmarshalTasks(tasks []dynamodbModel.Task) []controllerModel.Task {
+	controllerTasks := make([]controllerModel.Task, 0, len(tasks))
+	for _, task := range tasks {
+		controllerTasks = append(controllerTasks, task.DriverUnmarshal())
+	}
+	return controllerTasks
+}
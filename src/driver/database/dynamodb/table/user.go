@@ -20,9 +20,10 @@ type TableUser struct {
 	PrimaryKeyType string
 	SortKeyName    string // ID
 	SortKeyType    string
+	Audit          TableAudit // entries written in the same transaction as the mutations
 }
 
-func (table TableUser) CreateUser(ctx context.Context, user controllerModel.User) error {
+func (table TableUser) CreateUser(ctx context.Context, user controllerModel.User, audit *controllerModel.Audit) error {
 	var driverUser dynamodbModel.User
 	driverUser.DriverMarshal(user)
 
@@ -31,19 +32,14 @@ func (table TableUser) CreateUser(ctx context.Context, user controllerModel.User
 		return err
 	}
 
-	_, err = table.DynamoDbClient.PutItem(ctx, &dynamodb.PutItemInput{
+	return table.Audit.writeAudited(ctx, types.TransactWriteItem{Put: &types.Put{
 		TableName: aws.String(table.TableName),
 		Item:      item,
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	}}, audit)
 }
 
-func (table TableUser) DeleteUser(ctx context.Context, primaryKey string, sortKey model.UUID) error {
-	_, err := table.DynamoDbClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+func (table TableUser) DeleteUser(ctx context.Context, primaryKey string, sortKey model.UUID, audit *controllerModel.Audit) error {
+	return table.Audit.writeAudited(ctx, types.TransactWriteItem{Delete: &types.Delete{
 		TableName: aws.String(table.TableName),
 		Key: map[string]types.AttributeValue{
 			table.PrimaryKeyName: &types.AttributeValueMemberS{
@@ -53,11 +49,7 @@ func (table TableUser) DeleteUser(ctx context.Context, primaryKey string, sortKe
 				Value: sortKey[:],
 			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-	return nil
+	}}, audit)
 }
 
 func (table TableUser) ReadUser(ctx context.Context, primaryKey string, sortKey model.UUID) (*controllerModel.User, error) {
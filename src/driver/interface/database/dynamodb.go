@@ -8,21 +8,23 @@ import (
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 )
 
+// the single writes take the audit entry of the mutation, written in the same transaction when it is not nil
 type DriverDynamodbPicture interface {
 	ReadPicture(ctx context.Context, primaryKey string, sortKey model.UUID) (*controllerModel.Picture, error)
 	ReadPictures(ctx context.Context, projection *expression.ProjectionBuilder, filter *expression.ConditionBuilder) ([]controllerModel.Picture, error)
-	CreatePicture(ctx context.Context, id model.UUID, picture controllerModel.Picture) error
-	DeletePicture(ctx context.Context, primaryKey string, sortKey model.UUID) error
-	DeletePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, tagID model.UUID) error
-	CreatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, tagID model.UUID, tag controllerModel.PictureTag) error
-	UpdatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, tagID model.UUID, tag controllerModel.PictureTag) error
-	CreatePictureSize(ctx context.Context, primaryKey string, sortKey model.UUID, size controllerModel.PictureSize) error
+	CreatePicture(ctx context.Context, id model.UUID, picture controllerModel.Picture, audit *controllerModel.Audit) error
+	CreatePictures(ctx context.Context, pictures []controllerModel.Picture) error
+	DeletePicture(ctx context.Context, primaryKey string, sortKey model.UUID, version int, audit *controllerModel.Audit) error
+	DeletePictures(ctx context.Context, pictures []controllerModel.Picture) error
+	DeletePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID, audit *controllerModel.Audit) error
+	CreatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tag controllerModel.PictureTag, audit *controllerModel.Audit) error
+	UpdatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tag controllerModel.PictureTag, audit *controllerModel.Audit) error
 }
 
 type DriverDynamodbTag interface {
 	ReadTags(ctx context.Context, primaryKey string) ([]controllerModel.Tag, error)
-	CreateTag(ctx context.Context, picture controllerModel.Tag) error
-	DeleteTag(ctx context.Context, primaryKey string, sortKey model.UUID) error
+	CreateTag(ctx context.Context, picture controllerModel.Tag, audit *controllerModel.Audit) error
+	DeleteTag(ctx context.Context, primaryKey string, sortKey model.UUID, audit *controllerModel.Audit) error
 	ScanTags(ctx context.Context) ([]controllerModel.Tag, error)
 }
 
@@ -30,6 +32,18 @@ type DriverDynamodbUser interface {
 	ReadUser(ctx context.Context, primaryKey string, sortKey model.UUID) (*controllerModel.User, error)
 	ReadUsers(ctx context.Context, primaryKey string) ([]controllerModel.User, error)
 	ScanUsers(ctx context.Context) ([]controllerModel.User, error)
-	CreateUser(ctx context.Context, picture controllerModel.User) error
-	DeleteUser(ctx context.Context, primaryKey string, sortKey model.UUID) error
+	CreateUser(ctx context.Context, picture controllerModel.User, audit *controllerModel.Audit) error
+	DeleteUser(ctx context.Context, primaryKey string, sortKey model.UUID, audit *controllerModel.Audit) error
+}
+
+type DriverDynamodbAudit interface {
+	CreateAudit(ctx context.Context, audit controllerModel.Audit) error
+	ReadAudits(ctx context.Context, entity string, primaryKey string, sortKey model.UUID) ([]controllerModel.Audit, error)
+	ScanAudits(ctx context.Context, filter *expression.ConditionBuilder) ([]controllerModel.Audit, error)
+}
+
+type DriverDynamodbTask interface {
+	CreateTask(ctx context.Context, task controllerModel.Task) error
+	ReadTasks(ctx context.Context, primaryKey model.UUID) ([]controllerModel.Task, error)
+	ScanTasks(ctx context.Context, filter *expression.ConditionBuilder) ([]controllerModel.Task, error)
 }
@@ -3,11 +3,14 @@ package adapter
 import (
 	"context"
 	"io"
+	"time"
 )
 
 type DriverS3 interface {
 	ItemCreate(ctx context.Context, buffer io.Reader, bucketName, path string) error
 	ItemRead(ctx context.Context, bucketName, path string) ([]byte, error)
+	ItemExists(ctx context.Context, bucketName, path string) (bool, error)
+	ItemList(ctx context.Context, bucketName, prefix string) (map[string]time.Time, error) // last modification of every key
 	ItemCopy(ctx context.Context, bucketName, sourcePath, destinationPath string) error
 	ItemDelete(ctx context.Context, bucketName, destinationPath string) error
 }
@@ -10,6 +10,8 @@ func Contructor(
 	controllerPicture interfaceAdapter.ControllerPicture,
 	controllerTag interfaceAdapter.ControllerTag,
 	controllerUser interfaceAdapter.ControllerUser,
+	controllerAudit interfaceAdapter.ControllerAudit,
+	controllerTask interfaceAdapter.ControllerTask,
 	controllerFlickr interfaceAdapter.ControllerFlickr,
 	controllerPexels interfaceAdapter.ControllerPexels,
 	controllerUnsplash interfaceAdapter.ControllerUnsplash,
@@ -18,6 +20,8 @@ func Contructor(
 		ControllerPicture:  controllerPicture,
 		ControllerTag:      controllerTag,
 		ControllerUser:     controllerUser,
+		ControllerAudit:    controllerAudit,
+		ControllerTask:     controllerTask,
 		ControllerFlickr:   controllerFlickr,
 		ControllerPexels:   controllerPexels,
 		ControllerUnsplash: controllerUnsplash,
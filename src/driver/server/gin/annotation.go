@@ -0,0 +1,102 @@
+package gin
+
+import (
+	"context"
+	"fmt"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	"scraper-backend/src/driver/model"
+	serverModel "scraper-backend/src/driver/server/model"
+)
+
+type BodyUpdatePictureAnnotationSet struct {
+	Origin *string                  `json:"origin"`
+	ID     *string                  `json:"id"`
+	Tags   []serverModel.PictureTag `json:"tags"`
+}
+
+// the annotator is the actor of the request
+func (d DriverServerGin) UpdatePictureAnnotationSet(ctx context.Context, body BodyUpdatePictureAnnotationSet) (string, error) {
+	if body.Origin == nil || body.ID == nil || body.Tags == nil {
+		return "error", fmt.Errorf("body fields must not be empty")
+	}
+	id, err := model.ParseUUID(*body.ID)
+	if err != nil {
+		return "error", err
+	}
+	tags := make([]controllerModel.PictureTag, 0, len(body.Tags))
+	for _, tag := range body.Tags {
+		tags = append(tags, tag.DriverUnmarshal())
+	}
+	if err := d.ControllerPicture.UpdatePictureAnnotationSet(ctx, *body.Origin, id, ifMatchVersion(ctx), controllerModel.ContextActor(ctx), tags); err != nil {
+		return "error", err
+	}
+	return "ok", nil
+}
+
+type BodyUpdatePictureAnnotationReview struct {
+	Origin   *string  `json:"origin"`
+	ID       *string  `json:"id"`
+	Decision *string  `json:"decision"` // accept, merge or reject
+	SetIDs   []string `json:"setIDs"`
+}
+
+// the reviewer is the actor of the request
+func (d DriverServerGin) UpdatePictureAnnotationReview(ctx context.Context, body BodyUpdatePictureAnnotationReview) (string, error) {
+	if body.Origin == nil || body.ID == nil || body.Decision == nil || body.SetIDs == nil {
+		return "error", fmt.Errorf("body fields must not be empty")
+	}
+	id, err := model.ParseUUID(*body.ID)
+	if err != nil {
+		return "error", err
+	}
+	review := controllerModel.AnnotationReview{
+		Decision: *body.Decision,
+		SetIDs:   make([]model.UUID, 0, len(body.SetIDs)),
+		Reviewer: controllerModel.ContextActor(ctx),
+	}
+	for _, setID := range body.SetIDs {
+		parsedSetID, err := model.ParseUUID(setID)
+		if err != nil {
+			return "error", err
+		}
+		review.SetIDs = append(review.SetIDs, parsedSetID)
+	}
+	if err := d.ControllerPicture.UpdatePictureAnnotationReview(ctx, *body.Origin, id, ifMatchVersion(ctx), review); err != nil {
+		return "error", err
+	}
+	return "ok", nil
+}
+
+// pictures waiting for the label of another annotator than the actor of the request
+func (d DriverServerGin) ReadPicturesAnnotationSecond(ctx context.Context) ([]serverModel.Picture, error) {
+	controllerPictures, err := d.ControllerPicture.ReadPicturesAnnotationSecond(ctx, controllerModel.ContextActor(ctx))
+	if err != nil {
+		return nil, err
+	}
+	serverPictures := make([]serverModel.Picture, 0, len(controllerPictures))
+	for _, controllerPicture := range controllerPictures {
+		var serverPicture serverModel.Picture
+		serverPicture.DriverMarshal(controllerPicture)
+		serverPictures = append(serverPictures, serverPicture)
+	}
+	return serverPictures, nil
+}
+
+type QueryReadPicturesAnnotationDisagreement struct {
+	Threshold float64 `form:"threshold,default=0.5"` // agreement under which the sets disagree
+}
+
+func (d DriverServerGin) ReadPicturesAnnotationDisagreement(ctx context.Context, query QueryReadPicturesAnnotationDisagreement) ([]serverModel.AnnotationAgreement, error) {
+	controllerAgreements, err := d.ControllerPicture.ReadPicturesAnnotationDisagreement(ctx, query.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	serverAgreements := make([]serverModel.AnnotationAgreement, 0, len(controllerAgreements))
+	for _, controllerAgreement := range controllerAgreements {
+		var serverAgreement serverModel.AnnotationAgreement
+		serverAgreement.DriverMarshal(controllerAgreement)
+		serverAgreements = append(serverAgreements, serverAgreement)
+	}
+	return serverAgreements, nil
+}
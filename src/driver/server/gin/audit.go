@@ -0,0 +1,79 @@
+package gin
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	"scraper-backend/src/driver/model"
+	serverModel "scraper-backend/src/driver/server/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// the actor of the request is recorded in the audit entries of its mutations
+func contextActor(c *gin.Context) {
+	if actor := c.GetHeader("X-Actor"); actor != "" {
+		c.Request = c.Request.WithContext(controllerModel.ContextWithActor(c.Request.Context(), actor))
+	}
+	c.Next()
+}
+
+// the routes owned by one person, e.g. leases or annotation sets, cannot be shared by the requests without actor
+func requireActor(c *gin.Context) {
+	if actor := c.GetHeader("X-Actor"); actor == "" || actor == controllerModel.AuditActorUnknown {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": "X-Actor header is required"})
+		return
+	}
+	c.Next()
+}
+
+type ParamsReadPictureHistory struct {
+	Origin string `uri:"origin" binding:"required"`
+	ID     string `uri:"id" binding:"required"`
+}
+
+func (d DriverServerGin) ReadPictureHistory(ctx context.Context, params ParamsReadPictureHistory) ([]serverModel.Audit, error) {
+	id, err := model.ParseUUID(params.ID)
+	if err != nil {
+		return nil, err
+	}
+	controllerAudits, err := d.ControllerAudit.ReadPictureHistory(ctx, params.Origin, id)
+	if err != nil {
+		return nil, err
+	}
+	return driverMarshalAudits(controllerAudits), nil
+}
+
+type QueryReadAudits struct {
+	Entity string    `form:"entity"` // picture, tag or user
+	Actor  string    `form:"actor"`
+	Action string    `form:"action"`
+	From   time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+func (d DriverServerGin) ReadAudits(ctx context.Context, query QueryReadAudits) ([]serverModel.Audit, error) {
+	controllerAudits, err := d.ControllerAudit.ReadAudits(ctx, controllerModel.AuditFilter{
+		Entity: query.Entity,
+		Actor:  query.Actor,
+		Action: query.Action,
+		From:   query.From,
+		To:     query.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return driverMarshalAudits(controllerAudits), nil
+}
+
+func driverMarshalAudits(controllerAudits []controllerModel.Audit) []serverModel.Audit {
+	serverAudits := make([]serverModel.Audit, 0, len(controllerAudits))
+	for _, controllerAudit := range controllerAudits {
+		var serverAudit serverModel.Audit
+		serverAudit.DriverMarshal(controllerAudit)
+		serverAudits = append(serverAudits, serverAudit)
+	}
+	return serverAudits
+}
@@ -0,0 +1,80 @@
+package gin
+
+import (
+	"context"
+	"fmt"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	"scraper-backend/src/driver/model"
+	serverModel "scraper-backend/src/driver/server/model"
+)
+
+type BodyPictureKey struct {
+	Origin *string `json:"origin"`
+	ID     *string `json:"id"`
+}
+
+type BodyUpdatePicturesBulk struct {
+	Operation *string          `json:"operation"` // transfer, block, unblock, delete, addTag or removeTag
+	Pictures  []BodyPictureKey `json:"pictures"`
+	From      *string          `json:"from"` // process by default
+	To        *string          `json:"to"`
+	Actor     *string          `json:"actor"`
+	Reason    *string          `json:"reason"`
+	Override  *bool            `json:"override"`
+	Tag       *string          `json:"tag"`
+	DryRun    *bool            `json:"dryRun"`
+}
+
+func (d DriverServerGin) UpdatePicturesBulk(ctx context.Context, body BodyUpdatePicturesBulk) ([]serverModel.PictureBulkResult, error) {
+	if body.Operation == nil || len(body.Pictures) == 0 {
+		return nil, fmt.Errorf("body fields must not be empty")
+	}
+	bulk := controllerModel.PictureBulk{
+		Operation: *body.Operation,
+		Pictures:  make([]controllerModel.PictureKey, 0, len(body.Pictures)),
+	}
+	for _, picture := range body.Pictures {
+		if picture.Origin == nil || picture.ID == nil {
+			return nil, fmt.Errorf("pictures fields must not be empty")
+		}
+		id, err := model.ParseUUID(*picture.ID)
+		if err != nil {
+			return nil, err
+		}
+		bulk.Pictures = append(bulk.Pictures, controllerModel.PictureKey{Origin: *picture.Origin, ID: id})
+	}
+	if body.From != nil {
+		bulk.From = *body.From
+	}
+	if body.To != nil {
+		bulk.To = *body.To
+	}
+	if body.Actor != nil {
+		bulk.Actor = *body.Actor
+	}
+	if body.Reason != nil {
+		bulk.Reason = *body.Reason
+	}
+	if body.Override != nil {
+		bulk.Override = *body.Override
+	}
+	if body.Tag != nil {
+		bulk.TagName = *body.Tag
+	}
+	if body.DryRun != nil {
+		bulk.DryRun = *body.DryRun
+	}
+
+	controllerResults, err := d.ControllerPicture.UpdatePicturesBulk(ctx, bulk)
+	if err != nil {
+		return nil, err
+	}
+	serverResults := make([]serverModel.PictureBulkResult, 0, len(controllerResults))
+	for _, controllerResult := range controllerResults {
+		var serverResult serverModel.PictureBulkResult
+		serverResult.DriverMarshal(controllerResult)
+		serverResults = append(serverResults, serverResult)
+	}
+	return serverResults, nil
+}
@@ -1,11 +1,20 @@
 package gin
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
 
+	controllerModel "scraper-backend/src/adapter/controller/model"
 	interfaceAdapter "scraper-backend/src/adapter/interface"
+	serverModel "scraper-backend/src/driver/server/model"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -15,6 +24,8 @@ type DriverServerGin struct {
 	ControllerPicture  interfaceAdapter.ControllerPicture
 	ControllerTag      interfaceAdapter.ControllerTag
 	ControllerUser     interfaceAdapter.ControllerUser
+	ControllerAudit    interfaceAdapter.ControllerAudit
+	ControllerTask     interfaceAdapter.ControllerTask
 	ControllerFlickr   interfaceAdapter.ControllerFlickr
 	ControllerPexels   interfaceAdapter.ControllerPexels
 	ControllerUnsplash interfaceAdapter.ControllerUnsplash
@@ -24,27 +35,44 @@ type DriverServerGin struct {
 func (d DriverServerGin) Router(port int, healthCheckPath string) *gin.Engine {
 	router := gin.Default()
 	router.Use(cors.Default())
+	router.Use(contextActor)
 
 	// health check
 	router.Any("/", func(c *gin.Context) { c.JSON(http.StatusOK, "ok") })
 	router.Any(healthCheckPath, func(c *gin.Context) { c.JSON(http.StatusOK, "ok") })
 
-	router.GET("/image/file/:origin/:name/:extension", wrapperDataHandlerURI(d.ReadPictureFile))
+	// also /image/file/:origin/:name/:extension, the path of the files before their keys were derived from the IDs
+	router.GET("/image/file/:origin/:id/:collection", wrapperDataHandlerURIQuery(d.ReadPictureFile))
 	router.GET("/image/:origin/:id/:collection", wrapperJSONHandlerURI(d.ReadPicture))
-	router.PUT("/image/tag", wrapperJSONHandlerBody(d.UpdatePictureTag))
-	router.DELETE("/image/tag", wrapperJSONHandlerBody(d.DeletePictureTag))
-	router.PUT("/image/crop", wrapperJSONHandlerBody(d.UpdatePictureCrop))
+	router.GET("/image/:origin/:id/history", wrapperJSONHandlerURI(d.ReadPictureHistory))
+	router.POST("/image/tag", requireIfMatch, wrapperJSONHandlerBody(d.CreatePictureTag))
+	router.PUT("/image/tag", requireIfMatch, wrapperJSONHandlerBody(d.UpdatePictureTag))
+	router.DELETE("/image/tag", requireIfMatch, wrapperJSONHandlerBody(d.DeletePictureTag))
+	router.POST("/image/tag/segmentation", requireIfMatch, wrapperJSONHandlerBody(d.CreatePictureTagSegmentation))
+	router.PUT("/image/tag/segmentation", requireIfMatch, wrapperJSONHandlerBody(d.UpdatePictureTagSegmentation))
+	router.POST("/image/tag/keypoints", requireIfMatch, wrapperJSONHandlerBody(d.CreatePictureTagKeypoints))
+	router.PUT("/image/tag/keypoints", requireIfMatch, wrapperJSONHandlerBody(d.UpdatePictureTagKeypoints))
+	router.PUT("/image/annotation", requireActor, requireIfMatch, wrapperJSONHandlerBody(d.UpdatePictureAnnotationSet))
+	router.POST("/image/annotation/review", requireActor, requireIfMatch, wrapperJSONHandlerBody(d.UpdatePictureAnnotationReview))
+	router.PUT("/image/crop", requireIfMatch, wrapperJSONHandlerBody(d.UpdatePictureCrop))
 	router.POST("/image/crop", wrapperJSONHandlerBody(d.CreatePictureCrop))
 	router.POST("/image/copy", wrapperJSONHandlerBody(d.CreatePictureCopy))
-	router.POST("/image/transfer", wrapperJSONHandlerBody(d.UpdatePictureTransfer))
-	router.DELETE("/image/:origin/:id/:name", wrapperJSONHandlerURI(d.DeletePictureAndFile))
+	router.POST("/image/transfer", requireIfMatch, wrapperJSONHandlerBody(d.UpdatePictureTransfer))
+	router.DELETE("/image/:origin/:id", requireIfMatch, wrapperJSONHandlerURI(d.DeletePictureAndFile))
 
 	// routes for multiple images
-	router.GET("/images/id/:collection/:origin", wrapperJSONHandlerURI(d.ReadPicturesID))
+	router.GET("/images/id/:collection/:origin", wrapperJSONHandlerURIQuery(d.ReadPicturesID))
+	router.POST("/images/bulk", wrapperJSONHandlerBody(d.UpdatePicturesBulk))
+	router.GET("/images/export/:collection", wrapperJSONHandlerURIQuery(d.ExportPictures))
+	router.GET("/images/annotation/second", wrapperJSONHandler(d.ReadPicturesAnnotationSecond))
+	router.GET("/images/annotation/disagreement", wrapperJSONHandlerQuery(d.ReadPicturesAnnotationDisagreement))
+	router.GET("/images/sample", wrapperJSONHandlerQuery(d.ReadPicturesSample))
+	router.POST("/predictions", wrapperJSONHandlerQueryList(d.CreatePredictions))
+	router.GET("/predictions/evaluation", wrapperJSONHandlerQuery(d.EvaluatePredictions))
 
 	// routes for one image unwanted
-	router.POST("/image/unwanted", wrapperJSONHandlerBody(d.CreatePictureBlocked))
-	router.DELETE("/image/unwanted", wrapperJSONHandlerURI(d.DeletePictureBlocked))
+	router.POST("/image/unwanted", requireIfMatch, wrapperJSONHandlerBody(d.CreatePictureBlocked))
+	router.DELETE("/image/unwanted", requireIfMatch, wrapperJSONHandlerBody(d.DeletePictureBlocked))
 
 	// routes for multiple images unwanted
 	router.GET("/images/unwanted", wrapperJSONHandler(d.ReadPicturesBlocked))
@@ -52,19 +80,36 @@ func (d DriverServerGin) Router(port int, healthCheckPath string) *gin.Engine {
 	// routes for one tag
 	router.POST("/tag/wanted", wrapperJSONHandlerBody(d.CreateTag))
 	router.POST("/tag/unwanted", wrapperJSONHandlerBody(d.CreateTagBlocked))
+	router.GET("/tag/wanted/:id", wrapperJSONHandlerURI(d.ReadTag))
+	router.PUT("/tag/wanted/skeleton", wrapperJSONHandlerBody(d.UpdateTagSkeleton))
+	router.PUT("/tag/wanted/hierarchy", wrapperJSONHandlerBody(d.UpdateTagHierarchy))
+	router.PUT("/tag/wanted/target", wrapperJSONHandlerBody(d.UpdateTagTarget))
 	router.DELETE("/tag/wanted/:id", wrapperJSONHandlerURI(d.DeleteTag))
 	router.DELETE("/tag/unwanted/:id", wrapperJSONHandlerURI(d.DeleteTagBlocked))
 
 	// routes for multiple tags
 	router.GET("/tags/wanted", wrapperJSONHandler(d.ReadTags))
+	router.GET("/tags/wanted/progress", wrapperJSONHandler(d.ReadTagsProgress))
 	router.GET("/tags/unwanted", wrapperJSONHandler(d.ReadTagsBlocked))
+	router.POST("/tags/test", wrapperJSONHandlerBody(d.TestTagsBlocked))
 
-	// routes for one user unwanted
+	// routes for one user, blocked, trusted or capped
+	router.POST("/user", wrapperJSONHandlerBody(d.CreateUser))
 	router.POST("/user/unwanted", wrapperJSONHandlerBody(d.CreateUserBlocked))
-	router.DELETE("/user/unwanted/:id", wrapperJSONHandlerURI(d.DeleteUserBlocked))
+	router.DELETE("/user/:origin/:id", wrapperJSONHandlerURI(d.DeleteUser))
+	router.DELETE("/user/unwanted/:origin/:id", wrapperJSONHandlerURI(d.DeleteUser))
 
-	// routes for multiple users unwanted
-	router.GET("/users/unwanted", wrapperJSONHandler(d.ReadUsers))
+	// routes for multiple users
+	router.GET("/users", wrapperJSONHandler(d.ReadUsersStats))
+	router.GET("/users/unwanted", wrapperJSONHandler(d.ReadUsersBlocked))
+
+	// routes for the audit log
+	router.GET("/audit", wrapperJSONHandlerQuery(d.ReadAudits))
+
+	// routes for the labeling queue
+	router.GET("/tasks/next", requireActor, wrapperJSONHandlerQuery(d.ReadTaskNext))
+	router.POST("/tasks/:id/complete", requireActor, wrapperJSONHandlerURI(d.CompleteTask))
+	router.GET("/tasks/stats", wrapperJSONHandlerQuery(d.ReadTaskStats))
 
 	// routes for scraping the internet
 	router.POST("/search/flickr/:quality", wrapperJSONHandlerURI(d.SearchPhotosFlickr))
@@ -102,13 +147,92 @@ func wrapperJSONHandlerURI[P any, R any](f func(ctx context.Context, params P) (
 	}
 }
 
-func wrapperJSONResponseArg[A any, R any](c *gin.Context, f func(ctx context.Context, arg A) (R, error), arg A) {
-	res, err := f(c.Request.Context(), arg)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"status": err.Error()})
-		return
+// Query
+func wrapperJSONHandlerQuery[Q any, R any](f func(ctx context.Context, query Q) (R, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query Q
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+			return
+		}
+		wrapperJSONResponseArg(c, f, query)
 	}
-	c.JSON(http.StatusOK, res)
+}
+
+// URI and query
+func wrapperJSONHandlerURIQuery[P any, Q any, R any](f func(ctx context.Context, params P, query Q) (R, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var params P
+		if err := c.ShouldBindUri(&params); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+			return
+		}
+		var query Q
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+			return
+		}
+		wrapperJSONResponse(c, func(ctx context.Context) (R, error) { return f(ctx, params, query) })
+	}
+}
+
+// query and a body of either a JSON list or newline delimited JSON objects
+func wrapperJSONHandlerQueryList[Q any, B any, R any](f func(ctx context.Context, query Q, body []B) (R, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query Q
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+			return
+		}
+		body, err := decodeJSONList[B](c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+			return
+		}
+		wrapperJSONResponse(c, func(ctx context.Context) (R, error) { return f(ctx, query, body) })
+	}
+}
+
+func decodeJSONList[B any](reader io.Reader) ([]B, error) {
+	buffered := bufio.NewReader(reader)
+	var first byte
+	for {
+		b, err := buffered.ReadByte()
+		if err == io.EOF {
+			return nil, fmt.Errorf("body must not be empty")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !unicode.IsSpace(rune(b)) {
+			first = b
+			break
+		}
+	}
+	if err := buffered.UnreadByte(); err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(buffered)
+	var list []B
+	if first == '[' {
+		if err := decoder.Decode(&list); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+	for decoder.More() {
+		var element B
+		if err := decoder.Decode(&element); err != nil {
+			return nil, fmt.Errorf("line %d: %v", len(list)+1, err)
+		}
+		list = append(list, element)
+	}
+	return list, nil
+}
+
+func wrapperJSONResponseArg[A any, R any](c *gin.Context, f func(ctx context.Context, arg A) (R, error), arg A) {
+	wrapperJSONResponse(c, func(ctx context.Context) (R, error) { return f(ctx, arg) })
 }
 
 // No Body and URI
@@ -121,17 +245,108 @@ func wrapperJSONHandler[R any](f func(ctx context.Context) (R, error)) gin.Handl
 func wrapperJSONResponse[R any](c *gin.Context, f func(ctx context.Context) (R, error)) {
 	res, err := f(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"status": err.Error()})
+		wrapperErrorResponse(c, err)
 		return
 	}
+	if tagged, ok := any(res).(interface{ ETag() string }); ok && tagged.ETag() != "" {
+		c.Header("ETag", tagged.ETag())
+	}
 	c.JSON(http.StatusOK, res)
 }
 
+// If-Match
+
+type ifMatchKey struct{}
+
+// the routes editing a picture require the ETag of the version read by the client
+func requireIfMatch(c *gin.Context) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		c.AbortWithStatusJSON(http.StatusPreconditionRequired, gin.H{"status": "If-Match header with the ETag of the picture is required"})
+		return
+	}
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"msg": fmt.Sprintf("If-Match `%s` is not the ETag of a picture", header)})
+		return
+	}
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ifMatchKey{}, version))
+	c.Next()
+}
+
+// version of the picture given in If-Match
+func ifMatchVersion(ctx context.Context) int {
+	version, _ := ctx.Value(ifMatchKey{}).(int)
+	return version
+}
+
+// Error response
+
+// a parameter of the request is well formed but its value cannot be used
+type paramInvalidError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+func (e paramInvalidError) Error() string {
+	return fmt.Sprintf("%s `%s` is not valid: %v", e.Name, e.Value, e.Err)
+}
+
+func wrapperErrorResponse(c *gin.Context, err error) {
+	var notFoundErr controllerModel.PictureNotFoundError
+	if errors.As(err, &notFoundErr) {
+		c.JSON(http.StatusNotFound, gin.H{"status": err.Error()})
+		return
+	}
+	var paramErr paramInvalidError
+	if errors.As(err, &paramErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"status": err.Error()})
+		return
+	}
+	var conflictErr controllerModel.PictureConflictError
+	if errors.As(err, &conflictErr) {
+		c.Header("ETag", serverModel.PictureETag(conflictErr.Version))
+		c.JSON(http.StatusConflict, gin.H{"status": err.Error(), "version": conflictErr.Version})
+		return
+	}
+	var taskConflictErr controllerModel.TaskConflictError
+	if errors.As(err, &taskConflictErr) {
+		c.JSON(http.StatusConflict, gin.H{"status": err.Error()})
+		return
+	}
+	var overrideErr controllerModel.OverrideForbiddenError
+	if errors.As(err, &overrideErr) {
+		c.JSON(http.StatusForbidden, gin.H{"status": err.Error()})
+		return
+	}
+	var formatErr controllerModel.FormatUnsupportedError
+	if errors.As(err, &formatErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"status": err.Error()})
+		return
+	}
+	var validationErrors controllerModel.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		serverErrors := make([]serverModel.ValidationError, 0, len(validationErrors))
+		for _, validationError := range validationErrors {
+			var serverError serverModel.ValidationError
+			serverError.DriverMarshal(validationError)
+			serverErrors = append(serverErrors, serverError)
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"status": err.Error(), "errors": serverErrors})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"status": err.Error()})
+}
+
 // File response
 
 type DataSchema struct {
-	DataType string
-	DataFile []byte
+	DataType     string
+	DataFile     []byte
+	ETag         string
+	CacheControl string
+	NotModified  bool // the client copy matches the ETag, no file is sent
 }
 
 // URI
@@ -146,20 +361,65 @@ func wrapperDataHandlerURI[P any](f func(ctx context.Context, params P) (*DataSc
 	}
 }
 
+// URI, query and headers
+func wrapperDataHandlerURIQuery[P any, Q any](f func(ctx context.Context, params P, query Q) (*DataSchema, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var params P
+		if err := c.ShouldBindUri(&params); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+			return
+		}
+		var query Q
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+			return
+		}
+		if err := c.ShouldBindHeader(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+			return
+		}
+		wrapperDataResponse(c, func(ctx context.Context) (*DataSchema, error) { return f(ctx, params, query) })
+	}
+}
+
 func wrapperDataResponseArg[A any](c *gin.Context, f func(ctx context.Context, arg A) (*DataSchema, error), arg A) {
-	data, err := f(c.Request.Context(), arg)
+	wrapperDataResponse(c, func(ctx context.Context) (*DataSchema, error) { return f(ctx, arg) })
+}
+
+func wrapperDataResponse(c *gin.Context, f func(ctx context.Context) (*DataSchema, error)) {
+	data, err := f(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"status": err.Error()})
+		wrapperErrorResponse(c, err)
 		return
 	}
-	switch data.DataType {
-	case "jpg":
-		c.Data(http.StatusOK, "image/jpeg", data.DataFile)
-	case "jpeg":
-		c.Data(http.StatusOK, "image/jpeg", data.DataFile)
-	case "png":
-		c.Data(http.StatusOK, "image/png", data.DataFile)
-	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"status": fmt.Errorf("wrong content-type: %s", data.DataType)})
+	if data.ETag != "" {
+		c.Header("ETag", data.ETag)
+	}
+	if data.CacheControl != "" {
+		c.Header("Cache-Control", data.CacheControl)
 	}
+	if data.NotModified {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	// unknown extensions are sniffed from the bytes
+	contentType, ok := contentTypes[strings.ToLower(data.DataType)]
+	if !ok {
+		contentType = http.DetectContentType(data.DataFile)
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": fmt.Sprintf("wrong content-type: %s", data.DataType)})
+		return
+	}
+	c.Data(http.StatusOK, contentType, data.DataFile)
+}
+
+var contentTypes = map[string]string{
+	"jpg":  "image/jpeg",
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+	"gif":  "image/gif",
+	"webp": "image/webp",
+	"tiff": "image/tiff",
+	"tif":  "image/tiff",
 }
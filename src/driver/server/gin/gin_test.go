@@ -0,0 +1,128 @@
+package gin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	interfaceAdapter "scraper-backend/src/adapter/interface"
+	"scraper-backend/src/driver/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// one picture at a version with one file, the other methods are not used
+type fakeControllerPicture struct {
+	interfaceAdapter.ControllerPicture
+	picture controllerModel.Picture
+}
+
+func (f *fakeControllerPicture) CreatePictureTag(ctx context.Context, primaryKey string, sortKey model.UUID, version int, tagID model.UUID, tag controllerModel.PictureTag) error {
+	if sortKey != f.picture.ID {
+		return controllerModel.PictureNotFoundError{State: controllerModel.PictureStateProcess, Origin: primaryKey, ID: sortKey.String()}
+	}
+	if version != f.picture.Version {
+		return controllerModel.PictureConflictError{Origin: primaryKey, ID: sortKey, Version: f.picture.Version}
+	}
+	if tag.Name == "" {
+		return controllerModel.ValidationErrors{{Rule: "name", Message: "the tag has no name"}}
+	}
+	return nil
+}
+
+func (f *fakeControllerPicture) ReadPicture(ctx context.Context, state string, primaryKey string, sortKey model.UUID) (*controllerModel.Picture, error) {
+	if sortKey != f.picture.ID {
+		return nil, nil
+	}
+	return &f.picture, nil
+}
+
+func (f *fakeControllerPicture) file(cachedTag string) *controllerModel.PictureFile {
+	file := controllerModel.PictureFile{Tag: "size", Extension: "png", Buffer: []byte("\x89PNG\r\n\x1a\n")}
+	file.NotModified = cachedTag == file.Tag
+	return &file
+}
+
+func (f *fakeControllerPicture) ReadPictureFile(ctx context.Context, state string, primaryKey string, sortKey model.UUID, width int, cachedTag string) (*controllerModel.PictureFile, error) {
+	if sortKey != f.picture.ID {
+		return nil, controllerModel.PictureNotFoundError{State: state, Origin: primaryKey, ID: sortKey.String()}
+	}
+	return f.file(cachedTag), nil
+}
+
+func (f *fakeControllerPicture) ReadPictureFileByName(ctx context.Context, primaryKey string, name string, width int, cachedTag string) (*controllerModel.PictureFile, error) {
+	if name != f.picture.Name {
+		return nil, controllerModel.PictureNotFoundError{Origin: primaryKey, ID: name}
+	}
+	return f.file(cachedTag), nil
+}
+
+func TestPictureRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	picture := controllerModel.Picture{Origin: "pexels", ID: model.NewUUID(), Name: "1234", Version: 3}
+	d := DriverServerGin{ControllerPicture: &fakeControllerPicture{picture: picture}}
+	router := gin.New()
+	router.Use(contextActor)
+	router.GET("/image/file/:origin/:id/:collection", wrapperDataHandlerURIQuery(d.ReadPictureFile))
+	router.GET("/image/:origin/:id/:collection", wrapperJSONHandlerURI(d.ReadPicture))
+	router.POST("/image/tag", requireIfMatch, wrapperJSONHandlerBody(d.CreatePictureTag))
+
+	tagBody := func(name string) string {
+		return fmt.Sprintf(`{"origin":"pexels","id":"%s","tag":{"name":"%s"}}`, picture.ID, name)
+	}
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		ifMatch    string
+		ifNone     string
+		body       string
+		wantStatus int
+		wantETag   string
+	}{
+		{name: "tag at the current version", method: http.MethodPost, path: "/image/tag", ifMatch: `"3"`, body: tagBody("dog"), wantStatus: http.StatusOK},
+		{name: "tag at a weak ETag", method: http.MethodPost, path: "/image/tag", ifMatch: `W/"3"`, body: tagBody("dog"), wantStatus: http.StatusOK},
+		{name: "tag without If-Match", method: http.MethodPost, path: "/image/tag", body: tagBody("dog"), wantStatus: http.StatusPreconditionRequired},
+		{name: "tag with an If-Match that is not a version", method: http.MethodPost, path: "/image/tag", ifMatch: `"abc"`, body: tagBody("dog"), wantStatus: http.StatusBadRequest},
+		{name: "tag at a stale version", method: http.MethodPost, path: "/image/tag", ifMatch: `"2"`, body: tagBody("dog"), wantStatus: http.StatusConflict, wantETag: `"3"`},
+		{name: "invalid tag", method: http.MethodPost, path: "/image/tag", ifMatch: `"3"`, body: tagBody(""), wantStatus: http.StatusUnprocessableEntity},
+		{name: "picture", method: http.MethodGet, path: fmt.Sprintf("/image/pexels/%s/process", picture.ID), wantStatus: http.StatusOK, wantETag: `"3"`},
+		{name: "missing picture", method: http.MethodGet, path: fmt.Sprintf("/image/pexels/%s/process", model.NewUUID()), wantStatus: http.StatusNotFound},
+		{name: "picture with an invalid ID", method: http.MethodGet, path: "/image/pexels/1234/process", wantStatus: http.StatusUnprocessableEntity},
+		{name: "file", method: http.MethodGet, path: fmt.Sprintf("/image/file/pexels/%s/process", picture.ID), wantStatus: http.StatusOK, wantETag: `"size"`},
+		{name: "file cached by the client", method: http.MethodGet, path: fmt.Sprintf("/image/file/pexels/%s/process", picture.ID), ifNone: `"size"`, wantStatus: http.StatusNotModified, wantETag: `"size"`},
+		{name: "missing file", method: http.MethodGet, path: fmt.Sprintf("/image/file/pexels/%s/process", model.NewUUID()), wantStatus: http.StatusNotFound},
+		{name: "file with an invalid ID", method: http.MethodGet, path: "/image/file/pexels/1234/process", wantStatus: http.StatusUnprocessableEntity},
+		{name: "file at its former path", method: http.MethodGet, path: "/image/file/pexels/1234/jpg", wantStatus: http.StatusOK, wantETag: `"size"`},
+		{name: "missing file at its former path", method: http.MethodGet, path: "/image/file/pexels/5678/jpg", wantStatus: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body io.Reader
+			if test.body != "" {
+				body = strings.NewReader(test.body)
+			}
+			request := httptest.NewRequest(test.method, test.path, body)
+			if test.ifMatch != "" {
+				request.Header.Set("If-Match", test.ifMatch)
+			}
+			if test.ifNone != "" {
+				request.Header.Set("If-None-Match", test.ifNone)
+			}
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, request)
+
+			if response.Code != test.wantStatus {
+				t.Errorf("status = %d, want %d: %s", response.Code, test.wantStatus, response.Body.String())
+			}
+			if test.wantETag != "" && response.Header().Get("ETag") != test.wantETag {
+				t.Errorf("ETag = %s, want %s", response.Header().Get("ETag"), test.wantETag)
+			}
+		})
+	}
+}
@@ -3,25 +3,59 @@ package gin
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
+	controllerModel "scraper-backend/src/adapter/controller/model"
 	"scraper-backend/src/driver/model"
 	serverModel "scraper-backend/src/driver/server/model"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"golang.org/x/exp/slices"
 )
 
 type ParamsReadPictureFile struct {
-	Origin    string `uri:"origin" binding:"required"`
-	Name      string `uri:"name" binding:"required"`
-	Extension string `uri:"extension" binding:"required"`
+	Origin     string `uri:"origin" binding:"required"`
+	ID         string `uri:"id" binding:"required"`
+	Collection string `uri:"collection" binding:"required"`
 }
 
-func (d DriverServerGin) ReadPictureFile(ctx context.Context, params ParamsReadPictureFile) (*DataSchema, error) {
-	buffer, err := d.ControllerPicture.ReadPictureFile(ctx, params.Origin, params.Name, params.Extension)
-	if err != nil {
-		return nil, err
+type QueryReadPictureFile struct {
+	Width       int    `form:"w"`
+	IfNoneMatch string `header:"If-None-Match"`
+}
+
+// tables of the pictures, any other collection is the extension of a file requested by its former path
+var pictureStates = []string{controllerModel.PictureStateProcess, controllerModel.PictureStateValidation, controllerModel.PictureStateProduction, controllerModel.PictureStateBlocked}
+
+// the files were read at /image/file/:origin/:name/:extension before their keys were derived from the IDs,
+// such a path still reads the file of the picture by its name
+func (d DriverServerGin) ReadPictureFile(ctx context.Context, params ParamsReadPictureFile, query QueryReadPictureFile) (*DataSchema, error) {
+	cachedTag := strings.Trim(query.IfNoneMatch, `"`)
+	var file *controllerModel.PictureFile
+	if slices.Contains(pictureStates, params.Collection) {
+		id, err := model.ParseUUID(params.ID)
+		if err != nil {
+			return nil, paramInvalidError{Name: "id", Value: params.ID, Err: err}
+		}
+		file, err = d.ControllerPicture.ReadPictureFile(ctx, params.Collection, params.Origin, id, query.Width, cachedTag)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		file, err = d.ControllerPicture.ReadPictureFileByName(ctx, params.Origin, params.ID, query.Width, cachedTag)
+		if err != nil {
+			return nil, err
+		}
+	}
+	data := DataSchema{
+		DataType:     file.Extension,
+		DataFile:     file.Buffer,
+		ETag:         fmt.Sprintf(`"%s"`, file.Tag),
+		CacheControl: "public, no-cache", // the current file changes with crops, the tag is always revalidated
+		NotModified:  file.NotModified,
 	}
-	data := DataSchema{DataType: params.Extension, DataFile: buffer}
 	return &data, nil
 }
 
@@ -30,14 +64,46 @@ type ParamsReadPicturesID struct {
 	Origin     string `uri:"origin" binding:"required"`
 }
 
+type QueryReadPicturesID struct {
+	Sort  string `form:"sort"`  // quality score used to sort the pictures
+	Order string `form:"order"` // asc or desc
+}
+
+// quality scores available to sort the pictures
+var pictureQualitySorts = map[string]func(quality controllerModel.PictureQuality) float64{
+	"resolution":  func(quality controllerModel.PictureQuality) float64 { return float64(quality.Width * quality.Height) },
+	"blur":        func(quality controllerModel.PictureQuality) float64 { return quality.Blur },
+	"luminance":   func(quality controllerModel.PictureQuality) float64 { return quality.Luminance },
+	"darkRatio":   func(quality controllerModel.PictureQuality) float64 { return quality.DarkRatio },
+	"brightRatio": func(quality controllerModel.PictureQuality) float64 { return quality.BrightRatio },
+}
+
 // TODO: use Query and not Scan
-func (d DriverServerGin) ReadPicturesID(ctx context.Context, params ParamsReadPicturesID) ([]serverModel.Picture, error) {
+func (d DriverServerGin) ReadPicturesID(ctx context.Context, params ParamsReadPicturesID, query QueryReadPicturesID) ([]serverModel.Picture, error) {
 	// projEx := expression.NamesList(expression.Name("ID"))
 	filtEx := expression.Name("Origin").Contains(params.Origin)
 	controllerPictures, err := d.ControllerPicture.ReadPictures(ctx, params.Collection, nil, &filtEx)
 	if err != nil {
 		return nil, err
 	}
+	if query.Sort != "" {
+		score, ok := pictureQualitySorts[query.Sort]
+		if !ok {
+			return nil, fmt.Errorf("sort `%s` not available", query.Sort)
+		}
+		desc := query.Order == "desc"
+		// pictures without scores are always last
+		sort.SliceStable(controllerPictures, func(i, j int) bool {
+			qi, qj := controllerPictures[i].Quality, controllerPictures[j].Quality
+			if !qi.Valid || !qj.Valid {
+				return qi.Valid && !qj.Valid
+			}
+			if desc {
+				return score(qi.Body) > score(qj.Body)
+			}
+			return score(qi.Body) < score(qj.Body)
+		})
+	}
 	driverServerPictures := make([]serverModel.Picture, 0, len(controllerPictures))
 	for _, controllerPicture := range controllerPictures {
 		var serverPicture serverModel.Picture
@@ -56,19 +122,22 @@ type ParamsReadPicture struct {
 func (d DriverServerGin) ReadPicture(ctx context.Context, params ParamsReadPicture) (*serverModel.Picture, error) {
 	id, err := model.ParseUUID(params.ID)
 	if err != nil {
-		return nil, err
+		return nil, paramInvalidError{Name: "id", Value: params.ID, Err: err}
 	}
 	controllerPicture, err := d.ControllerPicture.ReadPicture(ctx, params.Collection, params.Origin, id)
-	if err != nil || controllerPicture == nil {
+	if err != nil {
 		return nil, err
 	}
+	if controllerPicture == nil {
+		return nil, controllerModel.PictureNotFoundError{State: params.Collection, Origin: params.Origin, ID: params.ID}
+	}
 	var driverServerPicture serverModel.Picture
 	driverServerPicture.DriverMarshal(*controllerPicture)
 	return &driverServerPicture, nil
 }
 
 func (d DriverServerGin) ReadPicturesBlocked(ctx context.Context) ([]serverModel.Picture, error) {
-	controllerPictures, err := d.ControllerPicture.ReadPictures(ctx, "blocked", nil, nil)
+	controllerPictures, err := d.ControllerPicture.ReadPictures(ctx, controllerModel.PictureStateBlocked, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +153,6 @@ func (d DriverServerGin) ReadPicturesBlocked(ctx context.Context) ([]serverModel
 type ParamsDeletePictureAndFile struct {
 	Origin string `uri:"origin" binding:"required"`
 	ID     string `uri:"id" binding:"required"`
-	Name   string `uri:"name" binding:"required"`
 }
 
 func (d DriverServerGin) DeletePictureAndFile(ctx context.Context, params ParamsDeletePictureAndFile) (string, error) {
@@ -92,7 +160,7 @@ func (d DriverServerGin) DeletePictureAndFile(ctx context.Context, params Params
 	if err != nil {
 		return "error", err
 	}
-	if err := d.ControllerPicture.DeletePictureAndFile(ctx, params.Origin, id, params.Name); err != nil {
+	if err := d.ControllerPicture.DeletePictureAndFile(ctx, params.Origin, id, ifMatchVersion(ctx)); err != nil {
 		return "error", err
 	}
 	return "ok", nil
@@ -108,7 +176,27 @@ func (d DriverServerGin) DeletePicture(ctx context.Context, params ParamsDeleteP
 	if err != nil {
 		return "error", err
 	}
-	if err := d.ControllerPicture.DeletePicture(ctx, params.Origin, id); err != nil {
+	if err := d.ControllerPicture.DeletePicture(ctx, params.Origin, id, ifMatchVersion(ctx)); err != nil {
+		return "error", err
+	}
+	return "ok", nil
+}
+
+type BodyCreatePictureTag struct {
+	Origin *string                 `json:"origin"`
+	ID     *string                 `json:"id"`
+	Tag    *serverModel.PictureTag `json:"tag"`
+}
+
+func (d DriverServerGin) CreatePictureTag(ctx context.Context, body BodyCreatePictureTag) (string, error) {
+	if body.Origin == nil || body.ID == nil || body.Tag == nil {
+		return "error", fmt.Errorf("body fields must not be empty")
+	}
+	id, err := model.ParseUUID(*body.ID)
+	if err != nil {
+		return "error", err
+	}
+	if err := d.ControllerPicture.CreatePictureTag(ctx, *body.Origin, id, ifMatchVersion(ctx), model.NewUUID(), body.Tag.DriverUnmarshal()); err != nil {
 		return "error", err
 	}
 	return "ok", nil
@@ -117,11 +205,12 @@ func (d DriverServerGin) DeletePicture(ctx context.Context, params ParamsDeleteP
 type BodyUpdatePictureTag struct {
 	Origin *string                 `json:"origin"`
 	ID     *string                 `json:"id"`
+	TagID  *string                 `json:"tagID"`
 	Tag    *serverModel.PictureTag `json:"tag"`
 }
 
 func (d DriverServerGin) UpdatePictureTag(ctx context.Context, body BodyUpdatePictureTag) (string, error) {
-	if body.Origin == nil || body.ID == nil || body.Tag == nil {
+	if body.Origin == nil || body.ID == nil || body.TagID == nil || body.Tag == nil {
 		return "error", fmt.Errorf("body fields must not be empty")
 	}
 	if !body.Tag.BoxInformation.IsValid() {
@@ -131,7 +220,11 @@ func (d DriverServerGin) UpdatePictureTag(ctx context.Context, body BodyUpdatePi
 	if err != nil {
 		return "error", err
 	}
-	if err := d.ControllerPicture.UpdatePictureTag(ctx, *body.Origin, id, model.NewUUID(), body.Tag.DriverUnmarshal()); err != nil {
+	tagID, err := model.ParseUUID(*body.TagID)
+	if err != nil {
+		return "error", err
+	}
+	if err := d.ControllerPicture.UpdatePictureTag(ctx, *body.Origin, id, ifMatchVersion(ctx), tagID, body.Tag.DriverUnmarshal()); err != nil {
 		return "error", err
 	}
 	return "ok", nil
@@ -151,11 +244,107 @@ func (d DriverServerGin) DeletePictureTag(ctx context.Context, body BodyDeletePi
 	if err != nil {
 		return "error", err
 	}
-	tagID, err := model.ParseUUID(*body.ID)
+	tagID, err := model.ParseUUID(*body.TagID)
+	if err != nil {
+		return "error", err
+	}
+	if err := d.ControllerPicture.DeletePictureTag(ctx, *body.Origin, id, ifMatchVersion(ctx), tagID); err != nil {
+		return "error", err
+	}
+	return "ok", nil
+}
+
+type BodyCreatePictureTagSegmentation struct {
+	Origin *string                 `json:"origin"`
+	ID     *string                 `json:"id"`
+	Tag    *serverModel.PictureTag `json:"tag"`
+}
+
+func (d DriverServerGin) CreatePictureTagSegmentation(ctx context.Context, body BodyCreatePictureTagSegmentation) (string, error) {
+	if body.Origin == nil || body.ID == nil || body.Tag == nil {
+		return "error", fmt.Errorf("body fields must not be empty")
+	}
+	if !body.Tag.Segmentation.IsValid() {
+		return "error", fmt.Errorf("body not valid, tag.segmentation missing")
+	}
+	id, err := model.ParseUUID(*body.ID)
+	if err != nil {
+		return "error", err
+	}
+	if err := d.ControllerPicture.CreatePictureTagSegmentation(ctx, *body.Origin, id, ifMatchVersion(ctx), body.Tag.DriverUnmarshal()); err != nil {
+		return "error", err
+	}
+	return "ok", nil
+}
+
+type BodyUpdatePictureTagSegmentation struct {
+	Origin       *string                   `json:"origin"`
+	ID           *string                   `json:"id"`
+	TagID        *string                   `json:"tagID"`
+	Segmentation *serverModel.Segmentation `json:"segmentation"`
+}
+
+func (d DriverServerGin) UpdatePictureTagSegmentation(ctx context.Context, body BodyUpdatePictureTagSegmentation) (string, error) {
+	if body.Origin == nil || body.ID == nil || body.TagID == nil || body.Segmentation == nil {
+		return "error", fmt.Errorf("body fields must not be empty")
+	}
+	id, err := model.ParseUUID(*body.ID)
+	if err != nil {
+		return "error", err
+	}
+	tagID, err := model.ParseUUID(*body.TagID)
 	if err != nil {
 		return "error", err
 	}
-	if err := d.ControllerPicture.DeletePictureTag(ctx, *body.Origin, id, tagID); err != nil {
+	if err := d.ControllerPicture.UpdatePictureTagSegmentation(ctx, *body.Origin, id, ifMatchVersion(ctx), tagID, body.Segmentation.DriverUnmarshal()); err != nil {
+		return "error", err
+	}
+	return "ok", nil
+}
+
+type BodyCreatePictureTagKeypoints struct {
+	Origin *string                 `json:"origin"`
+	ID     *string                 `json:"id"`
+	Tag    *serverModel.PictureTag `json:"tag"`
+}
+
+func (d DriverServerGin) CreatePictureTagKeypoints(ctx context.Context, body BodyCreatePictureTagKeypoints) (string, error) {
+	if body.Origin == nil || body.ID == nil || body.Tag == nil {
+		return "error", fmt.Errorf("body fields must not be empty")
+	}
+	if !body.Tag.Keypoints.IsValid() {
+		return "error", fmt.Errorf("body not valid, tag.keypoints missing")
+	}
+	id, err := model.ParseUUID(*body.ID)
+	if err != nil {
+		return "error", err
+	}
+	if err := d.ControllerPicture.CreatePictureTagKeypoints(ctx, *body.Origin, id, ifMatchVersion(ctx), body.Tag.DriverUnmarshal()); err != nil {
+		return "error", err
+	}
+	return "ok", nil
+}
+
+type BodyUpdatePictureTagKeypoints struct {
+	Origin    *string                `json:"origin"`
+	ID        *string                `json:"id"`
+	TagID     *string                `json:"tagID"`
+	Keypoints *serverModel.Keypoints `json:"keypoints"`
+}
+
+func (d DriverServerGin) UpdatePictureTagKeypoints(ctx context.Context, body BodyUpdatePictureTagKeypoints) (string, error) {
+	if body.Origin == nil || body.ID == nil || body.TagID == nil || body.Keypoints == nil {
+		return "error", fmt.Errorf("body fields must not be empty")
+	}
+	id, err := model.ParseUUID(*body.ID)
+	if err != nil {
+		return "error", err
+	}
+	tagID, err := model.ParseUUID(*body.TagID)
+	if err != nil {
+		return "error", err
+	}
+	if err := d.ControllerPicture.UpdatePictureTagKeypoints(ctx, *body.Origin, id, ifMatchVersion(ctx), tagID, body.Keypoints.DriverUnmarshal()); err != nil {
 		return "error", err
 	}
 	return "ok", nil
@@ -164,19 +353,18 @@ func (d DriverServerGin) DeletePictureTag(ctx context.Context, body BodyDeletePi
 type BodyUpdatePictureCrop struct {
 	Origin *string          `json:"origin"`
 	ID     *string          `json:"id"`
-	Name   *string          `json:"name"`
 	Box    *serverModel.Box `json:"box"`
 }
 
 func (d DriverServerGin) UpdatePictureCrop(ctx context.Context, body BodyUpdatePictureCrop) (string, error) {
-	if body.Origin == nil || body.ID == nil || body.Name == nil || body.Box == nil {
+	if body.Origin == nil || body.ID == nil || body.Box == nil {
 		return "error", fmt.Errorf("body fields must not be empty")
 	}
 	id, err := model.ParseUUID(*body.ID)
 	if err != nil {
 		return "error", err
 	}
-	if err := d.ControllerPicture.UpdatePictureCrop(ctx, *body.Origin, id, *body.Name, model.NewUUID(), body.Box.DriverUnmarshal()); err != nil {
+	if err := d.ControllerPicture.UpdatePictureCrop(ctx, *body.Origin, id, ifMatchVersion(ctx), model.NewUUID(), body.Box.DriverUnmarshal()); err != nil {
 		return "error", err
 	}
 	return "ok", nil
@@ -185,13 +373,12 @@ func (d DriverServerGin) UpdatePictureCrop(ctx context.Context, body BodyUpdateP
 type BodyCreatePictureCrop struct {
 	Origin        *string          `json:"origin"`
 	ID            *string          `json:"id"`
-	Name          *string          `json:"name"`
 	PictureSizeID *string          `json:"pictureSizeID"`
 	Box           *serverModel.Box `json:"box"`
 }
 
 func (d DriverServerGin) CreatePictureCrop(ctx context.Context, body BodyCreatePictureCrop) (string, error) {
-	if body.Origin == nil || body.ID == nil || body.Name == nil || body.PictureSizeID == nil || body.Box == nil {
+	if body.Origin == nil || body.ID == nil || body.PictureSizeID == nil || body.Box == nil {
 		return "error", fmt.Errorf("body fields must not be empty")
 	}
 	id, err := model.ParseUUID(*body.ID)
@@ -228,21 +415,33 @@ func (d DriverServerGin) CreatePictureCopy(ctx context.Context, body BodyCreateP
 }
 
 type BodyUpdatePictureTransfer struct {
-	Origin *string `json:"origin"`
-	ID     *string `json:"id"`
-	From   *string `json:"from"`
-	To     *string `json:"to"`
+	Origin   *string `json:"origin"`
+	ID       *string `json:"id"`
+	From     *string `json:"from"`
+	To       *string `json:"to"`
+	Actor    *string `json:"actor"`
+	Reason   *string `json:"reason"`
+	Override *bool   `json:"override"` // skip the validation before production, only for the admins
 }
 
 func (d DriverServerGin) UpdatePictureTransfer(ctx context.Context, body BodyUpdatePictureTransfer) (string, error) {
-	if body.Origin == nil || body.ID == nil || body.From == nil || body.To == nil {
+	if body.Origin == nil || body.ID == nil || body.From == nil || body.To == nil || body.Actor == nil || body.Reason == nil {
 		return "error", fmt.Errorf("body fields must not be empty")
 	}
 	id, err := model.ParseUUID(*body.ID)
 	if err != nil {
 		return "error", err
 	}
-	if err := d.ControllerPicture.UpdatePictureTransfer(ctx, *body.Origin, id, *body.From, *body.To); err != nil {
+	transition := controllerModel.PictureTransition{
+		From:   *body.From,
+		To:     *body.To,
+		Actor:  *body.Actor,
+		Reason: *body.Reason,
+	}
+	if body.Override != nil {
+		transition.Override = *body.Override
+	}
+	if err := d.ControllerPicture.UpdatePictureTransfer(ctx, *body.Origin, id, ifMatchVersion(ctx), transition); err != nil {
 		return "error", err
 	}
 	return "ok", nil
@@ -251,17 +450,24 @@ func (d DriverServerGin) UpdatePictureTransfer(ctx context.Context, body BodyUpd
 type BodyCreatePictureBlocked struct {
 	Origin *string `json:"origin"`
 	ID     *string `json:"id"`
+	From   *string `json:"from"` // process by default
+	Actor  *string `json:"actor"`
+	Reason *string `json:"reason"`
 }
 
 func (d DriverServerGin) CreatePictureBlocked(ctx context.Context, body BodyCreatePictureBlocked) (string, error) {
-	if body.Origin == nil || body.ID == nil {
+	if body.Origin == nil || body.ID == nil || body.Actor == nil || body.Reason == nil {
 		return "error", fmt.Errorf("body fields must not be empty")
 	}
 	id, err := model.ParseUUID(*body.ID)
 	if err != nil {
 		return "error", err
 	}
-	if err := d.ControllerPicture.UpdatePictureTransfer(ctx, *body.Origin, id, "process", "blocked"); err != nil {
+	from := controllerModel.PictureStateProcess
+	if body.From != nil {
+		from = *body.From
+	}
+	if err := d.ControllerPicture.CreatePictureBlocked(ctx, *body.Origin, id, ifMatchVersion(ctx), from, *body.Actor, *body.Reason); err != nil {
 		return "error", err
 	}
 	return "ok", nil
@@ -270,18 +476,38 @@ func (d DriverServerGin) CreatePictureBlocked(ctx context.Context, body BodyCrea
 type BodyDeletePictureBlocked struct {
 	Origin *string `json:"origin"`
 	ID     *string `json:"id"`
+	Actor  *string `json:"actor"`
+	Reason *string `json:"reason"`
 }
 
-func (d DriverServerGin) DeletePictureBlocked(ctx context.Context, body BodyCreatePictureBlocked) (string, error) {
-	if body.Origin == nil || body.ID == nil {
+func (d DriverServerGin) DeletePictureBlocked(ctx context.Context, body BodyDeletePictureBlocked) (string, error) {
+	if body.Origin == nil || body.ID == nil || body.Actor == nil || body.Reason == nil {
 		return "error", fmt.Errorf("body fields must not be empty")
 	}
 	id, err := model.ParseUUID(*body.ID)
 	if err != nil {
 		return "error", err
 	}
-	if err := d.ControllerPicture.UpdatePictureTransfer(ctx, *body.Origin, id, "blocked", "process"); err != nil {
+	if err := d.ControllerPicture.DeletePictureBlocked(ctx, *body.Origin, id, ifMatchVersion(ctx), *body.Actor, *body.Reason); err != nil {
 		return "error", err
 	}
 	return "ok", nil
 }
+
+type ParamsExportPictures struct {
+	Collection string `uri:"collection" binding:"required"`
+}
+
+type QueryExportPictures struct {
+	Level int `form:"level,default=-1"`
+}
+
+func (d DriverServerGin) ExportPictures(ctx context.Context, params ParamsExportPictures, query QueryExportPictures) (*serverModel.Export, error) {
+	controllerExport, err := d.ControllerPicture.ExportPictures(ctx, params.Collection, query.Level)
+	if err != nil {
+		return nil, err
+	}
+	var export serverModel.Export
+	export.DriverMarshal(*controllerExport)
+	return &export, nil
+}
@@ -0,0 +1,97 @@
+package gin
+
+import (
+	"context"
+	"fmt"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	"scraper-backend/src/driver/model"
+	serverModel "scraper-backend/src/driver/server/model"
+)
+
+type QueryCreatePredictions struct {
+	Threshold float64 `form:"threshold,default=0"`   // predictions less confident are skipped
+	Replace   bool    `form:"replace,default=false"` // the tags predicted before by the same models are deleted
+}
+
+type BodyPrediction struct {
+	Origin        *string          `json:"origin"`
+	ID            *string          `json:"id"`
+	PictureSizeID *string          `json:"pictureSizeID"`
+	Tag           *string          `json:"tag"`
+	Box           *serverModel.Box `json:"box"`
+	Confidence    *float64         `json:"confidence"`
+	Model         *string          `json:"model"`
+	Weights       *string          `json:"weights"` // optional
+}
+
+// the predictions are sent as a JSON list or as one JSON object per line
+func (d DriverServerGin) CreatePredictions(ctx context.Context, query QueryCreatePredictions, body []BodyPrediction) ([]serverModel.PredictionResult, error) {
+	predictionImport := controllerModel.PredictionImport{
+		Predictions: make([]controllerModel.Prediction, 0, len(body)),
+		Threshold:   query.Threshold,
+		Replace:     query.Replace,
+	}
+	for i, prediction := range body {
+		if prediction.Origin == nil || prediction.ID == nil || prediction.PictureSizeID == nil || prediction.Tag == nil || prediction.Box == nil || prediction.Confidence == nil || prediction.Model == nil {
+			return nil, fmt.Errorf("prediction %d: body fields must not be empty", i)
+		}
+		id, err := model.ParseUUID(*prediction.ID)
+		if err != nil {
+			return nil, err
+		}
+		pictureSizeID, err := model.ParseUUID(*prediction.PictureSizeID)
+		if err != nil {
+			return nil, err
+		}
+		controllerPrediction := controllerModel.Prediction{
+			Origin:        *prediction.Origin,
+			ID:            id,
+			PictureSizeID: pictureSizeID,
+			TagName:       *prediction.Tag,
+			Box:           prediction.Box.DriverUnmarshal(),
+			Confidence:    *prediction.Confidence,
+			Model:         *prediction.Model,
+		}
+		if prediction.Weights != nil {
+			controllerPrediction.Weights = *prediction.Weights
+		}
+		predictionImport.Predictions = append(predictionImport.Predictions, controllerPrediction)
+	}
+
+	controllerResults, err := d.ControllerPicture.CreatePredictions(ctx, predictionImport)
+	if err != nil {
+		return nil, err
+	}
+	serverResults := make([]serverModel.PredictionResult, 0, len(controllerResults))
+	for _, controllerResult := range controllerResults {
+		var serverResult serverModel.PredictionResult
+		serverResult.DriverMarshal(controllerResult)
+		serverResults = append(serverResults, serverResult)
+	}
+	return serverResults, nil
+}
+
+type QueryEvaluatePredictions struct {
+	Model   string  `form:"model" binding:"required"`
+	Weights string  `form:"weights"` // every weights of the model when empty
+	State   string  `form:"state,default=validation"`
+	IoU     float64 `form:"iou,default=0.5"`
+	Worst   int     `form:"worst,default=10"` // amount of pictures with the lowest F1 listed
+}
+
+func (d DriverServerGin) EvaluatePredictions(ctx context.Context, query QueryEvaluatePredictions) (*serverModel.Evaluation, error) {
+	controllerEvaluation, err := d.ControllerPicture.EvaluatePredictions(ctx, controllerModel.EvaluationFilter{
+		State:   query.State,
+		Model:   query.Model,
+		Weights: query.Weights,
+		IoU:     query.IoU,
+		Worst:   query.Worst,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var serverEvaluation serverModel.Evaluation
+	serverEvaluation.DriverMarshal(*controllerEvaluation)
+	return &serverEvaluation, nil
+}
@@ -0,0 +1,34 @@
+package gin
+
+import (
+	"context"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	serverModel "scraper-backend/src/driver/server/model"
+)
+
+type QueryReadPicturesSample struct {
+	State    string `form:"state,default=process"`
+	Strategy string `form:"strategy,default=confidence"` // confidence, disagreement, rare or random
+	Count    int    `form:"count,default=20"`
+	Seed     int64  `form:"seed,default=0"` // random strategy only
+}
+
+func (d DriverServerGin) ReadPicturesSample(ctx context.Context, query QueryReadPicturesSample) ([]serverModel.Sample, error) {
+	controllerSamples, err := d.ControllerPicture.ReadPicturesSample(ctx, controllerModel.SampleFilter{
+		State:    query.State,
+		Strategy: query.Strategy,
+		Count:    query.Count,
+		Seed:     query.Seed,
+	})
+	if err != nil {
+		return nil, err
+	}
+	serverSamples := make([]serverModel.Sample, 0, len(controllerSamples))
+	for _, controllerSample := range controllerSamples {
+		var serverSample serverModel.Sample
+		serverSample.DriverMarshal(controllerSample)
+		serverSamples = append(serverSamples, serverSample)
+	}
+	return serverSamples, nil
+}
@@ -2,6 +2,7 @@ package gin
 
 import (
 	"context"
+	"fmt"
 	dynamodbTable "scraper-backend/src/driver/database/dynamodb/table"
 	"scraper-backend/src/driver/model"
 	serverModel "scraper-backend/src/driver/server/model"
@@ -17,11 +18,87 @@ func (d DriverServerGin) CreateTag(ctx context.Context, tag serverModel.Tag) (st
 }
 
 func (d DriverServerGin) CreateTagBlocked(ctx context.Context, tag serverModel.Tag) (string, error) {
-	tag.Type = dynamodbTable.TagPrimaryKeyBlocked
-	err := d.ControllerTag.CreateTag(ctx, tag.DriverUnmarshal())
+	err := d.ControllerTag.CreateTagBlocked(ctx, tag.DriverUnmarshal())
+	if err != nil {
+		return "error", err
+	}
+	return "ok", nil
+}
+
+type ParamsReadTag struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+func (d DriverServerGin) ReadTag(ctx context.Context, params ParamsReadTag) (*serverModel.Tag, error) {
+	id, err := model.ParseUUID(params.ID)
+	if err != nil {
+		return nil, err
+	}
+	controllerTag, err := d.ControllerTag.ReadTag(ctx, dynamodbTable.TagPrimaryKeySearched, id)
+	if err != nil {
+		return nil, err
+	}
+	var serverTag serverModel.Tag
+	serverTag.DriverMarshal(*controllerTag)
+	return &serverTag, nil
+}
+
+type BodyUpdateTagSkeleton struct {
+	ID       *string               `json:"id"`
+	Skeleton *serverModel.Skeleton `json:"skeleton"`
+}
+
+func (d DriverServerGin) UpdateTagSkeleton(ctx context.Context, body BodyUpdateTagSkeleton) (string, error) {
+	if body.ID == nil || body.Skeleton == nil {
+		return "error", fmt.Errorf("body fields must not be empty")
+	}
+	id, err := model.ParseUUID(*body.ID)
+	if err != nil {
+		return "error", err
+	}
+	if err := d.ControllerTag.UpdateTagSkeleton(ctx, dynamodbTable.TagPrimaryKeySearched, id, body.Skeleton.DriverUnmarshal()); err != nil {
+		return "error", err
+	}
+	return "ok", nil
+}
+
+type BodyUpdateTagHierarchy struct {
+	ID       *string  `json:"id"`
+	Parent   string   `json:"parent"`
+	Synonyms []string `json:"synonyms"`
+}
+
+func (d DriverServerGin) UpdateTagHierarchy(ctx context.Context, body BodyUpdateTagHierarchy) (string, error) {
+	if body.ID == nil {
+		return "error", fmt.Errorf("body field id must not be empty")
+	}
+	id, err := model.ParseUUID(*body.ID)
 	if err != nil {
 		return "error", err
 	}
+	if err := d.ControllerTag.UpdateTagHierarchy(ctx, dynamodbTable.TagPrimaryKeySearched, id, body.Parent, body.Synonyms); err != nil {
+		return "error", err
+	}
+	return "ok", nil
+}
+
+type BodyUpdateTagTarget struct {
+	ID          *string `json:"id"`
+	TargetCount int     `json:"targetCount"`
+	MaxPages    int     `json:"maxPages"`
+}
+
+func (d DriverServerGin) UpdateTagTarget(ctx context.Context, body BodyUpdateTagTarget) (string, error) {
+	if body.ID == nil {
+		return "error", fmt.Errorf("body field id must not be empty")
+	}
+	id, err := model.ParseUUID(*body.ID)
+	if err != nil {
+		return "error", err
+	}
+	if err := d.ControllerTag.UpdateTagTarget(ctx, dynamodbTable.TagPrimaryKeySearched, id, body.TargetCount, body.MaxPages); err != nil {
+		return "error", err
+	}
 	return "ok", nil
 }
 
@@ -65,6 +142,20 @@ func (d DriverServerGin) ReadTags(ctx context.Context) ([]serverModel.Tag, error
 	return serverTags, nil
 }
 
+func (d DriverServerGin) ReadTagsProgress(ctx context.Context) ([]serverModel.TagProgress, error) {
+	controllerProgresses, err := d.ControllerTag.ReadTagsProgress(ctx)
+	if err != nil {
+		return nil, err
+	}
+	serverProgresses := make([]serverModel.TagProgress, 0, len(controllerProgresses))
+	for _, controllerProgress := range controllerProgresses {
+		var serverProgress serverModel.TagProgress
+		serverProgress.DriverMarshal(controllerProgress)
+		serverProgresses = append(serverProgresses, serverProgress)
+	}
+	return serverProgresses, nil
+}
+
 func (d DriverServerGin) ReadTagsBlocked(ctx context.Context) ([]serverModel.Tag, error) {
 	controllerTags, err := d.ControllerTag.ReadTags(ctx, dynamodbTable.TagPrimaryKeyBlocked)
 	if err != nil {
@@ -78,3 +169,21 @@ func (d DriverServerGin) ReadTagsBlocked(ctx context.Context) ([]serverModel.Tag
 	}
 	return serverTags, nil
 }
+
+type BodyTestTagsBlocked struct {
+	Tags []string `json:"tags"`
+}
+
+func (d DriverServerGin) TestTagsBlocked(ctx context.Context, body BodyTestTagsBlocked) ([]serverModel.TagMatch, error) {
+	controllerMatches, err := d.ControllerTag.TestTagsBlocked(ctx, body.Tags)
+	if err != nil {
+		return nil, err
+	}
+	serverMatches := make([]serverModel.TagMatch, 0, len(controllerMatches))
+	for _, controllerMatch := range controllerMatches {
+		var serverMatch serverModel.TagMatch
+		serverMatch.DriverMarshal(controllerMatch)
+		serverMatches = append(serverMatches, serverMatch)
+	}
+	return serverMatches, nil
+}
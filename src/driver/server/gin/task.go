@@ -0,0 +1,62 @@
+package gin
+
+import (
+	"context"
+	"time"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	"scraper-backend/src/driver/model"
+	serverModel "scraper-backend/src/driver/server/model"
+)
+
+type QueryReadTaskNext struct {
+	State    string `form:"state,default=process"` // process or validation
+	Priority string `form:"priority,default=rare"` // sample strategy or oldest
+	Minutes  int    `form:"minutes,default=30"`    // duration of the lease
+}
+
+// the picture is leased to the actor of the request, null when the queue is empty
+func (d DriverServerGin) ReadTaskNext(ctx context.Context, query QueryReadTaskNext) (*serverModel.Task, error) {
+	controllerTask, err := d.ControllerTask.ReadTaskNext(ctx, query.State, query.Priority, time.Duration(query.Minutes)*time.Minute, controllerModel.ContextActor(ctx))
+	if err != nil || controllerTask == nil {
+		return nil, err
+	}
+	var serverTask serverModel.Task
+	serverTask.DriverMarshal(*controllerTask)
+	return &serverTask, nil
+}
+
+type ParamsCompleteTask struct {
+	ID string `uri:"id" binding:"required"` // ID of the picture
+}
+
+// the lease must be held by the actor of the request
+func (d DriverServerGin) CompleteTask(ctx context.Context, params ParamsCompleteTask) (string, error) {
+	id, err := model.ParseUUID(params.ID)
+	if err != nil {
+		return "error", err
+	}
+	if err := d.ControllerTask.CompleteTask(ctx, id, controllerModel.ContextActor(ctx)); err != nil {
+		return "error", err
+	}
+	return "ok", nil
+}
+
+type QueryReadTaskStats struct {
+	From time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+func (d DriverServerGin) ReadTaskStats(ctx context.Context, query QueryReadTaskStats) ([]serverModel.TaskStats, error) {
+	controllerStats, err := d.ControllerTask.ReadTaskStats(ctx, controllerModel.TaskFilter{From: query.From, To: query.To})
+	if err != nil {
+		return nil, err
+	}
+	serverStats := make([]serverModel.TaskStats, 0, len(controllerStats))
+	for _, controllerStat := range controllerStats {
+		var serverStat serverModel.TaskStats
+		serverStat.DriverMarshal(controllerStat)
+		serverStats = append(serverStats, serverStat)
+	}
+	return serverStats, nil
+}
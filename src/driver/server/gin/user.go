@@ -2,11 +2,21 @@ package gin
 
 import (
 	"context"
+	controllerModel "scraper-backend/src/adapter/controller/model"
 	"scraper-backend/src/driver/model"
 	serverModel "scraper-backend/src/driver/server/model"
 )
 
+func (d DriverServerGin) CreateUser(ctx context.Context, user serverModel.User) (string, error) {
+	err := d.ControllerUser.CreateUser(ctx, user.DriverUnmarshal())
+	if err != nil {
+		return "error", err
+	}
+	return "ok", nil
+}
+
 func (d DriverServerGin) CreateUserBlocked(ctx context.Context, user serverModel.User) (string, error) {
+	user.Status = controllerModel.UserStatusBlocked
 	err := d.ControllerUser.CreateUser(ctx, user.DriverUnmarshal())
 	if err != nil {
 		return "error", err
@@ -19,7 +29,7 @@ type ParamsDeleteUser struct {
 	ID     string `uri:"id" binding:"required"`
 }
 
-func (d DriverServerGin) DeleteUserBlocked(ctx context.Context, params ParamsDeleteUser) (string, error) {
+func (d DriverServerGin) DeleteUser(ctx context.Context, params ParamsDeleteUser) (string, error) {
 	id, err := model.ParseUUID(params.ID)
 	if err != nil {
 		return "error", err
@@ -30,8 +40,22 @@ func (d DriverServerGin) DeleteUserBlocked(ctx context.Context, params ParamsDel
 	return "ok", nil
 }
 
-func (d DriverServerGin) ReadUsers(ctx context.Context) ([]serverModel.User, error) {
-	controllerUsers, err := d.ControllerUser.ReadUsers(ctx)
+func (d DriverServerGin) ReadUsersStats(ctx context.Context) ([]serverModel.UserStats, error) {
+	controllerStats, err := d.ControllerUser.ReadUsersStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	serverStats := make([]serverModel.UserStats, 0, len(controllerStats))
+	for _, controllerStat := range controllerStats {
+		var serverStat serverModel.UserStats
+		serverStat.DriverMarshal(controllerStat)
+		serverStats = append(serverStats, serverStat)
+	}
+	return serverStats, nil
+}
+
+func (d DriverServerGin) ReadUsersBlocked(ctx context.Context) ([]serverModel.User, error) {
+	controllerUsers, err := d.ControllerUser.ReadUsersStatus(ctx, controllerModel.UserStatusBlocked)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"time"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+)
+
+type AnnotationSet struct {
+	ID           model.UUID   `json:"id"`
+	Annotator    string       `json:"annotator,omitempty"`
+	Tags         []PictureTag `json:"tags,omitempty"`
+	Status       string       `json:"status,omitempty"`
+	CreationDate time.Time    `json:"creationDate,omitempty"`
+	Reviewer     string       `json:"reviewer,omitempty"`
+	ReviewDate   time.Time    `json:"reviewDate,omitempty"`
+}
+
+func (as *AnnotationSet) DriverMarshal(value controllerModel.AnnotationSet) {
+	as.ID = value.ID
+	as.Annotator = value.Annotator
+	as.Status = value.Status
+	as.CreationDate = value.CreationDate
+	as.Reviewer = value.Reviewer
+	as.ReviewDate = value.ReviewDate
+
+	tags := make([]PictureTag, 0, len(value.Tags))
+	for _, controllerTag := range value.Tags {
+		var serverTag PictureTag
+		serverTag.DriverMarshal(controllerTag)
+		tags = append(tags, serverTag)
+	}
+	as.Tags = tags
+}
+
+func (as AnnotationSet) DriverUnmarshal() controllerModel.AnnotationSet {
+	tags := make([]controllerModel.PictureTag, 0, len(as.Tags))
+	for _, serverTag := range as.Tags {
+		tags = append(tags, serverTag.DriverUnmarshal())
+	}
+	return controllerModel.AnnotationSet{
+		ID:           as.ID,
+		Annotator:    as.Annotator,
+		Tags:         tags,
+		Status:       as.Status,
+		CreationDate: as.CreationDate,
+		Reviewer:     as.Reviewer,
+		ReviewDate:   as.ReviewDate,
+	}
+}
+
+type AnnotationAgreement struct {
+	Origin    string     `json:"origin,omitempty"`
+	ID        model.UUID `json:"id,omitempty"`
+	Version   int        `json:"version,omitempty"`
+	Sets      int        `json:"sets"`
+	Agreement float64    `json:"agreement"`
+}
+
+func (aa *AnnotationAgreement) DriverMarshal(value controllerModel.AnnotationAgreement) {
+	aa.Origin = value.Origin
+	aa.ID = value.ID
+	aa.Version = value.Version
+	aa.Sets = value.Sets
+	aa.Agreement = value.Agreement
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"encoding/json"
+	"time"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+)
+
+type Audit struct {
+	ID           model.UUID      `json:"id,omitempty"`
+	Entity       string          `json:"entity,omitempty"`
+	Origin       string          `json:"origin,omitempty"`
+	EntityID     model.UUID      `json:"entityID,omitempty"`
+	Action       string          `json:"action,omitempty"`
+	Actor        string          `json:"actor,omitempty"`
+	CreationDate time.Time       `json:"creationDate,omitempty"`
+	Before       json.RawMessage `json:"before,omitempty"` // fields changed by the action
+	After        json.RawMessage `json:"after,omitempty"`
+}
+
+func (a *Audit) DriverMarshal(value controllerModel.Audit) {
+	a.ID = value.ID
+	a.Entity = value.Entity
+	a.Origin = value.Origin
+	a.EntityID = value.EntityID
+	a.Action = value.Action
+	a.Actor = value.Actor
+	a.CreationDate = value.CreationDate
+	if value.Before != "" {
+		a.Before = json.RawMessage(value.Before)
+	}
+	if value.After != "" {
+		a.After = json.RawMessage(value.After)
+	}
+}
@@ -0,0 +1,20 @@
+package controller
+
+import (
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+)
+
+type PictureBulkResult struct {
+	Origin  string     `json:"origin"`
+	ID      model.UUID `json:"id"`
+	Status  string     `json:"status"`
+	Message string     `json:"message,omitempty"`
+}
+
+func (pbr *PictureBulkResult) DriverMarshal(value controllerModel.PictureBulkResult) {
+	pbr.Origin = value.Origin
+	pbr.ID = value.ID
+	pbr.Status = value.Status
+	pbr.Message = value.Message
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+)
+
+type Evaluation struct {
+	State    string              `json:"state,omitempty"`
+	Model    string              `json:"model,omitempty"`
+	Weights  string              `json:"weights,omitempty"`
+	IoU      float64             `json:"iou"`
+	Pictures int                 `json:"pictures"`
+	MeanAP   float64             `json:"meanAP"`
+	Tags     []EvaluationTag     `json:"tags"`
+	Worst    []EvaluationPicture `json:"worst"`
+}
+
+func (e *Evaluation) DriverMarshal(value controllerModel.Evaluation) {
+	e.State = value.State
+	e.Model = value.Model
+	e.Weights = value.Weights
+	e.IoU = value.IoU
+	e.Pictures = value.Pictures
+	e.MeanAP = value.MeanAP
+
+	tags := make([]EvaluationTag, 0, len(value.Tags))
+	for _, controllerTag := range value.Tags {
+		var serverTag EvaluationTag
+		serverTag.DriverMarshal(controllerTag)
+		tags = append(tags, serverTag)
+	}
+	e.Tags = tags
+
+	worst := make([]EvaluationPicture, 0, len(value.Worst))
+	for _, controllerPicture := range value.Worst {
+		var serverPicture EvaluationPicture
+		serverPicture.DriverMarshal(controllerPicture)
+		worst = append(worst, serverPicture)
+	}
+	e.Worst = worst
+}
+
+type EvaluationTag struct {
+	Name          string  `json:"name,omitempty"`
+	Humans        int     `json:"humans"`
+	Predictions   int     `json:"predictions"`
+	TruePositives int     `json:"truePositives"`
+	Precision     float64 `json:"precision"`
+	Recall        float64 `json:"recall"`
+	AP            float64 `json:"ap"`
+}
+
+func (et *EvaluationTag) DriverMarshal(value controllerModel.EvaluationTag) {
+	et.Name = value.Name
+	et.Humans = value.Humans
+	et.Predictions = value.Predictions
+	et.TruePositives = value.TruePositives
+	et.Precision = value.Precision
+	et.Recall = value.Recall
+	et.AP = value.AP
+}
+
+type EvaluationPicture struct {
+	Origin         string     `json:"origin,omitempty"`
+	ID             model.UUID `json:"id,omitempty"`
+	TruePositives  int        `json:"truePositives"`
+	FalsePositives int        `json:"falsePositives"`
+	FalseNegatives int        `json:"falseNegatives"`
+	F1             float64    `json:"f1"`
+}
+
+func (ep *EvaluationPicture) DriverMarshal(value controllerModel.EvaluationPicture) {
+	ep.Origin = value.Origin
+	ep.ID = value.ID
+	ep.TruePositives = value.TruePositives
+	ep.FalsePositives = value.FalsePositives
+	ep.FalseNegatives = value.FalseNegatives
+	ep.F1 = value.F1
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+)
+
+type Export struct {
+	Images      []ExportImage      `json:"images"`
+	Annotations []ExportAnnotation `json:"annotations"`
+	Categories  []ExportCategory   `json:"categories"`
+}
+
+func (e *Export) DriverMarshal(value controllerModel.Export) {
+	e.Images = make([]ExportImage, 0, len(value.Images))
+	for _, image := range value.Images {
+		e.Images = append(e.Images, ExportImage{
+			ID:        image.ID,
+			Origin:    image.Origin,
+			PictureID: image.PictureID,
+			FileName:  image.FileName,
+			Width:     image.Width,
+			Height:    image.Height,
+		})
+	}
+	e.Annotations = make([]ExportAnnotation, 0, len(value.Annotations))
+	for _, controllerAnnotation := range value.Annotations {
+		var annotation ExportAnnotation
+		annotation.DriverMarshal(controllerAnnotation)
+		e.Annotations = append(e.Annotations, annotation)
+	}
+	e.Categories = make([]ExportCategory, 0, len(value.Categories))
+	for _, controllerCategory := range value.Categories {
+		var category ExportCategory
+		category.DriverMarshal(controllerCategory)
+		e.Categories = append(e.Categories, category)
+	}
+}
+
+type ExportImage struct {
+	ID        int        `json:"id"`
+	Origin    string     `json:"origin"`
+	PictureID model.UUID `json:"pictureID"`
+	FileName  string     `json:"file_name"`
+	Width     int        `json:"width"`
+	Height    int        `json:"height"`
+}
+
+type ExportAnnotation struct {
+	ID           int       `json:"id"`
+	ImageID      int       `json:"image_id"`
+	CategoryID   int       `json:"category_id"`
+	BBox         []int     `json:"bbox"`                   // [x, y, width, height]
+	Segmentation any       `json:"segmentation,omitempty"` // flattened polygons or RLE mask
+	Area         float64   `json:"area"`
+	IsCrowd      int       `json:"iscrowd"`
+	Keypoints    []float64 `json:"keypoints,omitempty"` // [x, y, visibility, ...] in the order of the category
+	NumKeypoints int       `json:"num_keypoints,omitempty"`
+}
+
+func (ea *ExportAnnotation) DriverMarshal(value controllerModel.ExportAnnotation) {
+	ea.ID = value.ID
+	ea.ImageID = value.ImageID
+	ea.CategoryID = value.CategoryID
+	ea.BBox = []int{value.Box.Tlx, value.Box.Tly, value.Box.Width, value.Box.Height}
+	if value.Mask.Valid {
+		ea.Segmentation = ExportMask{
+			Size:   []int{value.Mask.Body.Height, value.Mask.Body.Width},
+			Counts: value.Mask.Body.Counts,
+		}
+	} else if len(value.Polygons) > 0 {
+		polygons := make([][]float64, 0, len(value.Polygons))
+		for _, polygon := range value.Polygons {
+			points := make([]float64, 0, 2*len(polygon))
+			for _, point := range polygon {
+				points = append(points, point.X, point.Y)
+			}
+			polygons = append(polygons, points)
+		}
+		ea.Segmentation = polygons
+	}
+	ea.Area = value.Area
+	if value.IsCrowd {
+		ea.IsCrowd = 1
+	}
+	for _, point := range value.Keypoints {
+		ea.Keypoints = append(ea.Keypoints, point.X, point.Y, float64(point.Visibility))
+		if point.Visibility != controllerModel.KeypointNotLabeled {
+			ea.NumKeypoints++
+		}
+	}
+}
+
+type ExportMask struct {
+	Size   []int `json:"size"` // [height, width]
+	Counts []int `json:"counts"`
+}
+
+type ExportCategory struct {
+	ID        int      `json:"id"`
+	Name      string   `json:"name"`
+	Keypoints []string `json:"keypoints,omitempty"`
+	Skeleton  [][2]int `json:"skeleton,omitempty"` // links between the keypoints, indexed from 1
+}
+
+func (ec *ExportCategory) DriverMarshal(value controllerModel.ExportCategory) {
+	ec.ID = value.ID
+	ec.Name = value.Name
+	if value.Skeleton.Valid {
+		ec.Keypoints = value.Skeleton.Body.Keypoints
+		for _, link := range value.Skeleton.Body.Links {
+			ec.Skeleton = append(ec.Skeleton, [2]int{link[0] + 1, link[1] + 1})
+		}
+	}
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 
 	controllerModel "scraper-backend/src/adapter/controller/model"
@@ -9,18 +10,36 @@ import (
 )
 
 type Picture struct {
-	Origin       string        `json:"origin,omitempty"`
-	ID           model.UUID    `json:"id,omitempty"`
-	Name         string        `json:"name,omitempty"`
-	OriginID     string        `json:"originID,omitempty"`
-	User         User          `json:"user,omitempty"`
-	Extension    string        `json:"extension,omitempty"`
-	Sizes        []PictureSize `json:"sizes,omitempty"`
-	Title        string        `json:"title,omitempty"`
-	Description  string        `json:"description,omitempty"`
-	License      string        `json:"license,omitempty"`
-	CreationDate time.Time     `json:"creationDate,omitempty"`
-	Tags         []PictureTag  `json:"tags,omitempty"`
+	Origin         string                         `json:"origin,omitempty"`
+	ID             model.UUID                     `json:"id,omitempty"`
+	Name           string                         `json:"name,omitempty"`
+	OriginID       string                         `json:"originID,omitempty"`
+	User           User                           `json:"user,omitempty"`
+	Extension      string                         `json:"extension,omitempty"`
+	Sizes          []PictureSize                  `json:"sizes,omitempty"`
+	Title          string                         `json:"title,omitempty"`
+	Description    string                         `json:"description,omitempty"`
+	License        string                         `json:"license,omitempty"`
+	CreationDate   time.Time                      `json:"creationDate,omitempty"`
+	Tags           []PictureTag                   `json:"tags,omitempty"`
+	AnnotationSets []AnnotationSet                `json:"annotationSets,omitempty"`
+	Transitions    []PictureTransition            `json:"transitions,omitempty"`
+	Metadata       map[string]string              `json:"metadata,omitempty"`
+	Quality        model.Nullable[PictureQuality] `json:"quality,omitempty"`
+	Version        int                            `json:"version,omitempty"`
+}
+
+// entity tag of a version of a picture, sent back in If-Match to edit it
+func PictureETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// the ETag header of the response
+func (p *Picture) ETag() string {
+	if p == nil {
+		return ""
+	}
+	return PictureETag(p.Version)
 }
 
 func (p *Picture) DriverMarshal(value controllerModel.Picture) {
@@ -33,6 +52,13 @@ func (p *Picture) DriverMarshal(value controllerModel.Picture) {
 	p.Description = value.Description
 	p.License = value.License
 	p.CreationDate = value.CreationDate
+	p.Metadata = value.Metadata
+	p.Version = value.Version
+	if value.Quality.Valid {
+		var quality PictureQuality
+		quality.DriverMarshal(value.Quality.Body)
+		p.Quality = model.NewNullable(quality)
+	}
 
 	var user User
 	user.DriverMarshal(value.User)
@@ -53,6 +79,22 @@ func (p *Picture) DriverMarshal(value controllerModel.Picture) {
 		tags = append(tags, driverTag)
 	}
 	p.Tags = tags
+
+	annotationSets := make([]AnnotationSet, 0, len(value.AnnotationSets))
+	for _, controllerSet := range value.AnnotationSets {
+		var serverSet AnnotationSet
+		serverSet.DriverMarshal(controllerSet)
+		annotationSets = append(annotationSets, serverSet)
+	}
+	p.AnnotationSets = annotationSets
+
+	transitions := make([]PictureTransition, 0, len(value.Transitions))
+	for _, controllerTransition := range value.Transitions {
+		var driverTransition PictureTransition
+		driverTransition.DriverMarshal(controllerTransition)
+		transitions = append(transitions, driverTransition)
+	}
+	p.Transitions = transitions
 }
 
 func (p Picture) DriverUnmarshal() *controllerModel.Picture {
@@ -74,6 +116,18 @@ func (p Picture) DriverUnmarshal() *controllerModel.Picture {
 		picture.Tags = tags
 	}
 
+	annotationSets := make([]controllerModel.AnnotationSet, 0, len(p.AnnotationSets))
+	for _, annotationSet := range p.AnnotationSets {
+		annotationSets = append(annotationSets, annotationSet.DriverUnmarshal())
+	}
+
+	transitions := make([]controllerModel.PictureTransition, 0, len(p.Transitions))
+	if p.Transitions != nil {
+		for _, pictureTransition := range p.Transitions {
+			transitions = append(transitions, pictureTransition.DriverUnmarshal())
+		}
+	}
+
 	picture.Origin = p.Origin
 	picture.ID = p.ID
 	picture.OriginID = p.OriginID
@@ -85,6 +139,13 @@ func (p Picture) DriverUnmarshal() *controllerModel.Picture {
 	picture.License = p.License
 	picture.CreationDate = p.CreationDate
 	picture.Tags = tags
+	picture.AnnotationSets = annotationSets
+	picture.Transitions = transitions
+	picture.Metadata = p.Metadata
+	picture.Version = p.Version
+	if p.Quality.Valid {
+		picture.Quality = model.NewNullable(p.Quality.Body.DriverUnmarshal())
+	}
 	return &picture
 }
 
@@ -92,11 +153,13 @@ type PictureSize struct {
 	ID           model.UUID `json:"id"`
 	CreationDate time.Time  `json:"creationDate,omitempty"`
 	Box          Box        `json:"box,omitempty"` // absolut reference of the top left of new box based on the original sizes
+	ObjectKey    string     `json:"objectKey,omitempty"`
 }
 
 func (ps *PictureSize) DriverMarshal(value controllerModel.PictureSize) {
 	ps.ID = value.ID
 	ps.CreationDate = value.CreationDate
+	ps.ObjectKey = value.ObjectKey
 
 	var box Box
 	box.DriverMarshal(value.Box)
@@ -108,6 +171,7 @@ func (ps PictureSize) DriverUnmarshal() controllerModel.PictureSize {
 		ID:           ps.ID,
 		CreationDate: ps.CreationDate,
 		Box:          ps.Box.DriverUnmarshal(),
+		ObjectKey:    ps.ObjectKey,
 	}
 }
 
@@ -140,6 +204,8 @@ type PictureTag struct {
 	CreationDate   time.Time                      `json:"creationDate,omitempty"`
 	OriginName     string                         `json:"originName,omitempty"`
 	BoxInformation model.Nullable[BoxInformation] `json:"boxInformation,omitempty"`
+	Segmentation   model.Nullable[Segmentation]   `json:"segmentation,omitempty"`
+	Keypoints      model.Nullable[Keypoints]      `json:"keypoints,omitempty"`
 }
 
 func (pt *PictureTag) DriverMarshal(value controllerModel.PictureTag) {
@@ -154,6 +220,18 @@ func (pt *PictureTag) DriverMarshal(value controllerModel.PictureTag) {
 		boxInformation.DriverMarshal(value.BoxInformation.Body)
 		pt.BoxInformation = model.NewNullable(boxInformation)
 	}
+
+	if value.Segmentation.Valid {
+		var segmentation Segmentation
+		segmentation.DriverMarshal(value.Segmentation.Body)
+		pt.Segmentation = model.NewNullable(segmentation)
+	}
+
+	if value.Keypoints.Valid {
+		var keypoints Keypoints
+		keypoints.DriverMarshal(value.Keypoints.Body)
+		pt.Keypoints = model.NewNullable(keypoints)
+	}
 }
 
 func (pt PictureTag) DriverUnmarshal() controllerModel.PictureTag {
@@ -161,6 +239,14 @@ func (pt PictureTag) DriverUnmarshal() controllerModel.PictureTag {
 	if pt.BoxInformation.IsValid() {
 		boxInformation = model.NewNullable(pt.BoxInformation.Body.DriverUnmarshal())
 	}
+	var segmentation model.Nullable[controllerModel.Segmentation]
+	if pt.Segmentation.IsValid() {
+		segmentation = model.NewNullable(pt.Segmentation.Body.DriverUnmarshal())
+	}
+	var keypoints model.Nullable[controllerModel.Keypoints]
+	if pt.Keypoints.IsValid() {
+		keypoints = model.NewNullable(pt.Keypoints.Body.DriverUnmarshal())
+	}
 
 	return controllerModel.PictureTag{
 		ID:             pt.ID,
@@ -168,6 +254,8 @@ func (pt PictureTag) DriverUnmarshal() controllerModel.PictureTag {
 		CreationDate:   pt.CreationDate,
 		OriginName:     pt.OriginName,
 		BoxInformation: boxInformation,
+		Segmentation:   segmentation,
+		Keypoints:      keypoints,
 	}
 }
 
@@ -221,3 +309,186 @@ func (bi BoxInformation) DriverUnmarshal() controllerModel.BoxInformation {
 	}
 	return boxInformation
 }
+
+type PictureTransition struct {
+	ID           model.UUID `json:"id"`
+	From         string     `json:"from,omitempty"`
+	To           string     `json:"to,omitempty"`
+	Actor        string     `json:"actor,omitempty"`
+	Reason       string     `json:"reason,omitempty"`
+	Override     bool       `json:"override,omitempty"`
+	CreationDate time.Time  `json:"creationDate,omitempty"`
+}
+
+func (pt *PictureTransition) DriverMarshal(value controllerModel.PictureTransition) {
+	pt.ID = value.ID
+	pt.From = value.From
+	pt.To = value.To
+	pt.Actor = value.Actor
+	pt.Reason = value.Reason
+	pt.Override = value.Override
+	pt.CreationDate = value.CreationDate
+}
+
+func (pt PictureTransition) DriverUnmarshal() controllerModel.PictureTransition {
+	return controllerModel.PictureTransition{
+		ID:           pt.ID,
+		From:         pt.From,
+		To:           pt.To,
+		Actor:        pt.Actor,
+		Reason:       pt.Reason,
+		Override:     pt.Override,
+		CreationDate: pt.CreationDate,
+	}
+}
+
+type PictureQuality struct {
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	Blur        float64 `json:"blur"`
+	Luminance   float64 `json:"luminance"`
+	DarkRatio   float64 `json:"darkRatio"`
+	BrightRatio float64 `json:"brightRatio"`
+	Hash        string  `json:"hash,omitempty"`
+}
+
+func (pq *PictureQuality) DriverMarshal(value controllerModel.PictureQuality) {
+	pq.Width = value.Width
+	pq.Height = value.Height
+	pq.Blur = value.Blur
+	pq.Luminance = value.Luminance
+	pq.DarkRatio = value.DarkRatio
+	pq.BrightRatio = value.BrightRatio
+	pq.Hash = value.Hash
+}
+
+func (pq PictureQuality) DriverUnmarshal() controllerModel.PictureQuality {
+	return controllerModel.PictureQuality{
+		Width:       pq.Width,
+		Height:      pq.Height,
+		Blur:        pq.Blur,
+		Luminance:   pq.Luminance,
+		DarkRatio:   pq.DarkRatio,
+		BrightRatio: pq.BrightRatio,
+		Hash:        pq.Hash,
+	}
+}
+
+type Segmentation struct {
+	Model         string     `json:"model,omitempty"`         // name of the model used for the segmentation
+	Weights       string     `json:"weights,omitempty"`       // weights of the model used for the segmentation
+	PictureSizeID model.UUID `json:"pictureSizeID,omitempty"` // reference to the anchor point
+	Polygons      [][]Point  `json:"polygons,omitempty"`      // outlines relative to the anchor
+	Mask          *Mask      `json:"mask,omitempty"`
+	Confidence    float64    `json:"confidence,omitempty"` // accuracy of the model
+}
+
+func (s *Segmentation) DriverMarshal(value controllerModel.Segmentation) {
+	if value.Model.Valid {
+		s.Model = value.Model.String
+	}
+	if value.Weights.Valid {
+		s.Weights = value.Weights.String
+	}
+	s.PictureSizeID = value.PictureSizeID
+	s.Polygons = make([][]Point, 0, len(value.Polygons))
+	for _, polygon := range value.Polygons {
+		points := make([]Point, 0, len(polygon))
+		for _, point := range polygon {
+			points = append(points, Point{X: point.X, Y: point.Y})
+		}
+		s.Polygons = append(s.Polygons, points)
+	}
+	if value.Mask.Valid {
+		s.Mask = &Mask{Width: value.Mask.Body.Width, Height: value.Mask.Body.Height, Counts: value.Mask.Body.Counts}
+	}
+	if value.Confidence.Valid {
+		s.Confidence = value.Confidence.Float64
+	}
+}
+
+func (s Segmentation) DriverUnmarshal() controllerModel.Segmentation {
+	var segmentation controllerModel.Segmentation
+	if s.Model != "" {
+		segmentation.Model = sql.NullString{String: s.Model, Valid: true}
+	}
+	if s.Weights != "" {
+		segmentation.Weights = sql.NullString{String: s.Weights, Valid: true}
+	}
+	segmentation.PictureSizeID = s.PictureSizeID
+	for _, points := range s.Polygons {
+		polygon := make(controllerModel.Polygon, 0, len(points))
+		for _, point := range points {
+			polygon = append(polygon, controllerModel.Point{X: point.X, Y: point.Y})
+		}
+		segmentation.Polygons = append(segmentation.Polygons, polygon)
+	}
+	if s.Mask != nil {
+		segmentation.Mask = model.NewNullable(controllerModel.Mask{Width: s.Mask.Width, Height: s.Mask.Height, Counts: s.Mask.Counts})
+	}
+	if s.Confidence != 0 {
+		segmentation.Confidence = sql.NullFloat64{Float64: s.Confidence, Valid: true}
+	}
+	return segmentation
+}
+
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type Mask struct {
+	Width  int   `json:"width"`
+	Height int   `json:"height"`
+	Counts []int `json:"counts"` // run-length encoding in column-major order, starting with zeros
+}
+
+type Keypoints struct {
+	Model         string     `json:"model,omitempty"`         // name of the model used for the pose estimation
+	Weights       string     `json:"weights,omitempty"`       // weights of the model used for the pose estimation
+	PictureSizeID model.UUID `json:"pictureSizeID,omitempty"` // reference to the anchor point
+	Points        []Keypoint `json:"points,omitempty"`        // positions relative to the anchor
+	Confidence    float64    `json:"confidence,omitempty"`    // accuracy of the model
+}
+
+func (k *Keypoints) DriverMarshal(value controllerModel.Keypoints) {
+	if value.Model.Valid {
+		k.Model = value.Model.String
+	}
+	if value.Weights.Valid {
+		k.Weights = value.Weights.String
+	}
+	k.PictureSizeID = value.PictureSizeID
+	k.Points = make([]Keypoint, 0, len(value.Points))
+	for _, point := range value.Points {
+		k.Points = append(k.Points, Keypoint{Name: point.Name, X: point.X, Y: point.Y, Visibility: point.Visibility})
+	}
+	if value.Confidence.Valid {
+		k.Confidence = value.Confidence.Float64
+	}
+}
+
+func (k Keypoints) DriverUnmarshal() controllerModel.Keypoints {
+	var keypoints controllerModel.Keypoints
+	if k.Model != "" {
+		keypoints.Model = sql.NullString{String: k.Model, Valid: true}
+	}
+	if k.Weights != "" {
+		keypoints.Weights = sql.NullString{String: k.Weights, Valid: true}
+	}
+	keypoints.PictureSizeID = k.PictureSizeID
+	for _, point := range k.Points {
+		keypoints.Points = append(keypoints.Points, controllerModel.Keypoint{Name: point.Name, X: point.X, Y: point.Y, Visibility: point.Visibility})
+	}
+	if k.Confidence != 0 {
+		keypoints.Confidence = sql.NullFloat64{Float64: k.Confidence, Valid: true}
+	}
+	return keypoints
+}
+
+type Keypoint struct {
+	Name       string  `json:"name"`
+	X          float64 `json:"x"`
+	Y          float64 `json:"y"`
+	Visibility int     `json:"visibility"` // 0 not labeled, 1 occluded, 2 visible
+}
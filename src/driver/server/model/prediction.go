@@ -0,0 +1,28 @@
+package controller
+
+import (
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+)
+
+type PredictionResult struct {
+	Origin   string     `json:"origin"`
+	ID       model.UUID `json:"id"`
+	Status   string     `json:"status"`
+	Created  int        `json:"created"`
+	Replaced int        `json:"replaced"`
+	Skipped  int        `json:"skipped"`
+	Invalid  []string   `json:"invalid,omitempty"`
+	Message  string     `json:"message,omitempty"`
+}
+
+func (pr *PredictionResult) DriverMarshal(value controllerModel.PredictionResult) {
+	pr.Origin = value.Origin
+	pr.ID = value.ID
+	pr.Status = value.Status
+	pr.Created = value.Created
+	pr.Replaced = value.Replaced
+	pr.Skipped = value.Skipped
+	pr.Invalid = value.Invalid
+	pr.Message = value.Message
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+)
+
+type Sample struct {
+	Origin     string       `json:"origin,omitempty"`
+	ID         model.UUID   `json:"id,omitempty"`
+	Score      float64      `json:"score"`
+	Duplicates []PictureKey `json:"duplicates,omitempty"` // near duplicates left out of the sample
+}
+
+func (s *Sample) DriverMarshal(value controllerModel.Sample) {
+	s.Origin = value.Origin
+	s.ID = value.ID
+	s.Score = value.Score
+
+	duplicates := make([]PictureKey, 0, len(value.Duplicates))
+	for _, controllerKey := range value.Duplicates {
+		var serverKey PictureKey
+		serverKey.DriverMarshal(controllerKey)
+		duplicates = append(duplicates, serverKey)
+	}
+	s.Duplicates = duplicates
+}
+
+type PictureKey struct {
+	Origin string     `json:"origin"`
+	ID     model.UUID `json:"id"`
+}
+
+func (pk *PictureKey) DriverMarshal(value controllerModel.PictureKey) {
+	pk.Origin = value.Origin
+	pk.ID = value.ID
+}
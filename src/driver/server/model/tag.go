@@ -13,6 +13,12 @@ type Tag struct {
 	Name         string     `json:",omitempty"`
 	CreationDate time.Time  `json:",omitempty"`
 	OriginName   string     `json:",omitempty"`
+	Skeleton     *Skeleton  `json:",omitempty"`
+	Parent       string     `json:",omitempty"`
+	Synonyms     []string   `json:",omitempty"`
+	Mode         string     `json:",omitempty"`
+	TargetCount  int        `json:",omitempty"`
+	MaxPages     int        `json:",omitempty"`
 }
 
 func (t *Tag) DriverMarshal(value controllerModel.Tag) {
@@ -21,14 +27,71 @@ func (t *Tag) DriverMarshal(value controllerModel.Tag) {
 	t.Name = value.Name
 	t.CreationDate = value.CreationDate
 	t.OriginName = value.OriginName
+	t.Parent = value.Parent
+	t.Synonyms = value.Synonyms
+	t.Mode = value.Mode
+	t.TargetCount = value.TargetCount
+	t.MaxPages = value.MaxPages
+	if value.Skeleton.Valid {
+		t.Skeleton = &Skeleton{Keypoints: value.Skeleton.Body.Keypoints, Links: value.Skeleton.Body.Links}
+	}
 }
 
 func (t Tag) DriverUnmarshal() controllerModel.Tag {
+	var skeleton model.Nullable[controllerModel.Skeleton]
+	if t.Skeleton != nil {
+		skeleton = model.NewNullable(t.Skeleton.DriverUnmarshal())
+	}
 	return controllerModel.Tag{
 		Type:         t.Type,
 		ID:           t.ID,
 		Name:         t.Name,
 		CreationDate: t.CreationDate,
 		OriginName:   t.OriginName,
+		Skeleton:     skeleton,
+		Parent:       t.Parent,
+		Synonyms:     t.Synonyms,
+		Mode:         t.Mode,
+		TargetCount:  t.TargetCount,
+		MaxPages:     t.MaxPages,
 	}
 }
+
+type Skeleton struct {
+	Keypoints []string `json:"keypoints"`
+	Links     [][2]int `json:"links,omitempty"` // pairs of indexes in keypoints
+}
+
+func (s Skeleton) DriverUnmarshal() controllerModel.Skeleton {
+	return controllerModel.Skeleton{Keypoints: s.Keypoints, Links: s.Links}
+}
+
+type TagMatch struct {
+	Name    string `json:"name"`
+	Tag     Tag    `json:"tag"`
+	Pattern string `json:"pattern"`
+}
+
+func (t *TagMatch) DriverMarshal(value controllerModel.TagMatch) {
+	t.Name = value.Name
+	t.Tag.DriverMarshal(value.Tag)
+	t.Pattern = value.Pattern
+}
+
+type TagProgress struct {
+	Name        string `json:"name"`
+	TargetCount int    `json:"targetCount"`
+	Process     int    `json:"process"`
+	Validation  int    `json:"validation"`
+	Production  int    `json:"production"`
+	Remaining   int    `json:"remaining"`
+}
+
+func (t *TagProgress) DriverMarshal(value controllerModel.TagProgress) {
+	t.Name = value.Name
+	t.TargetCount = value.TargetCount
+	t.Process = value.Process
+	t.Validation = value.Validation
+	t.Production = value.Production
+	t.Remaining = value.Remaining
+}
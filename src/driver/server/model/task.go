@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"time"
+
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	model "scraper-backend/src/driver/model"
+)
+
+type Task struct {
+	ID             model.UUID `json:"id,omitempty"` // ID of the picture
+	State          string     `json:"state,omitempty"`
+	Origin         string     `json:"origin,omitempty"`
+	User           string     `json:"user,omitempty"`
+	Status         string     `json:"status,omitempty"`
+	Priority       float64    `json:"priority"`
+	LeaseDate      time.Time  `json:"leaseDate,omitempty"`
+	ExpirationDate time.Time  `json:"expirationDate,omitempty"`
+	CompletionDate time.Time  `json:"completionDate,omitempty"`
+}
+
+func (t *Task) DriverMarshal(value controllerModel.Task) {
+	t.ID = value.ID
+	t.State = value.State
+	t.Origin = value.Origin
+	t.User = value.User
+	t.Status = value.Status
+	t.Priority = value.Priority
+	t.LeaseDate = value.LeaseDate
+	t.ExpirationDate = value.ExpirationDate
+	t.CompletionDate = value.CompletionDate
+}
+
+type TaskStats struct {
+	User         string  `json:"user,omitempty"`
+	Leased       int     `json:"leased"`
+	Expired      int     `json:"expired"`
+	Completed    int     `json:"completed"`
+	MeanDuration float64 `json:"meanDuration"` // seconds
+	PerHour      float64 `json:"perHour"`
+}
+
+func (ts *TaskStats) DriverMarshal(value controllerModel.TaskStats) {
+	ts.User = value.User
+	ts.Leased = value.Leased
+	ts.Expired = value.Expired
+	ts.Completed = value.Completed
+	ts.MeanDuration = value.MeanDuration.Seconds()
+	ts.PerHour = value.PerHour
+}
@@ -13,6 +13,8 @@ type User struct {
 	Name         string     `json:"name,omitempty"`
 	OriginID     string     `json:"originID,omitempty"`
 	CreationDate time.Time  `json:"creationDate,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	Quota        int        `json:"quota,omitempty"`
 }
 
 func (u *User) DriverMarshal(value controllerModel.User) {
@@ -21,6 +23,8 @@ func (u *User) DriverMarshal(value controllerModel.User) {
 	u.Name = value.Name
 	u.OriginID = value.OriginID
 	u.CreationDate = value.CreationDate
+	u.Status = value.Status
+	u.Quota = value.Quota
 }
 
 func (u User) DriverUnmarshal() controllerModel.User {
@@ -30,5 +34,21 @@ func (u User) DriverUnmarshal() controllerModel.User {
 		Name:         u.Name,
 		OriginID:     u.OriginID,
 		CreationDate: u.CreationDate,
+		Status:       u.Status,
+		Quota:        u.Quota,
 	}
 }
+
+type UserStats struct {
+	User     User `json:"user"`
+	Ingested int  `json:"ingested"`
+	Blocked  int  `json:"blocked"`
+	Promoted int  `json:"promoted"`
+}
+
+func (u *UserStats) DriverMarshal(value controllerModel.UserStats) {
+	u.User.DriverMarshal(value.User)
+	u.Ingested = value.Ingested
+	u.Blocked = value.Blocked
+	u.Promoted = value.Promoted
+}
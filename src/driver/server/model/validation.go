@@ -0,0 +1,15 @@
+package controller
+
+import (
+	controllerModel "scraper-backend/src/adapter/controller/model"
+)
+
+type ValidationError struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (ve *ValidationError) DriverMarshal(value controllerModel.ValidationError) {
+	ve.Rule = value.Rule
+	ve.Message = value.Message
+}
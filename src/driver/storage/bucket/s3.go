@@ -2,10 +2,14 @@ package bucket
 
 import (
 	"context"
+	"errors"
 	"io"
+	"net/http"
 	"path/filepath"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsHttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 
@@ -30,7 +34,7 @@ func (s *S3) ItemCreate(ctx context.Context, buffer io.Reader, bucketName, path
 		Body:   buffer,
 	})
 	if err != nil {
-		return nil
+		return err
 	}
 	return nil
 }
@@ -52,6 +56,39 @@ func (s *S3) ItemRead(ctx context.Context, bucketName, path string) ([]byte, err
 	return buffer, nil
 }
 
+func (s *S3) ItemExists(ctx context.Context, bucketName, path string) (bool, error) {
+	_, err := s.Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(path),
+	})
+	if err != nil {
+		var responseError *awsHttp.ResponseError
+		if errors.As(err, &responseError) && responseError.HTTPStatusCode() == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *S3) ItemList(ctx context.Context, bucketName, prefix string) (map[string]time.Time, error) {
+	paths := map[string]time.Time{}
+	paginator := s3.NewListObjectsV2Paginator(s.Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			paths[aws.ToString(object.Key)] = aws.ToTime(object.LastModified)
+		}
+	}
+	return paths, nil
+}
+
 func (s *S3) ItemCopy(ctx context.Context, bucketName, sourcePath, destinationPath string) error {
 	sourceUrl := filepath.Join(bucketName, sourcePath)
 	_, err := s.Client.CopyObject(ctx, &s3.CopyObjectInput{
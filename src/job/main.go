@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"scraper-backend/src/adapter/controller"
+	controllerModel "scraper-backend/src/adapter/controller/model"
+	"scraper-backend/src/util"
+)
+
+// maintenance jobs run outside of the server, e.g. `go run src/job/main.go reconcile -fix`
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("usage: job <reconcile|migrate-keys|migrate-tags|backfill-sizes|evaluate|sweep-blocked> [flags]")
+	}
+
+	config, err := util.NewConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// the mutations of a job are recorded under its name
+	ctx := controllerModel.ContextWithActor(context.Background(), "job/"+os.Args[1])
+
+	controllerPicture := controller.ConstructorPicture(*config)
+
+	var report any
+	switch os.Args[1] {
+	case "reconcile":
+		flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+		fix := flags.Bool("fix", false, "delete the files without picture and the pictures without file")
+		flags.Parse(os.Args[2:])
+		report, err = controllerPicture.ReconcilePictures(ctx, *fix)
+	case "migrate-keys":
+		report, err = controllerPicture.MigratePictureKeys(ctx)
+	case "migrate-tags":
+		report, err = controllerPicture.MigratePictureTags(ctx)
+	case "backfill-sizes":
+		flags := flag.NewFlagSet("backfill-sizes", flag.ExitOnError)
+		fix := flags.Bool("fix", false, "write the dimensions of the files to the sizes")
+		flags.Parse(os.Args[2:])
+		report, err = controllerPicture.BackfillPictureSizes(ctx, *fix)
+	case "evaluate":
+		flags := flag.NewFlagSet("evaluate", flag.ExitOnError)
+		model := flags.String("model", "", "model whose predictions are evaluated")
+		weights := flags.String("weights", "", "weights of the model, every weights when empty")
+		state := flags.String("state", controllerModel.PictureStateValidation, "table of the pictures, validation or production")
+		iou := flags.Float64("iou", 0.5, "IoU from which a prediction matches a box drawn by hand")
+		worst := flags.Int("worst", 10, "amount of pictures with the lowest F1 listed")
+		flags.Parse(os.Args[2:])
+		report, err = controllerPicture.EvaluatePredictions(ctx, controllerModel.EvaluationFilter{
+			State:   *state,
+			Model:   *model,
+			Weights: *weights,
+			IoU:     *iou,
+			Worst:   *worst,
+		})
+	case "sweep-blocked":
+		report, err = controllerPicture.SweepPicturesBlocked(ctx)
+	default:
+		log.Fatalf("job `%s` not available", os.Args[1])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	output, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(output))
+}
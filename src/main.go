@@ -15,11 +15,13 @@ func main() {
 
 	controllerPicture := controller.ConstructorPicture(*config)
 	constrollerTag := controller.ConstructorTag(*config, controllerPicture)
-	constrollerUser := controller.ConstructorUser(*config)
+	constrollerUser := controller.ConstructorUser(*config, controllerPicture)
+	controllerAudit := controller.ConstructorAudit(*config)
+	controllerTask := controller.ConstructorTask(*config, controllerPicture)
 	controllerFlickr := controller.ConstructorFlickr(*config, controllerPicture, constrollerTag, constrollerUser)
 	controllerPexels := controller.ConstructorPexels(*config, controllerPicture, constrollerTag, constrollerUser)
 	controllerUnsplash := controller.ConstructorUnsplash(*config, controllerPicture, constrollerTag, constrollerUser)
 
-	server := server.Contructor(controllerPicture, constrollerTag, constrollerUser, controllerFlickr, controllerPexels, controllerUnsplash)
+	server := server.Contructor(controllerPicture, constrollerTag, constrollerUser, controllerAudit, controllerTask, controllerFlickr, controllerPexels, controllerUnsplash)
 	server.Router(config.Port, config.HealthCheckPath)
 }
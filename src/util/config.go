@@ -13,6 +13,7 @@ import (
 	awsCredentials "github.com/aws/aws-sdk-go-v2/credentials"
 	awsDynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"golang.org/x/exp/slices"
 )
 
 type AwsDynamodbTable struct {
@@ -28,6 +29,12 @@ type Config struct {
 	HealthCheckPath                   string
 	AwsS3Client                       *awsS3.Client
 	S3BucketNamePictures              string
+	RenditionWidths                   []int
+	EncodingNormalizeFormat           string
+	EncodingQuality                   int
+	EncodingStripMetadata             bool
+	QualityGate                       config.ConfigQualityGate
+	Admins                            []string
 	AwsDynamodbClient                 *awsDynamodb.Client
 	AwsDynamodbTablePictureProcess    AwsDynamodbTable
 	AwsDynamodbTablePictureValidation AwsDynamodbTable
@@ -35,6 +42,8 @@ type Config struct {
 	AwsDynamodbTablePictureBlocked    AwsDynamodbTable
 	AwsDynamodbTableTag               AwsDynamodbTable
 	AwsDynamodbTableUser              AwsDynamodbTable
+	AwsDynamodbTableAudit             AwsDynamodbTable
+	AwsDynamodbTableTask              AwsDynamodbTable
 }
 
 func NewConfig() (*Config, error) {
@@ -89,9 +98,22 @@ func NewConfig() (*Config, error) {
 	TableUserSortKeyName := *configYml.Databases["tableUser"].SortKeyName
 	TableUserPrimaryKeyType := *configYml.Databases["tableUser"].PrimaryKeyType
 	TableUserSortKeyType := *configYml.Databases["tableUser"].SortKeyType
+	TableAuditName := commonName + "-" + *configYml.Databases["tableAudit"].Name
+	TableAuditPrimaryKeyName := *configYml.Databases["tableAudit"].PrimaryKeyName
+	TableAuditSortKeyName := *configYml.Databases["tableAudit"].SortKeyName
+	TableAuditPrimaryKeyType := *configYml.Databases["tableAudit"].PrimaryKeyType
+	TableAuditSortKeyType := *configYml.Databases["tableAudit"].SortKeyType
+	TableTaskName := commonName + "-" + *configYml.Databases["tableTask"].Name
+	TableTaskPrimaryKeyName := *configYml.Databases["tableTask"].PrimaryKeyName
+	TableTaskSortKeyName := *configYml.Databases["tableTask"].SortKeyName
+	TableTaskPrimaryKeyType := *configYml.Databases["tableTask"].PrimaryKeyType
+	TableTaskSortKeyType := *configYml.Databases["tableTask"].SortKeyType
 
 	s3BucketNamePictures := commonName + "-" + *configYml.Buckets["picture"].Name
 
+	renditionWidths := slices.Clone(configYml.Renditions.Widths)
+	slices.Sort(renditionWidths)
+
 	switch cloudHost {
 	case "aws":
 		optFnsRegion := awsConfig.WithRegion(awsRegion)
@@ -190,16 +212,42 @@ func NewConfig() (*Config, error) {
 		); err != nil {
 			return nil, err
 		}
+		if err := client.DynamodbCreateTableStandardPkSk(
+			AwsDynamodbClient,
+			TableAuditName,
+			TableAuditPrimaryKeyName,
+			TableAuditPrimaryKeyType,
+			TableAuditSortKeyName,
+			TableAuditSortKeyType,
+		); err != nil {
+			return nil, err
+		}
+		if err := client.DynamodbCreateTableStandardPkSk(
+			AwsDynamodbClient,
+			TableTaskName,
+			TableTaskPrimaryKeyName,
+			TableTaskPrimaryKeyType,
+			TableTaskSortKeyName,
+			TableTaskSortKeyType,
+		); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, fmt.Errorf("cloud host variable not valid: %s", cloudHost)
 	}
 
 	config := Config{
-		Port:                 port,
-		HealthCheckPath:      healthCheckPath,
-		AwsS3Client:          AwsS3Client,
-		S3BucketNamePictures: s3BucketNamePictures,
-		AwsDynamodbClient:    AwsDynamodbClient,
+		Port:                    port,
+		HealthCheckPath:         healthCheckPath,
+		AwsS3Client:             AwsS3Client,
+		S3BucketNamePictures:    s3BucketNamePictures,
+		RenditionWidths:         renditionWidths,
+		EncodingNormalizeFormat: configYml.Encoding.NormalizeFormat,
+		EncodingQuality:         configYml.Encoding.Quality,
+		EncodingStripMetadata:   configYml.Encoding.StripMetadata,
+		QualityGate:             configYml.QualityGate,
+		Admins:                  configYml.Admins,
+		AwsDynamodbClient:       AwsDynamodbClient,
 		AwsDynamodbTablePictureProcess: AwsDynamodbTable{
 			TableName:      TablePictureProcessName,
 			PrimaryKeyName: TablePictureProcessPrimaryKeyName,
@@ -242,6 +290,20 @@ func NewConfig() (*Config, error) {
 			SortKeyName:    &TableUserSortKeyName,
 			SortKeyType:    &TableUserSortKeyType,
 		},
+		AwsDynamodbTableAudit: AwsDynamodbTable{
+			TableName:      TableAuditName,
+			PrimaryKeyName: TableAuditPrimaryKeyName,
+			PrimaryKeyType: TableAuditPrimaryKeyType,
+			SortKeyName:    &TableAuditSortKeyName,
+			SortKeyType:    &TableAuditSortKeyType,
+		},
+		AwsDynamodbTableTask: AwsDynamodbTable{
+			TableName:      TableTaskName,
+			PrimaryKeyName: TableTaskPrimaryKeyName,
+			PrimaryKeyType: TableTaskPrimaryKeyType,
+			SortKeyName:    &TableTaskSortKeyName,
+			SortKeyType:    &TableTaskSortKeyType,
+		},
 	}
 
 	return &config, nil